@@ -0,0 +1,202 @@
+package httpc
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Struct tags naming the request part a field is bound from
+const (
+	sourcePath   = "path"
+	sourceQuery  = "query"
+	sourceHeader = "header"
+	sourceCookie = "cookie"
+	sourceBody   = "body"
+)
+
+var fieldSourceTags = []string{sourcePath, sourceQuery, sourceHeader, sourceCookie, sourceBody}
+
+// fieldSource returns the source tag of a field and the key it is bound
+// from. The key defaults to the field name when the tag value is empty.
+func fieldSource(field reflect.StructField) (source, key string, ok bool) {
+	for _, tag := range fieldSourceTags {
+		value, found := field.Tag.Lookup(tag)
+		if !found {
+			continue
+		}
+		key = strings.Split(value, ",")[0]
+		if key == "" {
+			key = field.Name
+		}
+		return tag, key, true
+	}
+	return "", "", false
+}
+
+// boundElsewhere reports whether a field carries a source tag that is not
+// among tags, meaning it is bound from another part of the request
+func boundElsewhere(field reflect.StructField, tags []string) bool {
+	source, _, ok := fieldSource(field)
+	if !ok {
+		return false
+	}
+	for _, tag := range tags {
+		if tag == source {
+			return false
+		}
+	}
+	return true
+}
+
+// sourceField is a top-level or embedded struct field with a source tag
+type sourceField struct {
+	field  reflect.StructField
+	index  []int
+	source string
+	key    string
+}
+
+// sourceFields lists the fields of t that carry a source tag, including
+// those promoted from embedded structs
+func sourceFields(t reflect.Type) []sourceField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var fields []sourceField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if source, key, ok := fieldSource(field); ok {
+			fields = append(fields, sourceField{field: field, index: field.Index, source: source, key: key})
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for _, nested := range sourceFields(field.Type) {
+				nested.index = append([]int{i}, nested.index...)
+				fields = append(fields, nested)
+			}
+		}
+	}
+	return fields
+}
+
+// bodyField returns the field tagged `body`, if any
+func bodyField(t reflect.Type) (sourceField, bool) {
+	for _, f := range sourceFields(t) {
+		if f.source == sourceBody {
+			return f, true
+		}
+	}
+	return sourceField{}, false
+}
+
+// pathParams returns the names of the path parameters bound by t, in field order
+func pathParams(t reflect.Type) []string {
+	var names []string
+	for _, f := range sourceFields(t) {
+		if f.source == sourcePath {
+			names = append(names, f.key)
+		}
+	}
+	return names
+}
+
+// bindInput fills the struct pointed to by ptr from every part of the
+// request. The body (or the `body` field) is decoded first, or the query
+// string for GET, then path, query, header and cookie fields are applied on
+// top. A multipart/form-data body also binds File fields, within the limits
+// of uploads. The returned status describes the failure.
+func bindInput(c *gin.Context, m MethodInfo, ptr interface{}, uploads uploadConfig) (int, error) {
+	v := reflect.ValueOf(ptr).Elem()
+	fields := sourceFields(v.Type())
+
+	if m.HTTPMethod == http.MethodGet {
+		if err := decodeValues(c.Request.URL.Query(), ptr, queryTags...); err != nil {
+			return http.StatusBadRequest, fmt.Errorf("invalid query: %w", err)
+		}
+	} else if !(len(fields) > 0 && c.Request.ContentLength == 0) {
+		target := ptr
+		if body, ok := bodyField(v.Type()); ok {
+			target = v.FieldByIndex(body.index).Addr().Interface()
+		}
+		if c.ContentType() == MediaTypeMultipart {
+			if status, err := decodeMultipart(c, target, uploads); err != nil {
+				return status, err
+			}
+		} else if status, err := decodeBody(c, target, m.StrictJSON); err != nil {
+			return status, err
+		}
+	}
+
+	query := c.Request.URL.Query()
+	for _, f := range fields {
+		var raw []string
+		switch f.source {
+		case sourcePath:
+			if value, ok := c.Params.Get(f.key); ok {
+				raw = []string{value}
+			}
+		case sourceQuery:
+			if m.HTTPMethod == http.MethodGet {
+				continue // Already bound with the rest of the query string
+			}
+			raw = query[f.key]
+		case sourceHeader:
+			raw = c.Request.Header.Values(f.key)
+		case sourceCookie:
+			if cookie, err := c.Request.Cookie(f.key); err == nil {
+				raw = []string{cookie.Value}
+			}
+		default:
+			continue
+		}
+		if len(raw) == 0 {
+			continue
+		}
+		if err := setValues(v.FieldByIndex(f.index), raw, f.field); err != nil {
+			return http.StatusBadRequest, fmt.Errorf("invalid %s parameter %q for field %s: %w", f.source, f.key, f.field.Name, err)
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// routePath returns the route of a method under prefix, using Gin's
+// ":param" syntax. Without an explicit MethodInfo.Path, a segment is
+// appended for each `path` field of the input.
+func routePath(prefix string, m MethodInfo) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if m.Path != "" {
+		return fmt.Sprintf("%s/%s", prefix, strings.TrimPrefix(m.Path, "/"))
+	}
+	path := fmt.Sprintf("%s/%s", prefix, m.Name)
+	if m.InputType != nil && m.Kind != MethodWebSocket {
+		for _, name := range pathParams(m.InputType) {
+			path += "/:" + name
+		}
+	}
+	return path
+}
+
+// openAPIPath converts a Gin route to an OpenAPI path template
+func openAPIPath(route string) string {
+	segments := strings.Split(route, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	path := strings.Join(segments, "/")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
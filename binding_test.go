@@ -0,0 +1,104 @@
+package httpc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiSourceBinding(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	serverCfg := ServerConfig{
+		OtelEnabled: false,
+		Port:        8080,
+	}
+	clientCfg, err := config.New(config.WithDefault(map[string]interface{}{
+		"otel_enabled":            false,
+		"http_client_timeout_ms":  1000,
+		"http_client_max_retries": 0,
+	}))
+	require.NoError(t, err)
+
+	svc := &UserService{}
+	ts := setupServer(t, serverCfg, svc, "/v1")
+	defer ts.Close()
+
+	client, err := NewHTTPClient(clientCfg)
+	require.NoError(t, err)
+
+	t.Run("All Sources", func(t *testing.T) {
+		var result string
+		err := client.Call("PUT", ts.URL+"/v1/Update/42", UserPatch{Name: "Ann"}, &result,
+			WithHeader("X-Tenant", "acme"),
+			WithHeader("Cookie", "session=abc"),
+			WithQuery(url.Values{"dry_run": {"true"}}),
+		)
+		require.NoError(t, err)
+		require.Equal(t, "updated 42 for acme (session=abc dry_run=true): Ann", result)
+	})
+
+	t.Run("Invalid Path Parameter", func(t *testing.T) {
+		var result string
+		err := client.Call("PUT", ts.URL+"/v1/Update/abc", UserPatch{Name: "Ann"}, &result, WithHeader("X-Tenant", "acme"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "request failed with status 400")
+		require.Contains(t, err.Error(), `invalid path parameter "id" for field ID`)
+	})
+
+	t.Run("Validation Across Sources", func(t *testing.T) {
+		var result string
+		err := client.Call("PUT", ts.URL+"/v1/Update/42", UserPatch{Name: "Ann"}, &result)
+		var respErr *ResponseError
+		require.True(t, errors.As(err, &respErr))
+		require.Equal(t, []FieldError{{Field: "X-Tenant", In: "header", Rule: "required", Message: "X-Tenant is a required field"}}, respErr.Fields)
+
+		err = client.Call("PUT", ts.URL+"/v1/Update/42", UserPatch{}, &result, WithHeader("X-Tenant", "acme"))
+		require.True(t, errors.As(err, &respErr))
+		require.Equal(t, []FieldError{{Field: "name", In: "body", Rule: "required", Message: "name is a required field"}}, respErr.Fields)
+	})
+
+	t.Run("Explicit Path Without Body", func(t *testing.T) {
+		var result string
+		err := client.Call("DELETE", ts.URL+"/v1/users/7", nil, &result, WithHeader("X-Tenant", "acme"))
+		require.NoError(t, err)
+		require.Equal(t, "deleted 7 for acme", result)
+	})
+
+	t.Run("Swagger Parameters", func(t *testing.T) {
+		resp, err := http.Get(ts.URL + "/api/docs/swagger.json")
+		require.NoError(t, err)
+		var doc map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&doc))
+		paths := doc["paths"].(map[string]interface{})
+
+		require.Contains(t, paths, "/v1/Update/{id}")
+		require.Contains(t, paths, "/v1/users/{id}")
+
+		update := paths["/v1/Update/{id}"].(map[string]interface{})["put"].(map[string]interface{})
+		locations := map[string]string{}
+		for _, p := range update["parameters"].([]interface{}) {
+			param := p.(map[string]interface{})
+			locations[param["name"].(string)] = param["in"].(string)
+		}
+		require.Equal(t, map[string]string{"id": "path", "X-Tenant": "header", "session": "cookie", "dry_run": "query"}, locations)
+
+		schema := resolveRef(t, doc, update["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"])
+		require.Contains(t, schema["properties"], "name")
+		require.Contains(t, schema["properties"], "email")
+
+		del := paths["/v1/users/{id}"].(map[string]interface{})["delete"].(map[string]interface{})
+		require.NotContains(t, del, "requestBody")
+		require.Len(t, del["parameters"], 2)
+	})
+}
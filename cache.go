@@ -0,0 +1,551 @@
+package httpc
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultCacheMaxEntries is the default of http_client_cache_max_entries
+const defaultCacheMaxEntries = 1000
+
+// CachePolicy makes the GET responses of a method cacheable. Responses get a
+// strong ETag and the Cache-Control of the policy, and requests whose
+// If-None-Match matches are answered with 304 Not Modified. The zero value
+// lets clients store responses but revalidate them before each use.
+type CachePolicy struct {
+	MaxAge               time.Duration // How long a response stays fresh, no-cache when zero
+	SharedMaxAge         time.Duration // Freshness in shared caches, s-maxage
+	StaleWhileRevalidate time.Duration // How long a stale response may be used while revalidating
+	Private              bool          // Only the client may store responses, not shared caches
+	NoCache              bool          // Revalidate stored responses before each use, even within MaxAge
+	NoStore              bool          // Do not store responses at all
+	MustRevalidate       bool          // Never use stale responses
+	Immutable            bool          // Responses never change while fresh
+}
+
+// cacheControl returns the Cache-Control header of the policy
+func (p *CachePolicy) cacheControl() string {
+	if p.NoStore {
+		return "no-store"
+	}
+	var directives []string
+	if p.Private {
+		directives = append(directives, "private")
+	}
+	if p.MaxAge > 0 {
+		directives = append(directives, "max-age="+seconds(p.MaxAge))
+	}
+	if p.NoCache || p.MaxAge <= 0 {
+		directives = append(directives, "no-cache")
+	}
+	if p.SharedMaxAge > 0 {
+		directives = append(directives, "s-maxage="+seconds(p.SharedMaxAge))
+	}
+	if p.StaleWhileRevalidate > 0 {
+		directives = append(directives, "stale-while-revalidate="+seconds(p.StaleWhileRevalidate))
+	}
+	if p.MustRevalidate {
+		directives = append(directives, "must-revalidate")
+	}
+	if p.Immutable {
+		directives = append(directives, "immutable")
+	}
+	return strings.Join(directives, ", ")
+}
+
+// seconds formats d as a whole number of seconds for a Cache-Control directive
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
+
+// ETagger is implemented by outputs that supply their own entity tag, such as
+// a version or revision number, sparing the server from hashing the encoded
+// body. The tag must change whenever the output does.
+type ETagger interface {
+	ETag() string
+}
+
+// LastModifier is implemented by outputs that know when they last changed,
+// sent as Last-Modified and compared with If-Modified-Since
+type LastModifier interface {
+	LastModified() time.Time
+}
+
+// bodyETag returns a strong entity tag for an encoded body
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// suppliedETag quotes the tag of an ETagger, distinguishing the encodings of
+// the output other than JSON so each representation has its own strong tag
+func suppliedETag(tag string, codec Codec) string {
+	tag = strings.Trim(strings.TrimPrefix(tag, "W/"), `"`)
+	if _, ok := codec.(jsonCodec); !ok {
+		subtype := codec.MediaType()[strings.LastIndex(codec.MediaType(), "/")+1:]
+		tag += "-" + strings.TrimPrefix(subtype, "x-")
+	}
+	return `"` + tag + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, with the
+// weak comparison RFC 9110 uses for If-None-Match
+func etagMatches(ifNoneMatch, etag string) bool {
+	return matchingETag(ifNoneMatch, etag) != ""
+}
+
+// matchingETag returns the tag of an If-None-Match header matching etag, or
+// "" if none does. A tag that compressWriter suffixed with a content coding
+// matches the tag of the uncompressed representation.
+func matchingETag(ifNoneMatch, etag string) string {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(uncodedETag(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// codedETag returns the strong tag of the representation of etag compressed
+// with encoding, since RFC 9110 requires each content coding to have its own
+// strong validator. Weak tags are left unchanged.
+func codedETag(etag, encoding string) string {
+	if !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) || len(etag) < 2 {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + "-" + encoding + `"`
+}
+
+// uncodedETag removes the content coding codedETag added to etag
+func uncodedETag(etag string) string {
+	for _, encoding := range []string{EncodingGzip, EncodingZstd} {
+		if suffix := "-" + encoding + `"`; strings.HasSuffix(etag, suffix) {
+			return strings.TrimSuffix(etag, suffix) + `"`
+		}
+	}
+	return etag
+}
+
+// notModified reports whether the validators of a request show the client
+// already has the representation with etag and modTime. If-Modified-Since is
+// ignored when If-None-Match is present.
+func notModified(req *http.Request, etag string, modTime time.Time) bool {
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+	if modTime.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	return err == nil && !modTime.Truncate(time.Second).After(since)
+}
+
+// writeCached writes the result of a method with a CachePolicy, or 304 Not
+// Modified when the request's validators match it
+func writeCached(c *gin.Context, policy *CachePolicy, codec Codec, result interface{}) {
+	var body []byte
+	var etag string
+	if tagger, ok := result.(ETagger); ok {
+		etag = suppliedETag(tagger.ETag(), codec)
+	} else {
+		data, err := codec.Marshal(result)
+		if err != nil {
+			logger.ErrorContext(c.Request.Context(), "Failed to encode response", logger.String("media_type", codec.MediaType()), logger.ErrField(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode response: " + err.Error()})
+			return
+		}
+		body, etag = data, bodyETag(data)
+	}
+
+	header := c.Writer.Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", policy.cacheControl())
+	header.Add("Vary", "Accept")
+	var modTime time.Time
+	if modifier, ok := result.(LastModifier); ok {
+		if modTime = modifier.LastModified(); !modTime.IsZero() {
+			header.Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+		}
+	}
+	if notModified(c.Request, etag, modTime) {
+		if matched := matchingETag(c.GetHeader("If-None-Match"), etag); matched != "" && matched != "*" {
+			header.Set("ETag", matched) // The tag of the representation the client has
+		}
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	if body == nil {
+		data, err := codec.Marshal(result)
+		if err != nil {
+			logger.ErrorContext(c.Request.Context(), "Failed to encode response", logger.String("media_type", codec.MediaType()), logger.ErrField(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode response: " + err.Error()})
+			return
+		}
+		body = data
+	}
+	contentType := codec.MediaType()
+	if _, ok := codec.(jsonCodec); ok {
+		contentType = "application/json; charset=utf-8" // As written by c.JSON
+	}
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// CachedResponse is a response stored by the cache of an HTTPClient
+type CachedResponse struct {
+	StatusCode   int
+	Header       http.Header
+	Body         []byte
+	RequestTime  time.Time   // When the request the response answers was sent
+	ResponseTime time.Time   // When the response, or its last revalidation, was received
+	Vary         http.Header // Request headers named by the Vary of the response, as they were sent
+}
+
+// CacheStore stores the responses cached by an HTTPClient by key. It must be
+// safe for concurrent use; stored responses are not modified once set.
+type CacheStore interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse)
+	Delete(key string)
+}
+
+// MemoryCache is a CacheStore keeping up to a number of responses in memory,
+// evicting the least recently used
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List // Most recently used first
+}
+
+type memoryEntry struct {
+	key  string
+	resp *CachedResponse
+}
+
+// NewMemoryCache creates a MemoryCache holding up to maxEntries responses
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+func (m *MemoryCache) Get(key string) (*CachedResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(elem)
+	return elem.Value.(*memoryEntry).resp, true
+}
+
+func (m *MemoryCache) Set(key string, resp *CachedResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem, ok := m.entries[key]; ok {
+		elem.Value.(*memoryEntry).resp = resp
+		m.order.MoveToFront(elem)
+		return
+	}
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, resp: resp})
+	for m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem, ok := m.entries[key]; ok {
+		m.order.Remove(elem)
+		delete(m.entries, key)
+	}
+}
+
+// Len returns the number of responses stored
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// WithCache caches the GET responses of the client in store, replacing the
+// in-memory cache enabled by http_client_cache_enabled
+func WithCache(store CacheStore) ClientOption {
+	return func(h *HTTPClient) {
+		h.cache = store
+	}
+}
+
+// cacheControl parses the Cache-Control directives of header, lower-cased,
+// with their unquoted arguments
+func cacheControl(header http.Header) map[string]string {
+	directives := map[string]string{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name != "" {
+				directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+			}
+		}
+	}
+	return directives
+}
+
+// deltaSeconds parses the argument of a directive such as max-age
+func deltaSeconds(directives map[string]string, name string) (time.Duration, bool) {
+	arg, ok := directives[name]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || n < 0 {
+		return 0, true // An invalid value makes the response stale
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+// date returns the Date of the response, or when it was received
+func (r *CachedResponse) date() time.Time {
+	if date, err := http.ParseTime(r.Header.Get("Date")); err == nil {
+		return date
+	}
+	return r.ResponseTime
+}
+
+// freshness returns the freshness lifetime of the response: its max-age, else
+// the time from its Date to its Expires, else a tenth of the time since it
+// was last modified
+func (r *CachedResponse) freshness() time.Duration {
+	if maxAge, ok := deltaSeconds(cacheControl(r.Header), "max-age"); ok {
+		return maxAge
+	}
+	if expires := r.Header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(r.date())
+	}
+	if modTime, err := http.ParseTime(r.Header.Get("Last-Modified")); err == nil {
+		return r.date().Sub(modTime) / 10
+	}
+	return 0
+}
+
+// age returns the current age of the response, as computed by RFC 9111
+func (r *CachedResponse) age(now time.Time) time.Duration {
+	apparent := max(r.ResponseTime.Sub(r.date()), 0)
+	ageValue, _ := strconv.ParseInt(r.Header.Get("Age"), 10, 64)
+	corrected := time.Duration(ageValue)*time.Second + r.ResponseTime.Sub(r.RequestTime)
+	return max(apparent, corrected) + now.Sub(r.ResponseTime)
+}
+
+// fresh reports whether the response may answer req without revalidation
+func (r *CachedResponse) fresh(req *http.Request, now time.Time) bool {
+	reqDirectives := cacheControl(req.Header)
+	if _, ok := reqDirectives["no-cache"]; ok || req.Header.Get("Pragma") == "no-cache" {
+		return false
+	}
+	if _, ok := cacheControl(r.Header)["no-cache"]; ok {
+		return false
+	}
+	age := r.age(now)
+	if maxAge, ok := deltaSeconds(reqDirectives, "max-age"); ok && age > maxAge {
+		return false
+	}
+	return age < r.freshness()
+}
+
+// matches reports whether req selects the same variant as the request the
+// response answered
+func (r *CachedResponse) matches(req *http.Request) bool {
+	for name, values := range r.Vary {
+		if strings.Join(req.Header.Values(name), ",") != strings.Join(values, ",") {
+			return false
+		}
+	}
+	return true
+}
+
+// response returns the stored response as an answer to req
+func (r *CachedResponse) response(req *http.Request, now time.Time) *http.Response {
+	header := r.Header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(r.age(now)/time.Second), 10))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// revalidated returns the response updated with the headers of the 304
+// response that revalidated it
+func (r *CachedResponse) revalidated(notModified *http.Response, requestTime, responseTime time.Time) *CachedResponse {
+	updated := *r
+	updated.Header = r.Header.Clone()
+	for name, values := range notModified.Header {
+		if name != "Content-Length" {
+			updated.Header[name] = values
+		}
+	}
+	updated.RequestTime, updated.ResponseTime = requestTime, responseTime
+	return &updated
+}
+
+// storable reports whether a response may be stored by a private cache and
+// reused: it must be a 200 allowed to be stored, with a freshness lifetime or
+// a validator to revalidate it with
+func storable(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Vary") == "*" {
+		return false
+	}
+	directives := cacheControl(resp.Header)
+	if _, ok := directives["no-store"]; ok {
+		return false
+	}
+	_, maxAge := directives["max-age"]
+	return maxAge || resp.Header.Get("Expires") != "" || resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+// credentialed reports whether req carries credentials. Responses to such
+// requests are never stored, as in a shared cache (RFC 9111 section 3.5),
+// since one client may send the calls of several users.
+func credentialed(req *http.Request) bool {
+	return req != nil && (req.Header.Get("Authorization") != "" || req.Header.Get("Cookie") != "")
+}
+
+// cacheKey identifies the stored response of a request
+func cacheKey(req *http.Request) string {
+	return req.Method + " " + req.URL.String()
+}
+
+// sendCached answers GET requests from the cache when a stored response is
+// fresh, revalidates stale ones with their ETag or Last-Modified, and stores
+// new responses; next sends requests that the cache cannot answer. A
+// successful request with another method invalidates the response stored for
+// its URL. Requests carrying their own validators or credentials bypass the
+// cache, and responses to requests that interceptors or the transport gave
+// credentials are not stored.
+func (h *HTTPClient) sendCached(req *http.Request, co *callOptions, next RoundTripFunc) (*http.Response, error) {
+	key := cacheKey(&http.Request{Method: http.MethodGet, URL: req.URL})
+	if req.Method != http.MethodGet {
+		resp, err := next(req)
+		if err == nil && resp.StatusCode < 400 && req.Method != http.MethodHead && req.Method != http.MethodOptions {
+			h.cache.Delete(key)
+		}
+		return resp, err
+	}
+	if _, noStore := cacheControl(req.Header)["no-store"]; noStore || co.stream || credentialed(req) ||
+		req.Header.Get("Range") != "" || req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return next(req)
+	}
+
+	stored, ok := h.cache.Get(key)
+	if ok && !stored.matches(req) {
+		ok = false
+	}
+	if ok && stored.fresh(req, time.Now()) {
+		co.cached = true
+		return stored.response(req, time.Now()), nil
+	}
+
+	send := req
+	if ok {
+		etag, modified := stored.Header.Get("ETag"), stored.Header.Get("Last-Modified")
+		if etag != "" || modified != "" {
+			send = req.Clone(req.Context())
+			if etag != "" {
+				send.Header.Set("If-None-Match", etag)
+			} else {
+				send.Header.Set("If-Modified-Since", modified)
+			}
+		}
+	}
+	requestTime := time.Now()
+	resp, err := next(send)
+	if err != nil {
+		return nil, err
+	}
+	responseTime := time.Now()
+	if send != req && resp.StatusCode == http.StatusNotModified {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		updated := stored.revalidated(resp, requestTime, responseTime)
+		h.cache.Set(key, updated)
+		co.cached = true
+		return updated.response(req, responseTime), nil
+	}
+	if !storable(resp) || credentialed(resp.Request) {
+		return resp, nil
+	}
+	return h.store(key, req, resp, co, requestTime, responseTime)
+}
+
+// store reads the body of resp to store it under key, unless it exceeds the
+// size limit of the call, in which case the response is returned unstored
+func (h *HTTPClient) store(key string, req *http.Request, resp *http.Response, co *callOptions, requestTime, responseTime time.Time) (*http.Response, error) {
+	limit := co.maxResponseBytes
+	if limit <= 0 {
+		limit = math.MaxInt64 - 1
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if int64(len(body)) > limit {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	stored := &CachedResponse{
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header.Clone(),
+		Body:         body,
+		RequestTime:  requestTime,
+		ResponseTime: responseTime,
+		Vary:         http.Header{},
+	}
+	for _, value := range resp.Header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = http.CanonicalHeaderKey(strings.TrimSpace(name)); name != "" {
+				stored.Vary[name] = req.Header.Values(name)
+			}
+		}
+	}
+	h.cache.Set(key, stored)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	return resp, nil
+}
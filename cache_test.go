@@ -0,0 +1,463 @@
+package httpc
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/stretchr/testify/require"
+)
+
+// catalogItem is a cacheable output whose ETag is computed from its body
+type catalogItem struct {
+	ID    string `json:"id" xml:"id"`
+	Price int    `json:"price" xml:"price"`
+}
+
+// revision is a cacheable output supplying its own validators
+type revision struct {
+	Number  int       `json:"number"`
+	Changed time.Time `json:"changed"`
+}
+
+func (r revision) ETag() string            { return fmt.Sprintf("rev-%d", r.Number) }
+func (r revision) LastModified() time.Time { return r.Changed }
+
+// catalogService serves cacheable items, counting the calls of Item
+type catalogService struct {
+	calls atomic.Int32
+	price atomic.Int32
+}
+
+func (s *catalogService) Item(q fileQuery) (catalogItem, error) {
+	s.calls.Add(1)
+	return catalogItem{ID: q.Name, Price: int(s.price.Load())}, nil
+}
+
+func (s *catalogService) Revision(q fileQuery) (revision, error) {
+	return revision{Number: 3, Changed: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}, nil
+}
+
+func (s *catalogService) Fresh(q fileQuery) (catalogItem, error) {
+	return catalogItem{ID: q.Name}, nil
+}
+
+func (s *catalogService) RegisterMethods() []MethodInfo {
+	methods := []MethodInfo{
+		{Name: "Item", OutputType: reflect.TypeOf(catalogItem{}), Cache: &CachePolicy{}},
+		{Name: "Revision", OutputType: reflect.TypeOf(revision{}), Cache: &CachePolicy{MaxAge: time.Minute, Private: true}},
+		{Name: "Fresh", OutputType: reflect.TypeOf(catalogItem{})},
+	}
+	for i := range methods {
+		methods[i].HTTPMethod = "GET"
+		methods[i].InputType = reflect.TypeOf(fileQuery{})
+		methods[i].Func = reflect.ValueOf(s).MethodByName(methods[i].Name)
+	}
+	return methods
+}
+
+// newCatalogServer serves a catalogService at /catalog
+func newCatalogServer(t *testing.T) (*httptest.Server, *catalogService) {
+	c, err := config.New(config.WithDefault(map[string]interface{}{
+		"otel_enabled": false,
+		"port":         8080,
+	}))
+	require.NoError(t, err)
+	srv, err := NewServer(c)
+	require.NoError(t, err)
+	svc := &catalogService{}
+	require.NoError(t, srv.RegisterService(svc, WithPathPrefix("/catalog")))
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts, svc
+}
+
+// newCachingClient creates a client caching responses in memory
+func newCachingClient(t *testing.T, opts ...ClientOption) *HTTPClient {
+	c, err := config.New(config.WithDefault(map[string]interface{}{
+		"otel_enabled":              false,
+		"http_client_max_retries":   0,
+		"http_client_cache_enabled": true,
+	}))
+	require.NoError(t, err)
+	client, err := NewHTTPClient(c, opts...)
+	require.NoError(t, err)
+	return client
+}
+
+// cachedServer answers with the given response headers and an increasing
+// hit count, or 304 when If-None-Match matches its ETag
+func cachedServer(t *testing.T, header map[string]string) (*httptest.Server, *atomic.Int32) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		n := hits.Add(1)
+		for k, v := range header {
+			w.Header().Set(k, v)
+		}
+		if etag := header["ETag"]; etag != "" && r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", MediaTypeJSON)
+		fmt.Fprintf(w, `{"n":%d}`, n)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &hits
+}
+
+func TestServerCaching(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	get := func(t *testing.T, url string, header map[string]string) *http.Response {
+		req, err := http.NewRequest("GET", url, nil)
+		require.NoError(t, err)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("ETag From Body", func(t *testing.T) {
+		ts, svc := newCatalogServer(t)
+		resp := get(t, ts.URL+"/catalog/Item?name=a", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, `{"id":"a","price":0}`, readAll(t, resp))
+		require.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+		require.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+		etag := resp.Header.Get("ETag")
+		require.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+
+		resp = get(t, ts.URL+"/catalog/Item?name=a", map[string]string{"If-None-Match": `"other", ` + etag})
+		require.Equal(t, http.StatusNotModified, resp.StatusCode)
+		require.Equal(t, etag, resp.Header.Get("ETag"))
+		require.Empty(t, readAll(t, resp))
+
+		resp = get(t, ts.URL+"/catalog/Item?name=a", map[string]string{"If-None-Match": "W/" + etag})
+		require.Equal(t, http.StatusNotModified, resp.StatusCode, "If-None-Match uses the weak comparison")
+
+		// A changed body changes the tag
+		svc.price.Store(5)
+		resp = get(t, ts.URL+"/catalog/Item?name=a", map[string]string{"If-None-Match": etag})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NotEqual(t, etag, resp.Header.Get("ETag"))
+		require.Equal(t, int32(4), svc.calls.Load())
+
+		// Each representation has its own tag
+		resp = get(t, ts.URL+"/catalog/Item?name=a", map[string]string{"Accept": MediaTypeXML})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NotEqual(t, etag, resp.Header.Get("ETag"))
+		require.Contains(t, resp.Header.Values("Vary"), "Accept")
+	})
+
+	t.Run("Compressed Representations", func(t *testing.T) {
+		c, err := config.New(config.WithDefault(map[string]interface{}{
+			"otel_enabled":          false,
+			"port":                  8080,
+			"compression_enabled":   true,
+			"compression_min_bytes": 0,
+		}))
+		require.NoError(t, err)
+		srv, err := NewServer(c)
+		require.NoError(t, err)
+		require.NoError(t, srv.RegisterService(&catalogService{}, WithPathPrefix("/catalog")))
+		ts := httptest.NewServer(srv.Handler())
+		defer ts.Close()
+
+		resp := get(t, ts.URL+"/catalog/Item?name=a", map[string]string{"Accept-Encoding": "identity"})
+		identity := resp.Header.Get("ETag")
+		require.Empty(t, resp.Header.Get("Content-Encoding"))
+
+		tags := map[string]bool{identity: true}
+		for _, encoding := range []string{EncodingGzip, EncodingZstd} {
+			resp = get(t, ts.URL+"/catalog/Item?name=a", map[string]string{"Accept-Encoding": encoding})
+			require.Equal(t, encoding, resp.Header.Get("Content-Encoding"))
+			etag := resp.Header.Get("ETag")
+			require.Equal(t, strings.TrimSuffix(identity, `"`)+"-"+encoding+`"`, etag, "each coding has its own strong tag")
+			tags[etag] = true
+
+			resp = get(t, ts.URL+"/catalog/Item?name=a", map[string]string{"Accept-Encoding": encoding, "If-None-Match": etag})
+			require.Equal(t, http.StatusNotModified, resp.StatusCode)
+			require.Equal(t, etag, resp.Header.Get("ETag"))
+		}
+		require.Len(t, tags, 3)
+
+		// Supplied tags are suffixed the same way
+		resp = get(t, ts.URL+"/catalog/Revision?name=a", map[string]string{"Accept-Encoding": EncodingGzip})
+		require.Equal(t, `"rev-3-gzip"`, resp.Header.Get("ETag"))
+	})
+
+	t.Run("Supplied Validators", func(t *testing.T) {
+		ts, _ := newCatalogServer(t)
+		resp := get(t, ts.URL+"/catalog/Revision", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, `"rev-3"`, resp.Header.Get("ETag"))
+		require.Equal(t, "Sun, 01 Mar 2026 12:00:00 GMT", resp.Header.Get("Last-Modified"))
+		require.Equal(t, "private, max-age=60", resp.Header.Get("Cache-Control"))
+
+		require.Equal(t, http.StatusNotModified, get(t, ts.URL+"/catalog/Revision", map[string]string{"If-None-Match": `"rev-3"`}).StatusCode)
+		require.Equal(t, http.StatusNotModified, get(t, ts.URL+"/catalog/Revision", map[string]string{"If-Modified-Since": "Sun, 01 Mar 2026 12:00:00 GMT"}).StatusCode)
+		require.Equal(t, http.StatusOK, get(t, ts.URL+"/catalog/Revision", map[string]string{"If-Modified-Since": "Sat, 28 Feb 2026 12:00:00 GMT"}).StatusCode)
+		require.Equal(t, http.StatusOK, get(t, ts.URL+"/catalog/Revision", map[string]string{
+			"If-None-Match":     `"rev-2"`,
+			"If-Modified-Since": "Sun, 01 Mar 2026 12:00:00 GMT",
+		}).StatusCode, "If-Modified-Since is ignored with If-None-Match")
+	})
+
+	t.Run("Uncached Methods", func(t *testing.T) {
+		ts, _ := newCatalogServer(t)
+		resp := get(t, ts.URL+"/catalog/Fresh?name=a", nil)
+		require.Empty(t, resp.Header.Get("ETag"))
+		require.Empty(t, resp.Header.Get("Cache-Control"))
+	})
+
+	t.Run("Cache Control Directives", func(t *testing.T) {
+		for expected, policy := range map[string]CachePolicy{
+			"no-cache":                             {},
+			"max-age=30":                           {MaxAge: 30 * time.Second},
+			"private, max-age=30, no-cache":        {MaxAge: 30 * time.Second, Private: true, NoCache: true},
+			"no-store":                             {NoStore: true, MaxAge: time.Hour},
+			"max-age=3600, s-maxage=60":            {MaxAge: time.Hour, SharedMaxAge: time.Minute},
+			"max-age=60, immutable":                {MaxAge: time.Minute, Immutable: true},
+			"no-cache, must-revalidate":            {MustRevalidate: true},
+			"max-age=1, stale-while-revalidate=10": {MaxAge: time.Second, StaleWhileRevalidate: 10 * time.Second},
+		} {
+			require.Equal(t, expected, policy.cacheControl())
+		}
+	})
+
+	t.Run("Documented Not Modified", func(t *testing.T) {
+		ts, _ := newCatalogServer(t)
+		doc, _ := fetchDocument(t, ts)
+		paths := doc["paths"].(map[string]interface{})
+		responses := func(path string) map[string]interface{} {
+			return paths[path].(map[string]interface{})["get"].(map[string]interface{})["responses"].(map[string]interface{})
+		}
+		require.Contains(t, responses("/catalog/Item"), "304")
+		require.NotContains(t, responses("/catalog/Fresh"), "304")
+	})
+}
+
+func TestClientCaching(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	t.Run("Fresh Responses", func(t *testing.T) {
+		ts, hits := cachedServer(t, map[string]string{"Cache-Control": "max-age=60"})
+		client := newCachingClient(t)
+
+		var out tick
+		resp, err := client.CallWithResponse("GET", ts.URL, nil, &out)
+		require.NoError(t, err)
+		require.False(t, resp.Cached)
+		require.Equal(t, 1, out.N)
+
+		resp, err = client.CallWithResponse("GET", ts.URL, nil, &out)
+		require.NoError(t, err)
+		require.True(t, resp.Cached)
+		require.Zero(t, resp.Attempts)
+		require.Equal(t, 1, out.N)
+		require.Equal(t, `{"n":1}`, string(resp.Body))
+		require.NotEmpty(t, resp.Header.Get("Age"))
+		require.Equal(t, int32(1), hits.Load())
+
+		// Another query is another resource
+		require.NoError(t, client.Call("GET", ts.URL+"?page=2", nil, &out))
+		require.Equal(t, 2, out.N)
+	})
+
+	t.Run("Revalidation", func(t *testing.T) {
+		ts, hits := cachedServer(t, map[string]string{"Cache-Control": "no-cache", "ETag": `"v1"`})
+		client := newCachingClient(t)
+
+		var out tick
+		require.NoError(t, client.Call("GET", ts.URL, nil, &out))
+		resp, err := client.CallWithResponse("GET", ts.URL, nil, &out)
+		require.NoError(t, err)
+		require.True(t, resp.Cached)
+		require.Equal(t, 1, resp.Attempts)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 1, out.N, "the stored body answers a 304")
+		require.Equal(t, int32(2), hits.Load())
+	})
+
+	t.Run("Request Directives", func(t *testing.T) {
+		ts, hits := cachedServer(t, map[string]string{"Cache-Control": "max-age=60", "ETag": `"v1"`})
+		client := newCachingClient(t)
+
+		var out tick
+		require.NoError(t, client.Call("GET", ts.URL, nil, &out))
+		resp, err := client.CallWithResponse("GET", ts.URL, nil, &out, WithHeader("Cache-Control", "no-cache"))
+		require.NoError(t, err)
+		require.True(t, resp.Cached, "revalidated")
+		require.Equal(t, int32(2), hits.Load())
+
+		resp, err = client.CallWithResponse("GET", ts.URL, nil, &out, WithHeader("Cache-Control", "no-store"))
+		require.NoError(t, err)
+		require.False(t, resp.Cached)
+		require.Equal(t, 3, out.N)
+
+		// Validators set by the caller are passed through
+		resp, err = client.CallWithResponse("GET", ts.URL, nil, nil, WithHeader("If-None-Match", `"v1"`), WithExpectedStatus(http.StatusNotModified))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotModified, resp.StatusCode)
+	})
+
+	t.Run("Unstorable Responses", func(t *testing.T) {
+		for _, header := range []map[string]string{
+			{"Cache-Control": "no-store", "ETag": `"v1"`},
+			{"Cache-Control": "max-age=60", "Vary": "*"},
+			{},
+		} {
+			ts, hits := cachedServer(t, header)
+			client := newCachingClient(t)
+			var out tick
+			require.NoError(t, client.Call("GET", ts.URL, nil, &out))
+			require.NoError(t, client.Call("GET", ts.URL, nil, &out))
+			require.Equal(t, int32(2), hits.Load(), header)
+		}
+	})
+
+	t.Run("Vary", func(t *testing.T) {
+		ts, hits := cachedServer(t, map[string]string{"Cache-Control": "max-age=60", "Vary": "Accept-Language"})
+		client := newCachingClient(t)
+		var out tick
+		require.NoError(t, client.Call("GET", ts.URL, nil, &out, WithHeader("Accept-Language", "en")))
+		require.NoError(t, client.Call("GET", ts.URL, nil, &out, WithHeader("Accept-Language", "en")))
+		require.Equal(t, int32(1), hits.Load())
+		require.NoError(t, client.Call("GET", ts.URL, nil, &out, WithHeader("Accept-Language", "th")))
+		require.Equal(t, int32(2), hits.Load())
+	})
+
+	t.Run("Credentials", func(t *testing.T) {
+		var hits atomic.Int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			w.Header().Set("Cache-Control", "private, max-age=60")
+			w.Header().Set("Content-Type", MediaTypeJSON)
+			fmt.Fprintf(w, `{"user":%q}`, r.Header.Get("Authorization"))
+		}))
+		defer ts.Close()
+
+		var out struct {
+			User string `json:"user"`
+		}
+		// Two clients sharing a store, with tokens added by attempt interceptors
+		store := NewMemoryCache(10)
+		alice := newCachingClient(t, WithCache(store), WithInterceptor(BearerAuthInterceptor("alice")))
+		bob := newCachingClient(t, WithCache(store), WithInterceptor(BearerAuthInterceptor("bob")))
+		require.NoError(t, alice.Call("GET", ts.URL, nil, &out))
+		require.Equal(t, "Bearer alice", out.User)
+		require.NoError(t, bob.Call("GET", ts.URL, nil, &out))
+		require.Equal(t, "Bearer bob", out.User)
+		require.Equal(t, 0, store.Len())
+
+		// One client sending each user's token per call
+		client := newCachingClient(t, WithCache(store))
+		for _, token := range []string{"Bearer alice", "Bearer bob", "Bearer alice"} {
+			require.NoError(t, client.Call("GET", ts.URL, nil, &out, WithHeader("Authorization", token)))
+			require.Equal(t, token, out.User)
+		}
+		require.NoError(t, client.Call("GET", ts.URL, nil, &out, WithHeader("Cookie", "session=alice")))
+		require.Equal(t, int32(6), hits.Load())
+		require.Equal(t, 0, store.Len())
+	})
+
+	t.Run("Unsafe Methods Invalidate", func(t *testing.T) {
+		ts, hits := cachedServer(t, map[string]string{"Cache-Control": "max-age=60"})
+		client := newCachingClient(t)
+		var out tick
+		require.NoError(t, client.Call("GET", ts.URL, nil, &out))
+		require.NoError(t, client.Call("PUT", ts.URL, tick{N: 9}, nil))
+		require.NoError(t, client.Call("GET", ts.URL, nil, &out))
+		require.Equal(t, 2, out.N)
+		require.Equal(t, int32(2), hits.Load())
+	})
+
+	t.Run("Expires And Heuristic Freshness", func(t *testing.T) {
+		now := time.Now()
+		ts, hits := cachedServer(t, map[string]string{"Expires": now.Add(time.Hour).UTC().Format(http.TimeFormat)})
+		client := newCachingClient(t)
+		var out tick
+		require.NoError(t, client.Call("GET", ts.URL, nil, &out))
+		require.NoError(t, client.Call("GET", ts.URL, nil, &out))
+		require.Equal(t, int32(1), hits.Load())
+
+		stored := &CachedResponse{
+			Header: http.Header{
+				"Date":          {now.UTC().Format(http.TimeFormat)},
+				"Last-Modified": {now.Add(-100 * time.Hour).UTC().Format(http.TimeFormat)},
+			},
+			RequestTime:  now,
+			ResponseTime: now,
+		}
+		require.Equal(t, 10*time.Hour, stored.freshness())
+		req := httptest.NewRequest("GET", "/", nil)
+		require.True(t, stored.fresh(req, now.Add(9*time.Hour)))
+		require.False(t, stored.fresh(req, now.Add(11*time.Hour)))
+		req.Header.Set("Cache-Control", "max-age=60")
+		require.False(t, stored.fresh(req, now.Add(2*time.Minute)))
+	})
+
+	t.Run("Custom Store", func(t *testing.T) {
+		ts, hits := cachedServer(t, map[string]string{"Cache-Control": "max-age=60"})
+		store := NewMemoryCache(1)
+		client := newCachingClient(t, WithCache(store))
+		var out tick
+		require.NoError(t, client.Call("GET", ts.URL+"/a", nil, &out))
+		require.NoError(t, client.Call("GET", ts.URL+"/b", nil, &out))
+		require.Equal(t, 1, store.Len(), "the least recently used response is evicted")
+		require.NoError(t, client.Call("GET", ts.URL+"/a", nil, &out))
+		require.Equal(t, int32(3), hits.Load())
+		_, ok := store.Get("GET " + ts.URL + "/a")
+		require.True(t, ok)
+	})
+
+	t.Run("Caching Server Responses", func(t *testing.T) {
+		ts, svc := newCatalogServer(t)
+		client := newCachingClient(t)
+		var item catalogItem
+		require.NoError(t, client.Call("GET", ts.URL+"/catalog/Item?name=a", nil, &item))
+		resp, err := client.CallWithResponse("GET", ts.URL+"/catalog/Item?name=a", nil, &item)
+		require.NoError(t, err)
+		require.True(t, resp.Cached)
+		require.Equal(t, catalogItem{ID: "a"}, item)
+		require.Equal(t, int32(2), svc.calls.Load(), "no-cache responses are revalidated")
+
+		var rev revision
+		require.NoError(t, client.Call("GET", ts.URL+"/catalog/Revision", nil, &rev))
+		resp, err = client.CallWithResponse("GET", ts.URL+"/catalog/Revision", nil, &rev)
+		require.NoError(t, err)
+		require.True(t, resp.Cached)
+		require.Zero(t, resp.Attempts)
+		require.Equal(t, 3, rev.Number)
+	})
+
+	t.Run("Disabled By Default", func(t *testing.T) {
+		ts, hits := cachedServer(t, map[string]string{"Cache-Control": "max-age=60"})
+		client := newLimitedClient(t, 0)
+		var out tick
+		require.NoError(t, client.Call("GET", ts.URL, nil, &out))
+		require.NoError(t, client.Call("GET", ts.URL, nil, &out))
+		require.Equal(t, int32(2), hits.Load())
+	})
+}
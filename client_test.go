@@ -1,8 +1,11 @@
 package httpc
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	config "github.com/T-Prohmpossadhorn/go-core-config"
 	logger "github.com/T-Prohmpossadhorn/go-core-logger"
@@ -177,4 +180,98 @@ func TestHTTPClient(t *testing.T) {
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "invalid HTTP method: INVALID")
 	})
+
+	t.Run("Client Response Metadata", func(t *testing.T) {
+		attempts := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Location", "/v1/users/42")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"result":"created"}`))
+		}))
+		defer ts.Close()
+
+		cfgMap := map[string]interface{}{
+			"otel_enabled":                false,
+			"http_client_timeout_ms":      1000,
+			"http_client_max_retries":     2,
+			"http_client_disable_backoff": true,
+		}
+		config, err := config.New(config.WithDefault(cfgMap))
+		require.NoError(t, err)
+
+		client, err := NewHTTPClient(config)
+		require.NoError(t, err)
+
+		var output MultiOutput
+		resp, err := client.CallWithResponse("POST", ts.URL, MultiInput{Value: "x"}, &output)
+		require.NoError(t, err)
+		require.Equal(t, "created", output.Result)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.Equal(t, `"v1"`, resp.Header.Get("ETag"))
+		require.Equal(t, "/v1/users/42", resp.Header.Get("Location"))
+		require.JSONEq(t, `{"result":"created"}`, string(resp.Body))
+		require.Equal(t, 2, resp.Attempts)
+		require.Greater(t, resp.Duration, time.Duration(0))
+	})
+
+	t.Run("Client Empty Responses", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/empty" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer ts.Close()
+
+		cfgMap := map[string]interface{}{
+			"otel_enabled":            false,
+			"http_client_timeout_ms":  1000,
+			"http_client_max_retries": 2,
+		}
+		config, err := config.New(config.WithDefault(cfgMap))
+		require.NoError(t, err)
+
+		client, err := NewHTTPClient(config)
+		require.NoError(t, err)
+
+		var output MultiOutput
+		resp, err := client.CallWithResponse("DELETE", ts.URL+"/gone", nil, &output)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.Empty(t, resp.Body)
+
+		err = client.Call("GET", ts.URL+"/empty", nil, &output)
+		require.NoError(t, err)
+	})
+
+	t.Run("Client Error Response Metadata", func(t *testing.T) {
+		svc := &TestService{}
+		ts := setupServer(t, serverCfg, svc, "/v1")
+		defer ts.Close()
+
+		cfgMap := map[string]interface{}{
+			"otel_enabled":            false,
+			"http_client_timeout_ms":  1000,
+			"http_client_max_retries": 2,
+		}
+		config, err := config.New(config.WithDefault(cfgMap))
+		require.NoError(t, err)
+
+		client, err := NewHTTPClient(config)
+		require.NoError(t, err)
+
+		var result string
+		resp, err := client.CallWithResponse("POST", ts.URL+"/v1/Create", User{}, &result)
+		require.Error(t, err)
+		require.NotNil(t, resp)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Equal(t, 1, resp.Attempts)
+	})
 }
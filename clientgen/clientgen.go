@@ -0,0 +1,758 @@
+// Package clientgen generates typed Go clients from the OpenAPI documents
+// published by httpc servers. The generated package wraps an httpc.HTTPClient
+// with one method per operation, plus the request and response types the
+// operations use.
+//
+// GenerateServer works the other way round for contract-first APIs: it
+// generates the service interface of a document and a handler registering
+// its implementations with httpc.Server.RegisterService.
+package clientgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	httpc "github.com/T-Prohmpossadhorn/go-core-httpc"
+)
+
+// Options configures the generated package
+type Options struct {
+	Package string // Name of the generated package
+	Client  string // Name of the generated client type, "Client" by default
+	Service string // Name of the generated service interface, "Service" by default
+	Source  string // Where the document came from, written in the file header
+}
+
+// componentsPrefix is the $ref prefix of component schemas
+const componentsPrefix = "#/components/schemas/"
+
+// httpcImport is the import path of the package the generated client builds on
+const httpcImport = "github.com/T-Prohmpossadhorn/go-core-httpc"
+
+// Load reads an OpenAPI document in JSON or YAML from a file or an http(s) URL
+func Load(location string) (map[string]interface{}, error) {
+	var data []byte
+	var err error
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		data, err = fetch(location)
+	} else {
+		data, err = os.ReadFile(location)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI document %s: %w", location, err)
+	}
+	return Parse(data)
+}
+
+func fetch(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Parse decodes an OpenAPI document in JSON or YAML
+func Parse(data []byte) (map[string]interface{}, error) {
+	return httpc.ParseOpenAPI(data)
+}
+
+// FromServer returns the document of s, as served at swagger.json
+func FromServer(s *httpc.Server) (map[string]interface{}, error) {
+	return s.OpenAPIDocument()
+}
+
+// Generate returns the gofmt-ed source of a client package for doc
+func Generate(doc map[string]interface{}, opts Options) ([]byte, error) {
+	if opts.Client == "" {
+		opts.Client = "Client"
+	}
+	return generate(doc, opts, false)
+}
+
+// GenerateServer returns the gofmt-ed source of a server stub package for
+// doc: the service interface, its input and output types, and a handler whose
+// RegisterMethods routes the document's operations to an implementation
+func GenerateServer(doc map[string]interface{}, opts Options) ([]byte, error) {
+	if opts.Service == "" {
+		opts.Service = "Service"
+	}
+	return generate(doc, opts, true)
+}
+
+func generate(doc map[string]interface{}, opts Options, server bool) ([]byte, error) {
+	if opts.Package == "" {
+		return nil, fmt.Errorf("package name is required")
+	}
+	version, _ := doc["openapi"].(string)
+	if !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q", version)
+	}
+
+	g := newGenerator(doc, opts, server)
+	if err := g.generate(); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(g.file())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return src, nil
+}
+
+// operationMethods lists the operation keys of a path item in generation order
+var operationMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// operation is an OpenAPI operation with the Go declarations generated for it
+type operation struct {
+	name       string
+	httpMethod string
+	path       string
+	spec       map[string]interface{}
+	params     []param
+	paramsType string
+	bodyType   string
+	outputType string
+	inputType  string // Input of the service method, in server stubs
+	websocket  bool   // WebSocket operation receiving inputType and sending outputType messages
+}
+
+// param is an operation parameter bound to a field of its params struct
+type param struct {
+	name     string
+	in       string
+	field    string
+	goType   string
+	required bool
+}
+
+// typeDecl is a generated named type
+type typeDecl struct {
+	name   string
+	doc    string
+	fields []field
+	alias  string // Underlying type of non-struct declarations
+}
+
+// field is a field of a generated struct
+type field struct {
+	name     string
+	goType   string
+	tag      string
+	doc      string
+	embedded bool
+}
+
+type generator struct {
+	doc        map[string]interface{}
+	opts       Options
+	server     bool // Generate a server stub instead of a client
+	components map[string]interface{}
+	refNames   map[string]string // Component name to Go type name
+	used       map[string]bool   // Go identifiers taken at package level
+	methods    map[string]bool   // Method names taken on the client or handler
+	decls      map[string]*typeDecl
+	pending    []string // Components referenced but not yet declared
+	imports    map[string]bool
+	operations []*operation
+	body       bytes.Buffer
+}
+
+func newGenerator(doc map[string]interface{}, opts Options, server bool) *generator {
+	g := &generator{
+		doc:      doc,
+		opts:     opts,
+		server:   server,
+		refNames: map[string]string{},
+		used:     map[string]bool{opts.Client: true, "New" + opts.Client: true},
+		methods:  map[string]bool{},
+		decls:    map[string]*typeDecl{},
+		imports:  map[string]bool{httpcImport: true},
+	}
+	if server {
+		handler := opts.Service + "Handler"
+		g.used = map[string]bool{opts.Service: true, handler: true, "New" + handler: true}
+		g.methods["RegisterMethods"] = true
+		g.imports["reflect"] = true
+	}
+	if components, ok := doc["components"].(map[string]interface{}); ok {
+		g.components, _ = components["schemas"].(map[string]interface{})
+	}
+	return g
+}
+
+func (g *generator) generate() error {
+	paths, _ := g.doc["paths"].(map[string]interface{})
+	for _, path := range sortedKeys(paths) {
+		item, _ := paths[path].(map[string]interface{})
+		for _, method := range operationMethods {
+			spec, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			op, err := g.operation(path, method, spec)
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+			g.operations = append(g.operations, op)
+		}
+	}
+	for len(g.pending) > 0 {
+		name := g.pending[0]
+		g.pending = g.pending[1:]
+		schema, ok := g.components[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("unresolved reference %s%s", componentsPrefix, name)
+		}
+		g.declare(g.refNames[name], schema, fmt.Sprintf("is the %s schema", name))
+	}
+
+	if g.server {
+		g.writeService()
+	} else {
+		for _, op := range g.operations {
+			g.writeOperation(op)
+		}
+	}
+	names := make([]string, 0, len(g.decls))
+	for name := range g.decls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g.writeDecl(g.decls[name])
+	}
+	return nil
+}
+
+// operation resolves the Go types of an operation's parameters, body and response
+func (g *generator) operation(path, method string, spec map[string]interface{}) (*operation, error) {
+	id, _ := spec["operationId"].(string)
+	if id == "" {
+		id = method + "_" + path
+	}
+	op := &operation{
+		name:       uniqueName(exportedName(id), g.methods),
+		httpMethod: strings.ToUpper(method),
+		path:       path,
+		spec:       spec,
+	}
+
+	// WebSocket operations exchange the messages of the httpc x-websocket extension
+	if messages, ok := spec["x-websocket"].(map[string]interface{}); ok {
+		in, _ := messages["clientMessage"].(map[string]interface{})
+		out, _ := messages["serverMessage"].(map[string]interface{})
+		op.websocket = true
+		op.inputType = g.goType(in, op.name+"ClientMessage")
+		op.outputType = g.goType(out, op.name+"ServerMessage")
+		g.imports["context"] = true
+		return op, nil
+	}
+
+	params, _ := spec["parameters"].([]interface{})
+	var fields []field
+	fieldNames := map[string]bool{}
+	for _, p := range params {
+		spec, _ := p.(map[string]interface{})
+		name, _ := spec["name"].(string)
+		in, _ := spec["in"].(string)
+		if name == "" || in == "" {
+			return nil, fmt.Errorf("parameter without name or location")
+		}
+		schema, _ := spec["schema"].(map[string]interface{})
+		required, _ := spec["required"].(bool)
+		fieldName := uniqueName(exportedName(name), fieldNames)
+		goType := g.goType(schema, op.name+fieldName)
+		op.params = append(op.params, param{name: name, in: in, field: fieldName, goType: goType, required: required})
+		tag := fmt.Sprintf("%s:%q", in, name)
+		if rules := validateTag(schema, required); rules != "" {
+			tag += fmt.Sprintf(" validate:%q", rules)
+		}
+		description, _ := spec["description"].(string)
+		fields = append(fields, field{name: fieldName, goType: goType, tag: tag, doc: description})
+	}
+	if len(fields) > 0 {
+		op.paramsType = g.reserve(op.name + "Params")
+		g.decls[op.paramsType] = &typeDecl{
+			name:   op.paramsType,
+			doc:    fmt.Sprintf("%s holds the parameters of %s", op.paramsType, op.name),
+			fields: fields,
+		}
+	}
+
+	if body, ok := spec["requestBody"].(map[string]interface{}); ok {
+		if schema, ok := contentSchema(body["content"]); ok {
+			op.bodyType = g.goType(schema, op.name+"Body")
+		}
+	}
+	responses, _ := spec["responses"].(map[string]interface{})
+	for _, status := range sortedKeys(responses) {
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+		response, _ := responses[status].(map[string]interface{})
+		if schema, ok := contentSchema(response["content"]); ok {
+			op.outputType = g.goType(schema, op.name+"Response")
+		}
+		break
+	}
+	if g.server {
+		g.serverTypes(op, fields, fieldNames)
+	}
+	return op, nil
+}
+
+// serverTypes sets the input and output types of op's service method. The
+// input is the params struct, the body, or both combined with the body bound
+// from a `body` field.
+func (g *generator) serverTypes(op *operation, params []field, fieldNames map[string]bool) {
+	switch {
+	case op.paramsType != "" && op.bodyType != "":
+		delete(g.decls, op.paramsType)
+		delete(g.used, op.paramsType)
+		op.paramsType = ""
+		op.inputType = g.reserve(op.name + "Input")
+		body := field{name: uniqueName("Body", fieldNames), goType: op.bodyType, tag: `body:""`}
+		g.decls[op.inputType] = &typeDecl{
+			name:   op.inputType,
+			doc:    fmt.Sprintf("%s holds the parameters and body of %s", op.inputType, op.name),
+			fields: append(params, body),
+		}
+	case op.paramsType != "":
+		op.inputType = op.paramsType
+	case op.bodyType != "":
+		op.inputType = op.bodyType
+	default:
+		op.inputType = "struct{}"
+	}
+	if op.outputType == "" {
+		op.outputType = "struct{}"
+	}
+}
+
+// contentSchema returns the JSON schema of a content map, or its first media type's
+func contentSchema(content interface{}) (map[string]interface{}, bool) {
+	media, _ := content.(map[string]interface{})
+	if len(media) == 0 {
+		return nil, false
+	}
+	mediaType, ok := media["application/json"].(map[string]interface{})
+	if !ok {
+		mediaType, _ = media[sortedKeys(media)[0]].(map[string]interface{})
+	}
+	schema, ok := mediaType["schema"].(map[string]interface{})
+	return schema, ok
+}
+
+// reserve returns name, or name with a numeric suffix when it is already taken
+func (g *generator) reserve(name string) string {
+	return uniqueName(name, g.used)
+}
+
+func uniqueName(name string, used map[string]bool) string {
+	candidate := name
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s%d", name, n)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// refType returns the Go type of a component reference, queueing its declaration
+func (g *generator) refType(ref string) string {
+	name := strings.TrimPrefix(ref, componentsPrefix)
+	if goName, ok := g.refNames[name]; ok {
+		return goName
+	}
+	goName := g.reserve(exportedName(name))
+	g.refNames[name] = goName
+	g.pending = append(g.pending, name)
+	return goName
+}
+
+// goType returns the Go type of schema. Inline objects are declared as named
+// types called hint.
+func (g *generator) goType(schema map[string]interface{}, hint string) string {
+	if schema == nil {
+		return "interface{}"
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		return g.refType(ref)
+	}
+	if ref, ok := nullableRef(schema); ok {
+		return "*" + g.refType(ref)
+	}
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		if len(allOf) == 1 {
+			sub, _ := allOf[0].(map[string]interface{})
+			return g.goType(sub, hint)
+		}
+		return g.inlineStruct(schema, hint)
+	}
+
+	typ, nullable := schemaType(schema)
+	var goType string
+	switch typ {
+	case "string":
+		switch schema["format"] {
+		case "date-time":
+			g.imports["time"] = true
+			goType = "time.Time"
+		case "byte":
+			return "[]byte"
+		default:
+			goType = "string"
+		}
+	case "integer":
+		switch schema["format"] {
+		case "int32":
+			goType = "int32"
+		case "int64":
+			goType = "int64"
+		default:
+			goType = "int"
+		}
+	case "number":
+		if schema["format"] == "float" {
+			goType = "float32"
+		} else {
+			goType = "float64"
+		}
+	case "boolean":
+		goType = "bool"
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		return "[]" + g.goType(items, hint+"Item")
+	case "object", "":
+		if _, ok := schema["properties"]; ok {
+			goType = g.inlineStruct(schema, hint)
+			break
+		}
+		if values, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+			return "map[string]" + g.goType(values, hint+"Value")
+		}
+		if typ == "object" {
+			return "map[string]interface{}"
+		}
+		return "interface{}"
+	default:
+		return "interface{}"
+	}
+	if nullable {
+		return "*" + goType
+	}
+	return goType
+}
+
+// inlineStruct declares an anonymous object schema as a named struct
+func (g *generator) inlineStruct(schema map[string]interface{}, hint string) string {
+	name := g.reserve(hint)
+	g.declare(name, schema, "is an inline schema")
+	return name
+}
+
+// declare adds the declaration of a named type for schema, documented as
+// name followed by summary and the schema description
+func (g *generator) declare(name string, schema map[string]interface{}, summary string) {
+	decl := &typeDecl{name: name, doc: name + " " + summary}
+	g.decls[name] = decl
+	if description, ok := schema["description"].(string); ok {
+		decl.doc += "\n\n" + description
+	}
+	typ, _ := schemaType(schema)
+	_, hasProperties := schema["properties"]
+	_, hasAllOf := schema["allOf"]
+	if !hasProperties && !hasAllOf && typ != "object" && typ != "" {
+		decl.alias = g.goType(schema, name+"Value")
+		return
+	}
+	decl.fields = g.structFields(schema, name)
+}
+
+// structFields returns the fields of an object schema. allOf references
+// become embedded fields, as they come from embedded Go structs.
+func (g *generator) structFields(schema map[string]interface{}, typeName string) []field {
+	var fields []field
+	names := map[string]bool{}
+	allOf, _ := schema["allOf"].([]interface{})
+	for _, part := range allOf {
+		part, _ := part.(map[string]interface{})
+		if ref, ok := part["$ref"].(string); ok {
+			goType := g.refType(ref)
+			names[goType] = true
+			fields = append(fields, field{name: goType, goType: goType, embedded: true})
+			continue
+		}
+		fields = append(fields, g.propertyFields(part, typeName, names)...)
+	}
+	return append(fields, g.propertyFields(schema, typeName, names)...)
+}
+
+func (g *generator) propertyFields(schema map[string]interface{}, typeName string, names map[string]bool) []field {
+	properties, _ := schema["properties"].(map[string]interface{})
+	required := map[string]bool{}
+	list, _ := schema["required"].([]interface{})
+	for _, name := range list {
+		if name, ok := name.(string); ok {
+			required[name] = true
+		}
+	}
+
+	var fields []field
+	for _, name := range sortedKeys(properties) {
+		property, _ := properties[name].(map[string]interface{})
+		fieldName := uniqueName(exportedName(name), names)
+		jsonTag := name
+		if !required[name] {
+			jsonTag += ",omitempty"
+		}
+		tag := fmt.Sprintf("json:%q", jsonTag)
+		if rules := validateTag(property, required[name]); rules != "" {
+			tag += fmt.Sprintf(" validate:%q", rules)
+		}
+		description, _ := property["description"].(string)
+		fields = append(fields, field{
+			name:   fieldName,
+			goType: g.goType(property, typeName+fieldName),
+			tag:    tag,
+			doc:    description,
+		})
+	}
+	return fields
+}
+
+// schemaType returns the type of a schema and whether it is nullable, for
+// both the OpenAPI 3.0 nullable keyword and 3.1 type arrays
+func schemaType(schema map[string]interface{}) (string, bool) {
+	nullable, _ := schema["nullable"].(bool)
+	switch typ := schema["type"].(type) {
+	case string:
+		return typ, nullable
+	case []interface{}:
+		var primary string
+		for _, t := range typ {
+			if t == "null" {
+				nullable = true
+			} else if s, ok := t.(string); ok && primary == "" {
+				primary = s
+			}
+		}
+		return primary, nullable
+	}
+	return "", nullable
+}
+
+// isStructSchema reports whether schema is generated as a struct, whose fields carry their own rules
+func isStructSchema(schema map[string]interface{}) bool {
+	_, hasRef := schema["$ref"]
+	_, hasProperties := schema["properties"]
+	_, hasAllOf := schema["allOf"]
+	return hasRef || hasProperties || hasAllOf
+}
+
+// nullableRef returns the reference of a nullable component: allOf with the
+// 3.0 nullable keyword, or anyOf with a null type in 3.1
+func nullableRef(schema map[string]interface{}) (string, bool) {
+	if nullable, _ := schema["nullable"].(bool); nullable {
+		if allOf, ok := schema["allOf"].([]interface{}); ok && len(allOf) == 1 {
+			if sub, ok := allOf[0].(map[string]interface{}); ok {
+				ref, ok := sub["$ref"].(string)
+				return ref, ok
+			}
+		}
+	}
+	anyOf, ok := schema["anyOf"].([]interface{})
+	if !ok || len(anyOf) != 2 {
+		return "", false
+	}
+	var ref string
+	var hasNull bool
+	for _, item := range anyOf {
+		sub, _ := item.(map[string]interface{})
+		if r, ok := sub["$ref"].(string); ok {
+			ref = r
+		} else if sub["type"] == "null" {
+			hasNull = true
+		}
+	}
+	return ref, ref != "" && hasNull
+}
+
+// formatRules maps schema formats back to the validate rules they come from
+var formatRules = map[string]string{
+	"email": "email", "uuid": "uuid", "uri": "url", "hostname": "hostname", "ipv4": "ipv4", "ipv6": "ipv6",
+}
+
+// validateTag returns the validate rules of a field with schema, the inverse
+// of the keywords httpc derives from validate tags
+func validateTag(schema map[string]interface{}, required bool) string {
+	var rules []string
+	if required {
+		rules = append(rules, "required")
+	}
+	rules = append(rules, schemaRules(schema)...)
+	if !required && len(rules) > 0 {
+		rules = append([]string{"omitempty"}, rules...)
+	}
+	return strings.Join(rules, ",")
+}
+
+func schemaRules(schema map[string]interface{}) []string {
+	var rules []string
+	typ, _ := schemaType(schema)
+	switch typ {
+	case "string":
+		minLength, hasMin := number(schema["minLength"])
+		maxLength, hasMax := number(schema["maxLength"])
+		if hasMin && hasMax && minLength == maxLength {
+			rules = append(rules, "len="+formatNumber(minLength))
+		} else {
+			rules = appendBound(rules, "min", minLength, hasMin)
+			rules = appendBound(rules, "max", maxLength, hasMax)
+		}
+		if format, ok := schema["format"].(string); ok && formatRules[format] != "" {
+			rules = append(rules, formatRules[format])
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			rules = append(rules, "regexp="+escapeRuleParam(pattern))
+		}
+	case "integer", "number":
+		rules = append(rules, numericBound(schema, "minimum", "exclusiveMinimum", "gte", "gt")...)
+		rules = append(rules, numericBound(schema, "maximum", "exclusiveMaximum", "lte", "lt")...)
+	case "array":
+		minItems, hasMin := number(schema["minItems"])
+		maxItems, hasMax := number(schema["maxItems"])
+		if hasMin && hasMax && minItems == maxItems {
+			rules = append(rules, "len="+formatNumber(minItems))
+		} else {
+			rules = appendBound(rules, "min", minItems, hasMin)
+			rules = appendBound(rules, "max", maxItems, hasMax)
+		}
+		if unique, _ := schema["uniqueItems"].(bool); unique {
+			rules = append(rules, "unique")
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			if itemRules := schemaRules(items); len(itemRules) > 0 {
+				rules = append(append(rules, "dive"), itemRules...)
+			} else if isStructSchema(items) {
+				rules = append(rules, "dive") // Validate the fields of each element
+			}
+		}
+	case "object":
+		minProperties, hasMin := number(schema["minProperties"])
+		maxProperties, hasMax := number(schema["maxProperties"])
+		rules = appendBound(rules, "min", minProperties, hasMin)
+		rules = appendBound(rules, "max", maxProperties, hasMax)
+		if values, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+			if valueRules := schemaRules(values); len(valueRules) > 0 {
+				rules = append(append(rules, "dive"), valueRules...)
+			}
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		var values []string
+		for _, value := range enum {
+			if value == nil {
+				continue
+			}
+			s := fmt.Sprint(value)
+			if f, ok := value.(float64); ok {
+				s = formatNumber(f)
+			}
+			if strings.ContainsAny(s, " ") {
+				s = "'" + s + "'"
+			}
+			values = append(values, s)
+		}
+		rules = append(rules, "oneof="+strings.Join(values, " "))
+	}
+	return rules
+}
+
+// numericBound returns the rule of an inclusive or exclusive bound, written
+// as a boolean flag in OpenAPI 3.0 or as the bound itself in 3.1
+func numericBound(schema map[string]interface{}, inclusive, exclusive, inclusiveRule, exclusiveRule string) []string {
+	if bound, ok := number(schema[exclusive]); ok {
+		return []string{exclusiveRule + "=" + formatNumber(bound)}
+	}
+	bound, ok := number(schema[inclusive])
+	if !ok {
+		return nil
+	}
+	if flag, _ := schema[exclusive].(bool); flag {
+		return []string{exclusiveRule + "=" + formatNumber(bound)}
+	}
+	return []string{inclusiveRule + "=" + formatNumber(bound)}
+}
+
+func appendBound(rules []string, rule string, bound float64, ok bool) []string {
+	if !ok {
+		return rules
+	}
+	return append(rules, rule+"="+formatNumber(bound))
+}
+
+func number(value interface{}) (float64, bool) {
+	f, ok := value.(float64)
+	return f, ok
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// escapeRuleParam escapes the characters validate tags use as separators
+func escapeRuleParam(param string) string {
+	return strings.NewReplacer(",", "0x2C", "|", "0x7C").Replace(param)
+}
+
+var (
+	wordSeparator = regexp.MustCompile(`[^A-Za-z0-9]+`)
+	initialisms   = map[string]bool{
+		"api": true, "http": true, "id": true, "ip": true, "json": true,
+		"uri": true, "url": true, "uuid": true, "sku": true,
+	}
+)
+
+// exportedName converts an OpenAPI name such as "dry_run", "X-Tenant" or
+// "go-core-httpc.Address" to an exported Go identifier
+func exportedName(name string) string {
+	var b strings.Builder
+	for _, word := range wordSeparator.Split(name, -1) {
+		if word == "" {
+			continue
+		}
+		if initialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	identifier := b.String()
+	if identifier == "" || unicode.IsDigit(rune(identifier[0])) {
+		identifier = "X" + identifier
+	}
+	return identifier
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
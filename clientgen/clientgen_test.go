@@ -0,0 +1,280 @@
+package clientgen
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	httpc "github.com/T-Prohmpossadhorn/go-core-httpc"
+	"github.com/T-Prohmpossadhorn/go-core-httpc/clientgen/internal/teststub"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// newTestServer registers the test_service.go services on a server documenting OpenAPI version
+func newTestServer(t *testing.T, version string) *httpc.Server {
+	c, err := config.New(config.WithDefault(map[string]interface{}{
+		"otel_enabled":       false,
+		"port":               8080,
+		"openapi_version":    version,
+		"openapi_validation": httpc.DocValidationFail,
+	}))
+	require.NoError(t, err)
+	srv, err := httpc.NewServer(c, httpc.WithAPIInfo(httpc.APIInfo{Title: "Test Services", Version: "1.0.0"}))
+	require.NoError(t, err)
+	services := []struct {
+		prefix string
+		svc    interface{}
+	}{
+		{"/greet", &httpc.TestService{}},
+		{"/multi", &httpc.MultiMethodService{}},
+		{"/custom", &httpc.CustomPathService{}},
+		{"/search", &httpc.SearchService{}},
+		{"/v1", &httpc.UserService{}},
+		{"/orders", &httpc.OrderService{}},
+		{"/catalog", &httpc.CatalogService{}},
+		{"/chat", &httpc.ChatService{}},
+	}
+	for _, s := range services {
+		require.NoError(t, srv.RegisterService(s.svc, httpc.WithPathPrefix(s.prefix)))
+	}
+	return srv
+}
+
+// checkGolden compares got with the golden file at path, rewriting it with -update
+func checkGolden(t *testing.T, path string, got []byte) {
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "run go test -update to create the golden file")
+	require.Equal(t, string(want), string(got))
+}
+
+func TestGenerate(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	opts := Options{Package: "testclient", Source: "test_service.go"}
+
+	doc, err := FromServer(newTestServer(t, httpc.OpenAPIVersion30))
+	require.NoError(t, err)
+	src, err := Generate(doc, opts)
+	require.NoError(t, err)
+
+	t.Run("Test Services", func(t *testing.T) {
+		checkGolden(t, filepath.Join("testdata", "test_services.go.golden"), src)
+	})
+
+	t.Run("OpenAPI 3.1", func(t *testing.T) {
+		doc, err := FromServer(newTestServer(t, httpc.OpenAPIVersion31))
+		require.NoError(t, err)
+		got, err := Generate(doc, opts)
+		require.NoError(t, err)
+		require.Equal(t, string(src), string(got), "both versions describe the same client")
+	})
+
+	t.Run("YAML Document", func(t *testing.T) {
+		data, err := yaml.Marshal(doc)
+		require.NoError(t, err)
+		path := filepath.Join(t.TempDir(), "openapi.yaml")
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+
+		loaded, err := Load(path)
+		require.NoError(t, err)
+		got, err := Generate(loaded, opts)
+		require.NoError(t, err)
+		require.Equal(t, string(src), string(got))
+	})
+
+	t.Run("Custom Client Name", func(t *testing.T) {
+		got, err := Generate(doc, Options{Package: "catalog", Client: "CatalogClient"})
+		require.NoError(t, err)
+		require.Contains(t, string(got), "// Code generated by httpc-gen. DO NOT EDIT.")
+		require.Contains(t, string(got), "func NewCatalogClient(client *httpc.HTTPClient, baseURL string) *CatalogClient {")
+		require.Contains(t, string(got), "func (c *CatalogClient) GetBook(params GetBookParams, opts ...httpc.CallOption) (Book, error) {")
+	})
+
+	t.Run("Generated Code Compiles", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("builds the generated package")
+		}
+		// Overlay the generated file as a package of this module, so it builds
+		// against the module's httpc without writing to the source tree
+		dir, err := filepath.Abs("generated_check")
+		require.NoError(t, err)
+		tmp := t.TempDir()
+		file := filepath.Join(tmp, "client.go")
+		require.NoError(t, os.WriteFile(file, src, 0o644))
+		overlay, err := json.Marshal(map[string]interface{}{
+			"Replace": map[string]string{filepath.Join(dir, "client.go"): file},
+		})
+		require.NoError(t, err)
+		overlayFile := filepath.Join(tmp, "overlay.json")
+		require.NoError(t, os.WriteFile(overlayFile, overlay, 0o644))
+
+		out, err := exec.Command("go", "build", "-overlay", overlayFile, "./generated_check").CombinedOutput()
+		require.NoError(t, err, string(out))
+	})
+
+	t.Run("Invalid Documents", func(t *testing.T) {
+		_, err := Generate(map[string]interface{}{"openapi": "2.0"}, opts)
+		require.EqualError(t, err, `unsupported OpenAPI version "2.0"`)
+		_, err = Generate(doc, Options{})
+		require.EqualError(t, err, "package name is required")
+		_, err = Parse([]byte("openapi: [unclosed"))
+		require.Error(t, err)
+	})
+}
+
+// stubService implements teststub.Service, leaving the operations a test
+// does not call unimplemented
+type stubService struct {
+	teststub.Service
+}
+
+func (stubService) GetBook(in teststub.GetBookParams) (teststub.Book, error) {
+	return teststub.Book{Isbn: in.Isbn, Title: "The Go Programming Language", Pages: 380}, nil
+}
+
+func (stubService) Update(in teststub.UpdateInput) (string, error) {
+	return fmt.Sprintf("updated %d for %s: %s", in.ID, in.XTenant, in.Body.Name), nil
+}
+
+func (stubService) Echo(ctx context.Context, ws *httpc.WebSocket[teststub.ChatMessage, teststub.ChatReply]) error {
+	msg, err := ws.Receive()
+	if err != nil {
+		return err
+	}
+	return ws.Send(teststub.ChatReply{Text: strings.ToUpper(msg.Text)})
+}
+
+func TestGenerateServer(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	doc, err := FromServer(newTestServer(t, httpc.OpenAPIVersion30))
+	require.NoError(t, err)
+	contract := filepath.Join(t.TempDir(), "openapi.json")
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(contract, data, 0o644))
+
+	// The stub package is the golden file, so it is compiled with the tests
+	t.Run("Test Services", func(t *testing.T) {
+		src, err := GenerateServer(doc, Options{Package: "teststub", Source: "test_service.go"})
+		require.NoError(t, err)
+		checkGolden(t, filepath.Join("internal", "teststub", "service.go"), src)
+	})
+
+	newStubServer := func(t *testing.T, version string) *httpc.Server {
+		c, err := config.New(config.WithDefault(map[string]interface{}{
+			"otel_enabled":           false,
+			"port":                   8080,
+			"openapi_version":        version,
+			"openapi_validation":     httpc.DocValidationFail,
+			"openapi_contract":       contract,
+			"openapi_contract_check": httpc.DocValidationFail,
+		}))
+		require.NoError(t, err)
+		srv, err := httpc.NewServer(c)
+		require.NoError(t, err)
+		require.NoError(t, srv.RegisterService(teststub.NewServiceHandler(stubService{})))
+		return srv
+	}
+
+	t.Run("Stub Matches Contract", func(t *testing.T) {
+		for _, version := range []string{httpc.OpenAPIVersion30, httpc.OpenAPIVersion31} {
+			require.NoError(t, newStubServer(t, version).CheckContract(), version)
+		}
+	})
+
+	t.Run("Stub Serves Operations", func(t *testing.T) {
+		srv := newStubServer(t, httpc.OpenAPIVersion30)
+		ts := httptest.NewServer(srv.Handler())
+		defer ts.Close()
+
+		resp, err := http.Get(ts.URL + "/catalog/GetBook?isbn=9780134190440")
+		require.NoError(t, err)
+		var book teststub.Book
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&book))
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "9780134190440", book.Isbn)
+
+		req, err := http.NewRequest(http.MethodPut, ts.URL+"/v1/Update/7", strings.NewReader(`{"name":"Ada"}`))
+		require.NoError(t, err)
+		req.Header.Set("X-Tenant", "acme")
+		req.Header.Set("Content-Type", "application/json")
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		require.Equal(t, `"updated 7 for acme: Ada"`, string(body))
+
+		c, err := config.New(config.WithDefault(map[string]interface{}{"otel_enabled": false}))
+		require.NoError(t, err)
+		client, err := httpc.NewHTTPClient(c)
+		require.NoError(t, err)
+		ws, err := httpc.DialWebSocket[teststub.ChatMessage, teststub.ChatReply](context.Background(), client, ts.URL+"/chat/Echo")
+		require.NoError(t, err)
+		defer ws.Close()
+		require.NoError(t, ws.Send(teststub.ChatMessage{Text: "hi"}))
+		reply, err := ws.Receive()
+		require.NoError(t, err)
+		require.Equal(t, "HI", reply.Text)
+	})
+}
+
+func TestExportedName(t *testing.T) {
+	for name, want := range map[string]string{
+		"dry_run":               "DryRun",
+		"X-Tenant":              "XTenant",
+		"page.number":           "PageNumber",
+		"go-core-httpc.Address": "GoCoreHttpcAddress",
+		"api_v2_Hello":          "APIV2Hello",
+		"id":                    "ID",
+		"getBook":               "GetBook",
+		"2fa":                   "X2fa",
+	} {
+		require.Equal(t, want, exportedName(name), name)
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	for _, tc := range []struct {
+		schema   map[string]interface{}
+		required bool
+		want     string
+	}{
+		{map[string]interface{}{"type": "string", "format": "email"}, true, "required,email"},
+		{map[string]interface{}{"type": "string", "minLength": float64(8), "maxLength": float64(8)}, true, "required,len=8"},
+		{map[string]interface{}{"type": "string", "pattern": "^[a-z]{2,3}$"}, false, "omitempty,regexp=^[a-z]{20x2C3}$"},
+		{map[string]interface{}{"type": "string", "enum": []interface{}{"draft", "in review"}}, false, "omitempty,oneof=draft 'in review'"},
+		{map[string]interface{}{"type": "integer", "minimum": float64(1), "maximum": float64(10)}, false, "omitempty,gte=1,lte=10"},
+		{map[string]interface{}{"type": "number", "minimum": float64(0), "exclusiveMinimum": true}, false, "omitempty,gt=0"},
+		{map[string]interface{}{"type": []interface{}{"number", "null"}, "exclusiveMaximum": float64(5)}, false, "omitempty,lt=5"},
+		{map[string]interface{}{"type": "array", "minItems": float64(1), "uniqueItems": true, "items": map[string]interface{}{"type": "string", "maxLength": float64(10)}}, true, "required,min=1,unique,dive,max=10"},
+		{map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/components/schemas/OrderItem"}}, true, "required,dive"},
+		{map[string]interface{}{"type": "boolean"}, false, ""},
+	} {
+		require.Equal(t, tc.want, validateTag(tc.schema, tc.required))
+	}
+}
@@ -0,0 +1,432 @@
+// Code generated by httpc-gen from test_service.go. DO NOT EDIT.
+
+// Package teststub serves Test Services 1.0.0.
+package teststub
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	httpc "github.com/T-Prohmpossadhorn/go-core-httpc"
+)
+
+// Service implements the operations of Test Services. Register it with
+// httpc.Server.RegisterService(NewServiceHandler(impl)).
+type Service interface {
+	// AddBook handles POST /catalog/AddBook.
+	//
+	// Deprecated: the operation is deprecated by the API.
+	AddBook(in Book) (Book, error)
+	// GetBook handles GET /catalog/GetBook.
+	GetBook(in GetBookParams) (Book, error)
+	// Echo handles the WebSocket at /chat/Echo.
+	Echo(ctx context.Context, ws *httpc.WebSocket[ChatMessage, ChatReply]) error
+	// Process handles POST /custom/Process.
+	Process(in CustomInput) (CustomOutput, error)
+	// Create handles POST /greet/Create.
+	Create(in User) (string, error)
+	// Hello handles GET /greet/Hello.
+	Hello(in HelloParams) (string, error)
+	// DeleteMethod handles DELETE /multi/DeleteMethod.
+	DeleteMethod(in MultiInput) (MultiOutput, error)
+	// GetMethod handles GET /multi/GetMethod.
+	GetMethod(in GetMethodParams) (MultiOutput, error)
+	// PostMethod handles POST /multi/PostMethod.
+	PostMethod(in MultiInput) (MultiOutput, error)
+	// PutMethod handles PUT /multi/PutMethod.
+	PutMethod(in MultiInput) (MultiOutput, error)
+	// Place handles POST /orders/Place.
+	Place(in OrderInput) (string, error)
+	// Lookup handles GET /search/Lookup.
+	Lookup(in LookupParams) (SearchResult, error)
+	// Search handles GET /search/Search.
+	Search(in SearchParams) (SearchResult, error)
+	// Update handles PUT /v1/Update/{id}.
+	Update(in UpdateInput) (string, error)
+	// Delete handles DELETE /v1/users/{id}.
+	Delete(in DeleteParams) (string, error)
+}
+
+// ServiceHandler registers the operations of a Service with httpc.Server.RegisterService
+type ServiceHandler struct {
+	impl Service
+}
+
+// NewServiceHandler returns the handler of impl
+func NewServiceHandler(impl Service) ServiceHandler {
+	return ServiceHandler{impl: impl}
+}
+
+// AddBook handles POST /catalog/AddBook.
+//
+// Deprecated: the operation is deprecated by the API.
+func (h ServiceHandler) AddBook(in Book) (Book, error) {
+	return h.impl.AddBook(in)
+}
+
+// GetBook handles GET /catalog/GetBook.
+func (h ServiceHandler) GetBook(in GetBookParams) (Book, error) {
+	return h.impl.GetBook(in)
+}
+
+// Echo handles the WebSocket at /chat/Echo.
+func (h ServiceHandler) Echo(ctx context.Context, ws *httpc.WebSocket[ChatMessage, ChatReply]) error {
+	return h.impl.Echo(ctx, ws)
+}
+
+// Process handles POST /custom/Process.
+func (h ServiceHandler) Process(in CustomInput) (CustomOutput, error) {
+	return h.impl.Process(in)
+}
+
+// Create handles POST /greet/Create.
+func (h ServiceHandler) Create(in User) (string, error) {
+	return h.impl.Create(in)
+}
+
+// Hello handles GET /greet/Hello.
+func (h ServiceHandler) Hello(in HelloParams) (string, error) {
+	return h.impl.Hello(in)
+}
+
+// DeleteMethod handles DELETE /multi/DeleteMethod.
+func (h ServiceHandler) DeleteMethod(in MultiInput) (MultiOutput, error) {
+	return h.impl.DeleteMethod(in)
+}
+
+// GetMethod handles GET /multi/GetMethod.
+func (h ServiceHandler) GetMethod(in GetMethodParams) (MultiOutput, error) {
+	return h.impl.GetMethod(in)
+}
+
+// PostMethod handles POST /multi/PostMethod.
+func (h ServiceHandler) PostMethod(in MultiInput) (MultiOutput, error) {
+	return h.impl.PostMethod(in)
+}
+
+// PutMethod handles PUT /multi/PutMethod.
+func (h ServiceHandler) PutMethod(in MultiInput) (MultiOutput, error) {
+	return h.impl.PutMethod(in)
+}
+
+// Place handles POST /orders/Place.
+func (h ServiceHandler) Place(in OrderInput) (string, error) {
+	return h.impl.Place(in)
+}
+
+// Lookup handles GET /search/Lookup.
+func (h ServiceHandler) Lookup(in LookupParams) (SearchResult, error) {
+	return h.impl.Lookup(in)
+}
+
+// Search handles GET /search/Search.
+func (h ServiceHandler) Search(in SearchParams) (SearchResult, error) {
+	return h.impl.Search(in)
+}
+
+// Update handles PUT /v1/Update/{id}.
+func (h ServiceHandler) Update(in UpdateInput) (string, error) {
+	return h.impl.Update(in)
+}
+
+// Delete handles DELETE /v1/users/{id}.
+func (h ServiceHandler) Delete(in DeleteParams) (string, error) {
+	return h.impl.Delete(in)
+}
+
+// RegisterMethods routes the operations of the contract from the server root
+func (h ServiceHandler) RegisterMethods() []httpc.MethodInfo {
+	return []httpc.MethodInfo{
+		{
+			Name:        "AddBook",
+			HTTPMethod:  "POST",
+			Path:        "catalog/AddBook",
+			InputType:   reflect.TypeOf(Book{}),
+			OutputType:  reflect.TypeOf(Book{}),
+			Func:        reflect.ValueOf(h).MethodByName("AddBook"),
+			Summary:     "AddBook",
+			OperationID: "AddBook",
+			Tags:        []string{"catalog"},
+			Deprecated:  true,
+		},
+		{
+			Name:        "GetBook",
+			HTTPMethod:  "GET",
+			Path:        "catalog/GetBook",
+			InputType:   reflect.TypeOf(GetBookParams{}),
+			OutputType:  reflect.TypeOf(Book{}),
+			Func:        reflect.ValueOf(h).MethodByName("GetBook"),
+			Summary:     "Look up a book",
+			Description: "Returns the book with the given ISBN.",
+			OperationID: "getBook",
+			Tags:        []string{"books"},
+		},
+		{
+			Name:        "Echo",
+			HTTPMethod:  "GET",
+			Path:        "chat/Echo",
+			Kind:        httpc.MethodWebSocket,
+			InputType:   reflect.TypeOf(ChatMessage{}),
+			OutputType:  reflect.TypeOf(ChatReply{}),
+			Func:        reflect.ValueOf(h).MethodByName("Echo"),
+			Summary:     "Echo chat messages in upper case",
+			OperationID: "Echo",
+			Tags:        []string{"chat"},
+		},
+		{
+			Name:        "Process",
+			HTTPMethod:  "POST",
+			Path:        "custom/Process",
+			InputType:   reflect.TypeOf(CustomInput{}),
+			OutputType:  reflect.TypeOf(CustomOutput{}),
+			Func:        reflect.ValueOf(h).MethodByName("Process"),
+			Summary:     "Process",
+			OperationID: "Process",
+			Tags:        []string{"custom"},
+		},
+		{
+			Name:        "Create",
+			HTTPMethod:  "POST",
+			Path:        "greet/Create",
+			InputType:   reflect.TypeOf(User{}),
+			OutputType:  reflect.TypeOf(""),
+			Func:        reflect.ValueOf(h).MethodByName("Create"),
+			Summary:     "Create",
+			OperationID: "Create",
+			Tags:        []string{"greet"},
+		},
+		{
+			Name:        "Hello",
+			HTTPMethod:  "GET",
+			Path:        "greet/Hello",
+			InputType:   reflect.TypeOf(HelloParams{}),
+			OutputType:  reflect.TypeOf(""),
+			Func:        reflect.ValueOf(h).MethodByName("Hello"),
+			Summary:     "Hello",
+			OperationID: "Hello",
+			Tags:        []string{"greet"},
+		},
+		{
+			Name:        "DeleteMethod",
+			HTTPMethod:  "DELETE",
+			Path:        "multi/DeleteMethod",
+			InputType:   reflect.TypeOf(MultiInput{}),
+			OutputType:  reflect.TypeOf(MultiOutput{}),
+			Func:        reflect.ValueOf(h).MethodByName("DeleteMethod"),
+			Summary:     "DeleteMethod",
+			OperationID: "DeleteMethod",
+			Tags:        []string{"multi"},
+		},
+		{
+			Name:        "GetMethod",
+			HTTPMethod:  "GET",
+			Path:        "multi/GetMethod",
+			InputType:   reflect.TypeOf(GetMethodParams{}),
+			OutputType:  reflect.TypeOf(MultiOutput{}),
+			Func:        reflect.ValueOf(h).MethodByName("GetMethod"),
+			Summary:     "GetMethod",
+			OperationID: "GetMethod",
+			Tags:        []string{"multi"},
+		},
+		{
+			Name:        "PostMethod",
+			HTTPMethod:  "POST",
+			Path:        "multi/PostMethod",
+			InputType:   reflect.TypeOf(MultiInput{}),
+			OutputType:  reflect.TypeOf(MultiOutput{}),
+			Func:        reflect.ValueOf(h).MethodByName("PostMethod"),
+			Summary:     "PostMethod",
+			OperationID: "PostMethod",
+			Tags:        []string{"multi"},
+		},
+		{
+			Name:        "PutMethod",
+			HTTPMethod:  "PUT",
+			Path:        "multi/PutMethod",
+			InputType:   reflect.TypeOf(MultiInput{}),
+			OutputType:  reflect.TypeOf(MultiOutput{}),
+			Func:        reflect.ValueOf(h).MethodByName("PutMethod"),
+			Summary:     "PutMethod",
+			OperationID: "PutMethod",
+			Tags:        []string{"multi"},
+		},
+		{
+			Name:        "Place",
+			HTTPMethod:  "POST",
+			Path:        "orders/Place",
+			InputType:   reflect.TypeOf(OrderInput{}),
+			OutputType:  reflect.TypeOf(""),
+			Func:        reflect.ValueOf(h).MethodByName("Place"),
+			Summary:     "Place",
+			OperationID: "Place",
+			Tags:        []string{"orders"},
+		},
+		{
+			Name:        "Lookup",
+			HTTPMethod:  "GET",
+			Path:        "search/Lookup",
+			InputType:   reflect.TypeOf(LookupParams{}),
+			OutputType:  reflect.TypeOf(SearchResult{}),
+			Func:        reflect.ValueOf(h).MethodByName("Lookup"),
+			Summary:     "Lookup",
+			OperationID: "Lookup",
+			Tags:        []string{"search"},
+		},
+		{
+			Name:        "Search",
+			HTTPMethod:  "GET",
+			Path:        "search/Search",
+			InputType:   reflect.TypeOf(SearchParams{}),
+			OutputType:  reflect.TypeOf(SearchResult{}),
+			Func:        reflect.ValueOf(h).MethodByName("Search"),
+			Summary:     "Search",
+			OperationID: "Search",
+			Tags:        []string{"search"},
+		},
+		{
+			Name:        "Update",
+			HTTPMethod:  "PUT",
+			Path:        "v1/Update/:id",
+			InputType:   reflect.TypeOf(UpdateInput{}),
+			OutputType:  reflect.TypeOf(""),
+			Func:        reflect.ValueOf(h).MethodByName("Update"),
+			Summary:     "Update",
+			OperationID: "Update",
+			Tags:        []string{"v1"},
+		},
+		{
+			Name:        "Delete",
+			HTTPMethod:  "DELETE",
+			Path:        "v1/users/:id",
+			InputType:   reflect.TypeOf(DeleteParams{}),
+			OutputType:  reflect.TypeOf(""),
+			Func:        reflect.ValueOf(h).MethodByName("Delete"),
+			Summary:     "Delete",
+			OperationID: "Delete",
+			Tags:        []string{"v1"},
+		},
+	}
+}
+
+// Book is the Book schema
+type Book struct {
+	// Primary author
+	Author *BookAuthor `json:"author,omitempty"`
+	// ISBN-13 of the book
+	Isbn  string `json:"isbn" validate:"required"`
+	Pages int64  `json:"pages,omitempty" validate:"omitempty,gte=1"`
+	// Title of the book
+	Title string `json:"title" validate:"required"`
+}
+
+// BookAuthor is the BookAuthor schema
+type BookAuthor struct {
+	Name string `json:"name,omitempty"`
+}
+
+// ChatMessage is the ChatMessage schema
+type ChatMessage struct {
+	Text string `json:"text" validate:"required"`
+}
+
+// ChatReply is the ChatReply schema
+type ChatReply struct {
+	Error string `json:"error,omitempty"`
+	Text  string `json:"text,omitempty"`
+}
+
+// CustomInput is the CustomInput schema
+type CustomInput struct {
+	Data string `json:"data,omitempty"`
+}
+
+// CustomOutput is the CustomOutput schema
+type CustomOutput struct {
+	Result string `json:"result,omitempty"`
+}
+
+// DeleteParams holds the parameters of Delete
+type DeleteParams struct {
+	ID      int    `path:"id" validate:"required,gte=1"`
+	XTenant string `header:"X-Tenant"`
+}
+
+// GetBookParams holds the parameters of GetBook
+type GetBookParams struct {
+	// ISBN to look up
+	Isbn string `query:"isbn" validate:"required"`
+}
+
+// GetMethodParams holds the parameters of GetMethod
+type GetMethodParams struct {
+	Name string `query:"name"`
+}
+
+// HelloParams holds the parameters of Hello
+type HelloParams struct {
+	Name string `query:"name"`
+}
+
+// LookupParams holds the parameters of Lookup
+type LookupParams struct {
+	ID string `query:"id"`
+}
+
+// MultiInput is the MultiInput schema
+type MultiInput struct {
+	Value string `json:"value,omitempty"`
+}
+
+// MultiOutput is the MultiOutput schema
+type MultiOutput struct {
+	Result string `json:"result,omitempty"`
+}
+
+// OrderInput is the OrderInput schema
+type OrderInput struct {
+	Customer UserPatch   `json:"customer,omitempty"`
+	Items    []OrderItem `json:"items" validate:"required,min=1,dive"`
+}
+
+// OrderItem is the OrderItem schema
+type OrderItem struct {
+	Quantity int64  `json:"quantity,omitempty" validate:"omitempty,gte=1,lte=10"`
+	SKU      string `json:"sku" validate:"required"`
+}
+
+// SearchParams holds the parameters of Search
+type SearchParams struct {
+	Q          string    `query:"q" validate:"required"`
+	Tag        []string  `query:"tag"`
+	Limit      int       `query:"limit" validate:"omitempty,gte=1,lte=100"`
+	Since      time.Time `query:"since"`
+	PageNumber int       `query:"page.number"`
+	PageSize   int       `query:"page.size"`
+}
+
+// SearchResult is the SearchResult schema
+type SearchResult struct {
+	Summary string `json:"summary,omitempty"`
+}
+
+// UpdateInput holds the parameters and body of Update
+type UpdateInput struct {
+	ID      int       `path:"id" validate:"required,gte=1"`
+	XTenant string    `header:"X-Tenant" validate:"required"`
+	Session string    `cookie:"session"`
+	DryRun  bool      `query:"dry_run"`
+	Body    UserPatch `body:""`
+}
+
+// User is the User schema
+type User struct {
+	Email string `json:"email" validate:"required,email"`
+	Name  string `json:"name" validate:"required"`
+}
+
+// UserPatch is the UserPatch schema
+type UserPatch struct {
+	Email string `json:"email,omitempty" validate:"omitempty,email"`
+	Name  string `json:"name" validate:"required"`
+}
@@ -0,0 +1,390 @@
+package clientgen
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// file assembles the generated source: header, imports, the client type,
+// then the operations and types written to g.body
+func (g *generator) file() []byte {
+	var b bytes.Buffer
+	source := ""
+	if g.opts.Source != "" {
+		source = " from " + g.opts.Source
+	}
+	fmt.Fprintf(&b, "// Code generated by httpc-gen%s. DO NOT EDIT.\n\n", source)
+
+	title, version := "the API", ""
+	if info, ok := g.doc["info"].(map[string]interface{}); ok {
+		if t, ok := info["title"].(string); ok && t != "" {
+			title = t
+		}
+		version, _ = info["version"].(string)
+	}
+	api := title
+	if version != "" {
+		api += " " + version
+	}
+	if g.server {
+		fmt.Fprintf(&b, "// Package %s serves %s.\npackage %s\n\n", g.opts.Package, api, g.opts.Package)
+	} else {
+		fmt.Fprintf(&b, "// Package %s is a client of %s.\npackage %s\n\n", g.opts.Package, api, g.opts.Package)
+		g.imports["strings"] = true
+	}
+	var std, external []string
+	for path := range g.imports {
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			external = append(external, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(external)
+	b.WriteString("import (\n")
+	for _, path := range std {
+		fmt.Fprintf(&b, "\t%q\n", path)
+	}
+	if len(std) > 0 && len(external) > 0 {
+		b.WriteString("\n")
+	}
+	for _, path := range external {
+		if path == httpcImport {
+			fmt.Fprintf(&b, "\thttpc %q\n", path)
+			continue
+		}
+		fmt.Fprintf(&b, "\t%q\n", path)
+	}
+	b.WriteString(")\n\n")
+
+	if g.server {
+		b.Write(g.body.Bytes())
+		return b.Bytes()
+	}
+	client := g.opts.Client
+	fmt.Fprintf(&b, "// %s calls the operations of %s through an httpc.HTTPClient\n", client, title)
+	fmt.Fprintf(&b, "type %s struct {\n\tclient  *httpc.HTTPClient\n\tbaseURL string\n}\n\n", client)
+	fmt.Fprintf(&b, "// New%s returns a %s sending requests to baseURL. With an empty\n", client, client)
+	b.WriteString("// baseURL, paths are resolved against the HTTPClient's base URL.\n")
+	fmt.Fprintf(&b, "func New%s(client *httpc.HTTPClient, baseURL string) *%s {\n", client, client)
+	fmt.Fprintf(&b, "\treturn &%s{client: client, baseURL: strings.TrimSuffix(baseURL, \"/\")}\n}\n\n", client)
+
+	b.Write(g.body.Bytes())
+	return b.Bytes()
+}
+
+// writeOperation writes the client method of op
+func (g *generator) writeOperation(op *operation) {
+	b := &g.body
+	writeComment(b, "", g.operationDoc(op))
+	if op.websocket {
+		fmt.Fprintf(b, "func (c *%s) %s(ctx context.Context, opts ...httpc.CallOption) (*httpc.WebSocket[%s, %s], error) {\n", g.opts.Client, op.name, op.outputType, op.inputType)
+		fmt.Fprintf(b, "return httpc.DialWebSocket[%s, %s](ctx, c.client, c.baseURL+%s, opts...)\n}\n\n", op.inputType, op.outputType, g.pathExpr(op))
+		return
+	}
+
+	var args []string
+	if op.paramsType != "" {
+		args = append(args, "params "+op.paramsType)
+	}
+	if op.bodyType != "" {
+		args = append(args, "body "+op.bodyType)
+	}
+	args = append(args, "opts ...httpc.CallOption")
+	results := "error"
+	if op.outputType != "" {
+		results = "(" + op.outputType + ", error)"
+	}
+	fmt.Fprintf(b, "func (c *%s) %s(%s) %s {\n", g.opts.Client, op.name, strings.Join(args, ", "), results)
+
+	var stmts []string
+	var query, cookies bool
+	for _, p := range op.params {
+		value := "params." + p.field
+		switch p.in {
+		case "query":
+			if !query {
+				g.imports["net/url"] = true
+				stmts = append(stmts, "query := url.Values{}")
+				query = true
+			}
+			if elem, ok := strings.CutPrefix(p.goType, "[]"); ok {
+				stmts = append(stmts, fmt.Sprintf("for _, v := range %s {\nquery.Add(%q, %s)\n}", value, p.name, g.formatValue(elem, "v")))
+				continue
+			}
+			stmts = append(stmts, g.guard(p, fmt.Sprintf("query.Set(%q, %s)", p.name, g.formatValue(p.goType, value))))
+		case "header":
+			stmts = append(stmts, g.guard(p, fmt.Sprintf("callOpts = append(callOpts, httpc.WithHeader(%q, %s))", p.name, g.formatValue(p.goType, value))))
+		case "cookie":
+			if !cookies {
+				g.imports["net/http"] = true
+				stmts = append(stmts, "var cookies []string")
+				cookies = true
+			}
+			stmts = append(stmts, g.guard(p, fmt.Sprintf("cookies = append(cookies, (&http.Cookie{Name: %q, Value: %s}).String())", p.name, g.formatValue(p.goType, value))))
+		}
+	}
+	if query {
+		stmts = append(stmts, "callOpts = append(callOpts, httpc.WithQuery(query))")
+	}
+	if cookies {
+		stmts = append(stmts, "if len(cookies) > 0 {\ncallOpts = append(callOpts, httpc.WithHeader(\"Cookie\", strings.Join(cookies, \"; \")))\n}")
+	}
+
+	callOpts := "opts..."
+	if len(stmts) > 0 {
+		b.WriteString("var callOpts []httpc.CallOption\n")
+		for _, stmt := range stmts {
+			b.WriteString(stmt + "\n")
+		}
+		callOpts = "append(callOpts, opts...)..."
+	}
+
+	body := "nil"
+	if op.bodyType != "" {
+		body = "body"
+	}
+	call := fmt.Sprintf("c.client.Call(%q, c.baseURL+%s, %s, %%s, %s)", op.httpMethod, g.pathExpr(op), body, callOpts)
+	if op.outputType == "" {
+		fmt.Fprintf(b, "return %s\n}\n\n", fmt.Sprintf(call, "nil"))
+		return
+	}
+	fmt.Fprintf(b, "var out %s\nerr := %s\nreturn out, err\n}\n\n", op.outputType, fmt.Sprintf(call, "&out"))
+}
+
+// writeService writes the service interface, then the handler forwarding
+// each operation to it and describing the operations in RegisterMethods
+func (g *generator) writeService() {
+	b := &g.body
+	service := g.opts.Service
+	handler := service + "Handler"
+	title := "the API"
+	if info, ok := g.doc["info"].(map[string]interface{}); ok {
+		if t, ok := info["title"].(string); ok && t != "" {
+			title = t
+		}
+	}
+
+	fmt.Fprintf(b, "// %s implements the operations of %s. Register it with\n", service, title)
+	fmt.Fprintf(b, "// httpc.Server.RegisterService(New%s(impl)).\n", handler)
+	fmt.Fprintf(b, "type %s interface {\n", service)
+	for _, op := range g.operations {
+		writeComment(b, "\t", g.handlerDoc(op))
+		if op.websocket {
+			fmt.Fprintf(b, "\t%s(ctx context.Context, ws *httpc.WebSocket[%s, %s]) error\n", op.name, op.inputType, op.outputType)
+			continue
+		}
+		fmt.Fprintf(b, "\t%s(in %s) (%s, error)\n", op.name, op.inputType, op.outputType)
+	}
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(b, "// %s registers the operations of a %s with httpc.Server.RegisterService\n", handler, service)
+	fmt.Fprintf(b, "type %s struct {\n\timpl %s\n}\n\n", handler, service)
+	fmt.Fprintf(b, "// New%s returns the handler of impl\n", handler)
+	fmt.Fprintf(b, "func New%s(impl %s) %s {\n\treturn %s{impl: impl}\n}\n\n", handler, service, handler, handler)
+	for _, op := range g.operations {
+		writeComment(b, "", g.handlerDoc(op))
+		if op.websocket {
+			fmt.Fprintf(b, "func (h %s) %s(ctx context.Context, ws *httpc.WebSocket[%s, %s]) error {\n\treturn h.impl.%s(ctx, ws)\n}\n\n",
+				handler, op.name, op.inputType, op.outputType, op.name)
+			continue
+		}
+		fmt.Fprintf(b, "func (h %s) %s(in %s) (%s, error) {\n\treturn h.impl.%s(in)\n}\n\n",
+			handler, op.name, op.inputType, op.outputType, op.name)
+	}
+
+	b.WriteString("// RegisterMethods routes the operations of the contract from the server root\n")
+	fmt.Fprintf(b, "func (h %s) RegisterMethods() []httpc.MethodInfo {\n\treturn []httpc.MethodInfo{\n", handler)
+	for _, op := range g.operations {
+		b.WriteString("{\n")
+		fmt.Fprintf(b, "Name: %q,\n", op.name)
+		fmt.Fprintf(b, "HTTPMethod: %q,\n", op.httpMethod)
+		fmt.Fprintf(b, "Path: %q,\n", ginPath(op.path))
+		if op.websocket {
+			b.WriteString("Kind: httpc.MethodWebSocket,\n")
+		}
+		fmt.Fprintf(b, "InputType: %s,\n", g.reflectType(op.inputType))
+		fmt.Fprintf(b, "OutputType: %s,\n", g.reflectType(op.outputType))
+		fmt.Fprintf(b, "Func: reflect.ValueOf(h).MethodByName(%q),\n", op.name)
+		for _, f := range [][2]string{{"Summary", "summary"}, {"Description", "description"}, {"OperationID", "operationId"}} {
+			if value, _ := op.spec[f[1]].(string); value != "" {
+				fmt.Fprintf(b, "%s: %q,\n", f[0], value)
+			}
+		}
+		if tags, _ := op.spec["tags"].([]interface{}); len(tags) > 0 {
+			quoted := make([]string, len(tags))
+			for i, tag := range tags {
+				quoted[i] = fmt.Sprintf("%q", fmt.Sprint(tag))
+			}
+			fmt.Fprintf(b, "Tags: []string{%s},\n", strings.Join(quoted, ", "))
+		}
+		if deprecated, _ := op.spec["deprecated"].(bool); deprecated {
+			b.WriteString("Deprecated: true,\n")
+		}
+		b.WriteString("},\n")
+	}
+	b.WriteString("}\n}\n\n")
+}
+
+// handlerDoc documents the service method of op with its route and deprecation
+func (g *generator) handlerDoc(op *operation) string {
+	doc := fmt.Sprintf("%s handles %s %s.", op.name, op.httpMethod, op.path)
+	if op.websocket {
+		doc = fmt.Sprintf("%s handles the WebSocket at %s.", op.name, op.path)
+	}
+	if deprecated, _ := op.spec["deprecated"].(bool); deprecated {
+		doc += "\n\nDeprecated: the operation is deprecated by the API."
+	}
+	return doc
+}
+
+// reflectType returns the expression of the reflect.Type of goType
+func (g *generator) reflectType(goType string) string {
+	switch {
+	case goType == "string":
+		return `reflect.TypeOf("")`
+	case goType == "struct{}":
+		return "reflect.TypeOf(struct{}{})"
+	case g.decls[goType] != nil && g.decls[goType].alias == "":
+		return "reflect.TypeOf(" + goType + "{})"
+	}
+	return "reflect.TypeOf((*" + goType + ")(nil)).Elem()"
+}
+
+// ginPath converts an OpenAPI path template to a route relative to the server root
+func ginPath(path string) string {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			segments[i] = ":" + seg[1:len(seg)-1]
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// operationDoc documents the method of op with its route, summary,
+// description and deprecation
+func (g *generator) operationDoc(op *operation) string {
+	doc := fmt.Sprintf("%s sends %s %s.", op.name, op.httpMethod, op.path)
+	if op.websocket {
+		doc = fmt.Sprintf("%s opens the WebSocket at %s.", op.name, op.path)
+	}
+	id, _ := op.spec["operationId"].(string)
+	if summary, _ := op.spec["summary"].(string); summary != "" && summary != id {
+		doc += "\n\n" + summary
+	}
+	if description, _ := op.spec["description"].(string); description != "" {
+		doc += "\n\n" + description
+	}
+	if deprecated, _ := op.spec["deprecated"].(bool); deprecated {
+		doc += "\n\nDeprecated: the operation is deprecated by the API."
+	}
+	return doc
+}
+
+// pathExpr returns the expression of op's path with its path parameters substituted
+func (g *generator) pathExpr(op *operation) string {
+	fields := map[string]param{}
+	for _, p := range op.params {
+		if p.in == "path" {
+			fields[p.name] = p
+		}
+	}
+	var parts []string
+	rest := op.path
+	for {
+		start := strings.Index(rest, "{")
+		end := strings.Index(rest, "}")
+		if start < 0 || end < start {
+			break
+		}
+		p, ok := fields[rest[start+1:end]]
+		if !ok {
+			break
+		}
+		if start > 0 {
+			parts = append(parts, fmt.Sprintf("%q", rest[:start]))
+		}
+		g.imports["net/url"] = true
+		parts = append(parts, fmt.Sprintf("url.PathEscape(%s)", g.formatValue(p.goType, "params."+p.field)))
+		rest = rest[end+1:]
+	}
+	if rest != "" || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%q", rest))
+	}
+	return strings.Join(parts, "+")
+}
+
+// formatValue returns the expression formatting expr of goType as a parameter value
+func (g *generator) formatValue(goType, expr string) string {
+	switch goType {
+	case "string":
+		return expr
+	case "time.Time":
+		return expr + ".Format(time.RFC3339)"
+	}
+	g.imports["fmt"] = true
+	return "fmt.Sprint(" + expr + ")"
+}
+
+// guard wraps the statement setting an optional parameter, so it is only
+// sent when the field is set
+func (g *generator) guard(p param, stmt string) string {
+	if p.required {
+		return stmt
+	}
+	value := "params." + p.field
+	var cond string
+	switch {
+	case p.goType == "string":
+		cond = value + ` != ""`
+	case p.goType == "bool":
+		cond = value
+	case p.goType == "time.Time":
+		cond = "!" + value + ".IsZero()"
+	case strings.HasPrefix(p.goType, "int"), strings.HasPrefix(p.goType, "float"):
+		cond = value + " != 0"
+	case strings.HasPrefix(p.goType, "*"), strings.HasPrefix(p.goType, "map["):
+		cond = value + " != nil"
+	default:
+		return stmt
+	}
+	return fmt.Sprintf("if %s {\n%s\n}", cond, stmt)
+}
+
+// writeDecl writes a generated type declaration
+func (g *generator) writeDecl(decl *typeDecl) {
+	b := &g.body
+	writeComment(b, "", decl.doc)
+	if decl.fields == nil && decl.alias != "" {
+		fmt.Fprintf(b, "type %s %s\n\n", decl.name, decl.alias)
+		return
+	}
+	fmt.Fprintf(b, "type %s struct {\n", decl.name)
+	for _, f := range decl.fields {
+		writeComment(b, "\t", f.doc)
+		if f.embedded {
+			fmt.Fprintf(b, "\t%s\n", f.goType)
+			continue
+		}
+		fmt.Fprintf(b, "\t%s %s `%s`\n", f.name, f.goType, f.tag)
+	}
+	b.WriteString("}\n\n")
+}
+
+// writeComment writes text as a line comment, one line per text line
+func writeComment(b *bytes.Buffer, indent, text string) {
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			fmt.Fprintf(b, "%s//\n", indent)
+			continue
+		}
+		fmt.Fprintf(b, "%s// %s\n", indent, line)
+	}
+}
@@ -0,0 +1,79 @@
+// Command httpc-gen generates a typed Go client package from an OpenAPI
+// document, such as the swagger.json or openapi.yaml served by an httpc server.
+// With -mode server it generates a server stub instead: the service interface
+// of the document and a handler to register its implementation.
+//
+// Usage:
+//
+//	httpc-gen -spec http://localhost:8080/api/docs/swagger.json -package userclient -out userclient/client.go
+//	httpc-gen -mode server -spec api/openapi.yaml -package userapi -out userapi/service.go
+//
+// To generate from a server in-process, without serving it, use
+// clientgen.FromServer with clientgen.Generate.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/T-Prohmpossadhorn/go-core-httpc/clientgen"
+)
+
+func main() {
+	spec := flag.String("spec", "", "OpenAPI document to read, a JSON or YAML file or an http(s) URL")
+	pkg := flag.String("package", "", "name of the generated package (default: the name of the -out directory)")
+	mode := flag.String("mode", "client", "what to generate: client, or server for a service stub")
+	client := flag.String("client", "Client", "name of the generated client type")
+	service := flag.String("service", "Service", "name of the generated service interface, with -mode server")
+	out := flag.String("out", "", "file to write the generated code to (default: stdout)")
+	flag.Parse()
+
+	opts := clientgen.Options{Package: *pkg, Client: *client, Service: *service, Source: *spec}
+	if err := run(*mode, *out, opts); err != nil {
+		fmt.Fprintln(os.Stderr, "httpc-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(mode, out string, opts clientgen.Options) error {
+	generate := clientgen.Generate
+	switch mode {
+	case "client":
+	case "server":
+		generate = clientgen.GenerateServer
+	default:
+		return fmt.Errorf("unknown -mode %q, want client or server", mode)
+	}
+	if opts.Source == "" {
+		return fmt.Errorf("-spec is required")
+	}
+	if opts.Package == "" && out != "" {
+		abs, err := filepath.Abs(filepath.Dir(out))
+		if err != nil {
+			return err
+		}
+		opts.Package = filepath.Base(abs)
+	}
+	if opts.Package == "" {
+		return fmt.Errorf("-package is required when writing to stdout")
+	}
+
+	doc, err := clientgen.Load(opts.Source)
+	if err != nil {
+		return err
+	}
+	src, err := generate(doc, opts)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
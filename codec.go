@@ -0,0 +1,331 @@
+package httpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/gin-gonic/gin"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Media types of the built-in codecs
+const (
+	MediaTypeJSON     = "application/json"
+	MediaTypeXML      = "application/xml"
+	MediaTypeForm     = "application/x-www-form-urlencoded"
+	MediaTypeMsgpack  = "application/msgpack"
+	MediaTypeProtobuf = "application/x-protobuf"
+)
+
+// Codec encodes and decodes message bodies for one media type
+type Codec interface {
+	MediaType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// codecSupporter is implemented by codecs that only handle some Go types
+type codecSupporter interface {
+	Supports(t reflect.Type) bool
+}
+
+var (
+	codecsMu     sync.RWMutex
+	codecs       = map[string]Codec{}
+	codecAliases = map[string]string{}
+	codecOrder   []string
+)
+
+func init() {
+	RegisterCodec(jsonCodec{})
+	RegisterCodec(xmlCodec{}, "text/xml")
+	RegisterCodec(formCodec{})
+	RegisterCodec(msgpackCodec{}, "application/x-msgpack")
+	RegisterCodec(protobufCodec{}, "application/protobuf")
+}
+
+// RegisterCodec makes c available to Server and HTTPClient under its media
+// type and any aliases, replacing a codec previously registered for them
+func RegisterCodec(c Codec, aliases ...string) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	mediaType := strings.ToLower(c.MediaType())
+	if _, exists := codecs[mediaType]; !exists {
+		codecOrder = append(codecOrder, mediaType)
+	}
+	codecs[mediaType] = c
+	for _, alias := range aliases {
+		codecAliases[strings.ToLower(alias)] = mediaType
+	}
+}
+
+// CodecFor returns the codec registered for a media type. Parameters such as
+// charset are ignored.
+func CodecFor(mediaType string) (Codec, bool) {
+	if parsed, _, err := mime.ParseMediaType(mediaType); err == nil {
+		mediaType = parsed
+	}
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	if canonical, ok := codecAliases[mediaType]; ok {
+		mediaType = canonical
+	}
+	c, ok := codecs[mediaType]
+	return c, ok
+}
+
+// codecsFor returns the registered codecs able to handle t, in registration order
+func codecsFor(t reflect.Type) []Codec {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	var result []Codec
+	for _, mediaType := range codecOrder {
+		c := codecs[mediaType]
+		if s, ok := c.(codecSupporter); ok && t != nil && !s.Supports(t) {
+			continue
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// acceptRange is a media range of an Accept header
+type acceptRange struct {
+	mediaType string
+	q         float64
+	order     int
+}
+
+// parseAccept returns the media ranges of an Accept header accepted with a
+// positive quality, the preferred first
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for i, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(qs, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, acceptRange{mediaType: strings.ToLower(mediaType), q: q, order: i})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	return ranges
+}
+
+// negotiateCodec picks the codec for the best match in an Accept header
+// among the codecs able to handle t. An empty header selects JSON.
+func negotiateCodec(accept string, t reflect.Type) (Codec, bool) {
+	available := codecsFor(t)
+	if strings.TrimSpace(accept) == "" {
+		return jsonCodec{}, true
+	}
+
+	ranges := parseAccept(accept)
+	for _, r := range ranges {
+		if r.mediaType == "*/*" {
+			return jsonCodec{}, true
+		}
+		if strings.HasSuffix(r.mediaType, "/*") {
+			prefix := strings.TrimSuffix(r.mediaType, "*")
+			for _, c := range available {
+				if strings.HasPrefix(c.MediaType(), prefix) {
+					return c, true
+				}
+			}
+			continue
+		}
+		if c, ok := CodecFor(r.mediaType); ok {
+			for _, a := range available {
+				if a.MediaType() == c.MediaType() {
+					return c, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// decodeBody unmarshals the request body into v using the codec for its
+// Content-Type, defaulting to JSON, which strict decodes with
+// unmarshalStrictJSON. The returned status is 415 for an unsupported media
+// type, 413 for a body over a size limit and 400 for a malformed body.
+func decodeBody(c *gin.Context, v interface{}, strict bool) (int, error) {
+	contentType := c.ContentType()
+	var codec Codec = jsonCodec{}
+	ok := true
+	if contentType != "" {
+		codec, ok = CodecFor(contentType)
+	}
+	if !ok {
+		return http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content type: %s", contentType)
+	}
+
+	data, err := c.GetRawData()
+	if err != nil {
+		return bodyStatus(err), fmt.Errorf("failed to read request body: %w", err)
+	}
+	if _, ok := codec.(jsonCodec); ok && strict {
+		err = unmarshalStrictJSON(data, v)
+	} else {
+		err = codec.Unmarshal(data, v)
+	}
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, nil
+}
+
+// writeResult encodes result with codec and writes it with status
+func writeResult(c *gin.Context, status int, codec Codec, result interface{}) {
+	if _, ok := codec.(jsonCodec); ok {
+		c.JSON(status, result)
+		return
+	}
+	data, err := codec.Marshal(result)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Failed to encode response", logger.String("media_type", codec.MediaType()), logger.ErrField(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode response: " + err.Error()})
+		return
+	}
+	c.Data(status, codec.MediaType(), data)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) MediaType() string                          { return MediaTypeJSON }
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+type xmlCodec struct{}
+
+func (xmlCodec) MediaType() string                          { return MediaTypeXML }
+func (xmlCodec) Marshal(v interface{}) ([]byte, error)      { return xml.Marshal(v) }
+func (xmlCodec) Unmarshal(data []byte, v interface{}) error { return xml.Unmarshal(data, v) }
+
+// Supports excludes maps, which encoding/xml cannot marshal
+func (xmlCodec) Supports(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() != reflect.Map && t.Kind() != reflect.Interface
+}
+
+// formCodec encodes flat or dotted-key structs using form, then json tags
+type formCodec struct{}
+
+func (formCodec) MediaType() string { return MediaTypeForm }
+
+func (formCodec) Marshal(v interface{}) ([]byte, error) {
+	values, err := encodeValues(v, formTags...)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(values.Encode()), nil
+}
+
+func (formCodec) Unmarshal(data []byte, v interface{}) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+	return decodeValues(values, v, formTags...)
+}
+
+// Supports limits form encoding to structs
+func (formCodec) Supports(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+// msgpackCodec honours json tags so the same structs work for both codecs
+type msgpackCodec struct{}
+
+func (msgpackCodec) MediaType() string { return MediaTypeMsgpack }
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+var protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+// protobufCodec handles proto.Message values only
+type protobufCodec struct{}
+
+func (protobufCodec) MediaType() string { return MediaTypeProtobuf }
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := asProtoMessage(v)
+	if !ok {
+		return nil, fmt.Errorf("protobuf codec requires a proto.Message, got %T", v)
+	}
+	return proto.Marshal(msg)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protobuf codec requires a proto.Message, got %T", v)
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+// Supports limits protobuf to generated message types
+func (protobufCodec) Supports(t reflect.Type) bool {
+	if t.Kind() != reflect.Ptr {
+		t = reflect.PointerTo(t)
+	}
+	return t.Implements(protoMessageType)
+}
+
+// asProtoMessage returns v as a proto.Message, taking the address of
+// message values returned by service methods
+func asProtoMessage(v interface{}) (proto.Message, bool) {
+	if msg, ok := v.(proto.Message); ok {
+		return msg, true
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() == reflect.Ptr {
+		return nil, false
+	}
+	ptr := reflect.New(rv.Type())
+	ptr.Elem().Set(rv)
+	msg, ok := ptr.Interface().(proto.Message)
+	return msg, ok
+}
@@ -0,0 +1,187 @@
+package httpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type formAddress struct {
+	City string `json:"city"`
+}
+
+type formPayload struct {
+	Name    string       `form:"full_name" json:"name"`
+	Tags    []string     `json:"tags"`
+	Limit   *int         `json:"limit"`
+	Since   time.Time    `json:"since" time_format:"2006-01-02"`
+	Address formAddress  `json:"address"`
+	Extra   *formAddress `json:"extra"`
+	Ignored string       `json:"-"`
+}
+
+func TestCodecs(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	serverCfg := ServerConfig{
+		OtelEnabled: false,
+		Port:        8080,
+	}
+	clientCfg, err := config.New(config.WithDefault(map[string]interface{}{
+		"otel_enabled":            false,
+		"http_client_timeout_ms":  1000,
+		"http_client_max_retries": 0,
+	}))
+	require.NoError(t, err)
+
+	t.Run("Round Trip Per Media Type", func(t *testing.T) {
+		svc := &CustomPathService{}
+		ts := setupServer(t, serverCfg, svc, "/v1")
+		defer ts.Close()
+
+		client, err := NewHTTPClient(clientCfg)
+		require.NoError(t, err)
+
+		for _, mediaType := range []string{MediaTypeJSON, MediaTypeXML, MediaTypeMsgpack} {
+			var output CustomOutput
+			resp, err := client.CallWithResponse("POST", ts.URL+"/v1/Process", CustomInput{Data: mediaType}, &output, WithContentType(mediaType))
+			require.NoError(t, err, mediaType)
+			require.Equal(t, "Processed: "+mediaType, output.Result)
+			respCodec, ok := CodecFor(resp.Header.Get("Content-Type"))
+			require.True(t, ok)
+			require.Equal(t, mediaType, respCodec.MediaType())
+		}
+	})
+
+	t.Run("Form Request JSON Response", func(t *testing.T) {
+		svc := &CustomPathService{}
+		ts := setupServer(t, serverCfg, svc, "/v1")
+		defer ts.Close()
+
+		client, err := NewHTTPClient(clientCfg)
+		require.NoError(t, err)
+
+		var output CustomOutput
+		err = client.Call("POST", ts.URL+"/v1/Process", CustomInput{Data: "a&b"}, &output,
+			WithContentType(MediaTypeForm), WithAccept(MediaTypeJSON))
+		require.NoError(t, err)
+		require.Equal(t, "Processed: a&b", output.Result)
+	})
+
+	t.Run("Unsupported And Unacceptable Media Types", func(t *testing.T) {
+		svc := &CustomPathService{}
+		ts := setupServer(t, serverCfg, svc, "/v1")
+		defer ts.Close()
+
+		resp, err := http.Post(ts.URL+"/v1/Process", "text/csv", bytes.NewBufferString("data\nx"))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+
+		req, err := http.NewRequest("POST", ts.URL+"/v1/Process", bytes.NewBufferString(`{"data":"x"}`))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", MediaTypeJSON)
+		req.Header.Set("Accept", "image/png")
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotAcceptable, resp.StatusCode)
+
+		req.Body, req.ContentLength = http.NoBody, 0
+		req.Header.Set("Accept", "text/html;q=0.9, application/*;q=0.8")
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NotEqual(t, http.StatusNotAcceptable, resp.StatusCode)
+	})
+
+	t.Run("Negotiation", func(t *testing.T) {
+		outputType := reflect.TypeOf(CustomOutput{})
+		c, ok := negotiateCodec("", outputType)
+		require.True(t, ok)
+		require.Equal(t, MediaTypeJSON, c.MediaType())
+
+		c, ok = negotiateCodec("application/xml;q=0.5, application/msgpack", outputType)
+		require.True(t, ok)
+		require.Equal(t, MediaTypeMsgpack, c.MediaType())
+
+		c, ok = negotiateCodec("text/xml", outputType)
+		require.True(t, ok)
+		require.Equal(t, MediaTypeXML, c.MediaType())
+
+		_, ok = negotiateCodec("application/x-protobuf", outputType)
+		require.False(t, ok, "protobuf only applies to proto.Message types")
+	})
+
+	t.Run("Protobuf Codec", func(t *testing.T) {
+		c, ok := CodecFor("application/protobuf")
+		require.True(t, ok)
+		require.True(t, c.(codecSupporter).Supports(reflect.TypeOf(wrapperspb.StringValue{})))
+		require.False(t, c.(codecSupporter).Supports(reflect.TypeOf(CustomOutput{})))
+
+		data, err := c.Marshal(wrapperspb.String("hello"))
+		require.NoError(t, err)
+		var decoded wrapperspb.StringValue
+		require.NoError(t, c.Unmarshal(data, &decoded))
+		require.Equal(t, "hello", decoded.GetValue())
+
+		_, err = c.Marshal(CustomOutput{})
+		require.Error(t, err)
+	})
+
+	t.Run("Form Codec", func(t *testing.T) {
+		limit := 10
+		in := formPayload{
+			Name:    "Ann",
+			Tags:    []string{"a", "b"},
+			Limit:   &limit,
+			Since:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			Address: formAddress{City: "Paris"},
+			Ignored: "x",
+		}
+		data, err := formCodec{}.Marshal(in)
+		require.NoError(t, err)
+		require.Equal(t, "address.city=Paris&full_name=Ann&limit=10&since=2024-03-01&tags=a&tags=b", string(data))
+
+		var out formPayload
+		require.NoError(t, formCodec{}.Unmarshal(data, &out))
+		in.Ignored = ""
+		require.Equal(t, in, out)
+		require.Nil(t, out.Extra)
+
+		err = formCodec{}.Unmarshal([]byte("limit=ten"), &out)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "field limit")
+	})
+
+	t.Run("Swagger Media Types", func(t *testing.T) {
+		svc := &TestService{}
+		ts := setupServer(t, serverCfg, svc, "/v1")
+		defer ts.Close()
+
+		resp, err := http.Get(ts.URL + "/api/docs/swagger.json")
+		require.NoError(t, err)
+		var doc map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&doc))
+
+		post := doc["paths"].(map[string]interface{})["/v1/Create"].(map[string]interface{})["post"].(map[string]interface{})
+		content := post["requestBody"].(map[string]interface{})["content"].(map[string]interface{})
+		for _, mediaType := range []string{MediaTypeJSON, MediaTypeXML, MediaTypeForm, MediaTypeMsgpack} {
+			require.Contains(t, content, mediaType)
+		}
+		require.NotContains(t, content, MediaTypeProtobuf)
+
+		okContent := post["responses"].(map[string]interface{})["200"].(map[string]interface{})["content"].(map[string]interface{})
+		require.Contains(t, okContent, MediaTypeXML)
+		require.NotContains(t, okContent, MediaTypeForm)
+	})
+}
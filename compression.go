@@ -0,0 +1,354 @@
+package httpc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content codings supported for request and response bodies
+const (
+	EncodingGzip = "gzip"
+	EncodingZstd = "zstd"
+)
+
+const (
+	defaultCompressionMinBytes   = 1024
+	defaultDecompressionMaxBytes = 32 << 20
+
+	// clientAcceptEncoding is sent by HTTPClient, which then decodes the
+	// response itself
+	clientAcceptEncoding = "zstd, gzip"
+)
+
+var (
+	gzipWriters = sync.Pool{New: func() interface{} { return gzip.NewWriter(nil) }}
+	zstdWriters = sync.Pool{New: func() interface{} {
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return w
+	}}
+)
+
+// compressor compresses into w with a pooled encoder, returned to its pool
+// on Close
+type compressor struct {
+	io.WriteCloser
+	encoding string
+}
+
+// newCompressor returns an encoder of encoding writing to w
+func newCompressor(encoding string, w io.Writer) *compressor {
+	if encoding == EncodingZstd {
+		zw := zstdWriters.Get().(*zstd.Encoder)
+		zw.Reset(w)
+		return &compressor{WriteCloser: zw, encoding: encoding}
+	}
+	gw := gzipWriters.Get().(*gzip.Writer)
+	gw.Reset(w)
+	return &compressor{WriteCloser: gw, encoding: encoding}
+}
+
+// Flush writes the data compressed so far
+func (c *compressor) Flush() error {
+	return c.WriteCloser.(interface{ Flush() error }).Flush()
+}
+
+func (c *compressor) Close() error {
+	err := c.WriteCloser.Close()
+	if c.encoding == EncodingZstd {
+		zstdWriters.Put(c.WriteCloser)
+	} else {
+		gzipWriters.Put(c.WriteCloser)
+	}
+	return err
+}
+
+// compress returns data encoded with encoding
+func compress(encoding string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := newCompressor(encoding, &buf)
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressor reads the decoded content of body
+type decompressor struct {
+	io.Reader
+	body  io.Closer
+	close func()
+}
+
+// errUnsupportedEncoding is returned by newDecompressor for an encoding it
+// cannot decode
+var errUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// newDecompressor decodes body encoded with encoding. maxWindow bounds the
+// history a zstd frame may ask the decoder to allocate, and a positive
+// maxSize the size of a decoded frame, so a small body cannot claim a large
+// allocation. The returned error wraps errUnsupportedEncoding, or reports a
+// malformed header.
+func newDecompressor(encoding string, body io.ReadCloser, maxWindow, maxSize int64) (io.ReadCloser, error) {
+	switch strings.ToLower(encoding) {
+	case EncodingGzip, "x-gzip":
+		gr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		return &decompressor{Reader: gr, body: body, close: func() { gr.Close() }}, nil
+	case EncodingZstd:
+		options := []zstd.DOption{zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(uint64(max(maxWindow, zstd.MinWindowSize)))}
+		if maxSize > 0 {
+			options = append(options, zstd.WithDecoderMaxMemory(uint64(maxSize)))
+		}
+		zr, err := zstd.NewReader(body, options...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd body: %w", err)
+		}
+		return &decompressor{Reader: zr, body: body, close: zr.Close}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", errUnsupportedEncoding, encoding)
+}
+
+func (d *decompressor) Close() error {
+	d.close()
+	return d.body.Close()
+}
+
+// compressionConfig holds the compression settings of a Server
+type compressionConfig struct {
+	enabled  bool  // Compress responses
+	minBytes int   // Smallest response body compressed
+	maxBytes int64 // Largest decompressed request body
+}
+
+// newCompressionConfig reads the compression settings of c
+func newCompressionConfig(c *config.Config) compressionConfig {
+	return compressionConfig{
+		enabled:  getBoolConfig(c, "compression_enabled", false),
+		minBytes: getIntConfig(c, "compression_min_bytes", defaultCompressionMinBytes),
+		maxBytes: int64(getIntConfig(c, "decompression_max_bytes", defaultDecompressionMaxBytes)),
+	}
+}
+
+// compressionMiddleware decodes gzip and zstd request bodies, up to the
+// decompression limit, and compresses responses when enabled. Requests in
+// other encodings are refused with 415.
+func compressionMiddleware(cfg compressionConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if encoding := c.GetHeader("Content-Encoding"); encoding != "" && !strings.EqualFold(encoding, "identity") {
+			body, err := newDecompressor(encoding, c.Request.Body, cfg.maxBytes, cfg.maxBytes)
+			if err != nil {
+				status := http.StatusBadRequest
+				if errors.Is(err, errUnsupportedEncoding) {
+					status = http.StatusUnsupportedMediaType
+					c.Header("Accept-Encoding", "gzip, zstd")
+				}
+				logger.ErrorContext(c.Request.Context(), "Request decompression failed", logger.ErrField(err))
+				c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+				return
+			}
+			defer body.Close()
+			// Exceeding the limit fails the read with an *http.MaxBytesError, answered with 413
+			c.Request.Body = http.MaxBytesReader(c.Writer, body, cfg.maxBytes)
+			c.Request.Header.Del("Content-Encoding")
+			c.Request.Header.Del("Content-Length")
+			c.Request.ContentLength = -1
+		}
+
+		if !cfg.enabled || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" {
+			c.Next()
+			return
+		}
+		w := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, minBytes: cfg.minBytes}
+		c.Writer = w
+		defer w.finish()
+		c.Next()
+	}
+}
+
+// negotiateEncoding returns the content coding preferred by an
+// Accept-Encoding header, or "" if none is supported
+func negotiateEncoding(accept string) string {
+	for _, r := range parseAccept(accept) {
+		switch r.mediaType {
+		case EncodingZstd, EncodingGzip:
+			return r.mediaType
+		case "*":
+			return EncodingGzip
+		}
+	}
+	return ""
+}
+
+// compressible reports whether a response of contentType is worth
+// compressing. Streams, which are flushed item by item, are not.
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == MediaTypeEventStream || mediaType == MediaTypeNDJSON:
+		return false
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case MediaTypeJSON, MediaTypeXML, MediaTypeForm, "application/yaml", "application/javascript", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// compressWriter holds back the start of a response until minBytes are
+// written, then compresses the rest if the response is compressible. Smaller
+// responses are written unchanged when the handler returns.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minBytes int
+	buf      []byte
+	decided  bool
+	enc      *compressor // Nil when the response is written unchanged
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.decided {
+		if w.enc != nil {
+			return w.enc.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minBytes {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written counts the bytes held back as written
+func (w *compressWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+// Flush writes out a response that is being streamed, compressing it only if
+// it was already large enough
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide chooses whether to compress the response, then writes what was held back
+func (w *compressWriter) decide(large bool) error {
+	w.decided = true
+	header := w.Header()
+	if compressible(header.Get("Content-Type")) {
+		header.Add("Vary", "Accept-Encoding")
+		status := w.Status()
+		if large && !w.ResponseWriter.Written() && header.Get("Content-Encoding") == "" && header.Get("Content-Range") == "" &&
+			status != http.StatusNoContent && status != http.StatusNotModified && status != http.StatusPartialContent {
+			header.Del("Content-Length")
+			header.Set("Content-Encoding", w.encoding)
+			if etag := header.Get("ETag"); etag != "" {
+				header.Set("ETag", codedETag(etag, w.encoding))
+			}
+			w.enc = newCompressor(w.encoding, w.ResponseWriter)
+		}
+	}
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// finish writes a response smaller than minBytes, or ends the compressed one
+func (w *compressWriter) finish() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.enc != nil {
+		if err := w.enc.Close(); err != nil {
+			logger.Warn("Failed to compress response", logger.ErrField(err))
+		}
+	}
+}
+
+// acceptCompressed asks for a compressed response, which HTTPClient decodes
+// itself, unless the request sets its own Accept-Encoding or asks for a
+// range, whose offsets would apply to the compressed content
+func acceptCompressed(req *http.Request) {
+	if req.Header.Get("Accept-Encoding") == "" && req.Header.Get("Range") == "" {
+		req.Header.Set("Accept-Encoding", clientAcceptEncoding)
+	}
+}
+
+// decompressResponse decodes the body of a response to a request made with
+// acceptCompressed, leaving other responses unchanged
+func decompressResponse(req *http.Request, resp *http.Response) error {
+	encoding := resp.Header.Get("Content-Encoding")
+	if encoding == "" || req.Header.Get("Accept-Encoding") != clientAcceptEncoding {
+		return nil
+	}
+	// Streams and downloads may exceed any size, but not claim a larger window
+	body, err := newDecompressor(encoding, resp.Body, defaultDecompressionMaxBytes, 0)
+	if err != nil {
+		return err
+	}
+	resp.Body = body
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// compressBody encodes a request body with the client's compression when it
+// is at least its minimum size, returning the body to send and its encoding
+func (h *HTTPClient) compressBody(data []byte) ([]byte, string, error) {
+	if h.config.Compression == "" || len(data) < h.config.CompressionMinBytes {
+		return data, "", nil
+	}
+	compressed, err := compress(h.config.Compression, data)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to compress request body: %w", err)
+	}
+	return compressed, h.config.Compression, nil
+}
@@ -0,0 +1,249 @@
+package httpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/stretchr/testify/require"
+)
+
+// note is the input and output of noteService
+type note struct {
+	Text string `json:"text"`
+}
+
+// noteService echoes notes, seeing request bodies after decompression
+type noteService struct{}
+
+func (s *noteService) Echo(ctx context.Context, in note) (note, error) {
+	return in, nil
+}
+
+func (s *noteService) RegisterMethods() []MethodInfo {
+	return []MethodInfo{
+		{
+			Name:       "Echo",
+			HTTPMethod: "POST",
+			InputType:  reflect.TypeOf(note{}),
+			OutputType: reflect.TypeOf(note{}),
+			Func:       reflect.ValueOf(s).MethodByName("Echo"),
+		},
+	}
+}
+
+// newCompressionServer serves a noteService at /notes, a streamService at
+// /stream and a fileService at /files with the given settings
+func newCompressionServer(t *testing.T, settings map[string]interface{}) *httptest.Server {
+	settings["otel_enabled"] = false
+	settings["port"] = 8080
+	c, err := config.New(config.WithDefault(settings))
+	require.NoError(t, err)
+	srv, err := NewServer(c)
+	require.NoError(t, err)
+	require.NoError(t, srv.RegisterService(&noteService{}, WithPathPrefix("/notes")))
+	require.NoError(t, srv.RegisterService(&streamService{stopped: make(chan struct{})}, WithPathPrefix("/stream")))
+	require.NoError(t, srv.RegisterService(&fileService{}, WithPathPrefix("/files")))
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// sendEncoded sends body to url with the given request headers, without the
+// transport decoding the response
+func sendEncoded(t *testing.T, method, url string, body []byte, header map[string]string) *http.Response {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", MediaTypeJSON)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// decodeBodyOf reads the body of resp decoded with its Content-Encoding
+func decodeBodyOf(t *testing.T, resp *http.Response) string {
+	body := io.ReadCloser(resp.Body)
+	if encoding := resp.Header.Get("Content-Encoding"); encoding != "" {
+		var err error
+		body, err = newDecompressor(encoding, resp.Body, defaultDecompressionMaxBytes, 0)
+		require.NoError(t, err)
+		defer body.Close()
+	}
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestCompression(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	long := `{"text":"` + strings.Repeat("compress me ", 200) + `"}`
+
+	t.Run("Compress Responses", func(t *testing.T) {
+		ts := newCompressionServer(t, map[string]interface{}{"compression_enabled": true})
+		for accept, encoding := range map[string]string{
+			"gzip":                       EncodingGzip,
+			"zstd":                       EncodingZstd,
+			"gzip;q=0.5, zstd":           EncodingZstd,
+			"*":                          EncodingGzip,
+			"br":                         "",
+			"identity":                   "",
+			"gzip;q=0, zstd;q=0, br;q=1": "",
+		} {
+			resp := sendEncoded(t, "POST", ts.URL+"/notes/Echo", []byte(long), map[string]string{"Accept-Encoding": accept})
+			require.Equal(t, http.StatusOK, resp.StatusCode, accept)
+			require.Equal(t, encoding, resp.Header.Get("Content-Encoding"), accept)
+			require.Equal(t, long, decodeBodyOf(t, resp), accept)
+			if encoding != "" {
+				require.Equal(t, "Accept-Encoding", resp.Header.Get("Vary"))
+				require.Less(t, resp.ContentLength, int64(len(long)), accept)
+			}
+		}
+	})
+
+	t.Run("Small Responses Are Not Compressed", func(t *testing.T) {
+		ts := newCompressionServer(t, map[string]interface{}{"compression_enabled": true})
+		resp := sendEncoded(t, "POST", ts.URL+"/notes/Echo", []byte(`{"text":"short"}`), map[string]string{"Accept-Encoding": "gzip"})
+		require.Empty(t, resp.Header.Get("Content-Encoding"))
+		require.Equal(t, "Accept-Encoding", resp.Header.Get("Vary"))
+		require.Equal(t, `{"text":"short"}`, readAll(t, resp))
+
+		ts = newCompressionServer(t, map[string]interface{}{"compression_enabled": true, "compression_min_bytes": 8})
+		resp = sendEncoded(t, "POST", ts.URL+"/notes/Echo", []byte(`{"text":"short"}`), map[string]string{"Accept-Encoding": "gzip"})
+		require.Equal(t, EncodingGzip, resp.Header.Get("Content-Encoding"))
+		require.Equal(t, `{"text":"short"}`, decodeBodyOf(t, resp))
+	})
+
+	t.Run("Disabled By Default", func(t *testing.T) {
+		ts := newCompressionServer(t, map[string]interface{}{})
+		resp := sendEncoded(t, "POST", ts.URL+"/notes/Echo", []byte(long), map[string]string{"Accept-Encoding": "gzip"})
+		require.Empty(t, resp.Header.Get("Content-Encoding"))
+		require.Equal(t, long, readAll(t, resp))
+	})
+
+	t.Run("Streams And Binary Content Are Not Compressed", func(t *testing.T) {
+		ts := newCompressionServer(t, map[string]interface{}{"compression_enabled": true, "compression_min_bytes": 0})
+		resp := sendEncoded(t, "GET", ts.URL+"/stream/Ticks?count=200", nil, map[string]string{"Accept-Encoding": "gzip", "Accept": MediaTypeNDJSON})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Empty(t, resp.Header.Get("Content-Encoding"))
+		require.Equal(t, 200, strings.Count(readAll(t, resp), "\n"))
+
+		resp = sendEncoded(t, "GET", ts.URL+"/files/Report?name=r", nil, map[string]string{"Accept-Encoding": "gzip", "Range": "bytes=2-4"})
+		require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+		require.Empty(t, resp.Header.Get("Content-Encoding"))
+		require.Equal(t, "234", readAll(t, resp))
+	})
+
+	t.Run("Decompress Requests", func(t *testing.T) {
+		ts := newCompressionServer(t, map[string]interface{}{})
+		for _, encoding := range []string{EncodingGzip, EncodingZstd} {
+			body, err := compress(encoding, []byte(long))
+			require.NoError(t, err)
+			resp := sendEncoded(t, "POST", ts.URL+"/notes/Echo", body, map[string]string{"Content-Encoding": encoding})
+			require.Equal(t, http.StatusOK, resp.StatusCode, encoding)
+			require.Equal(t, long, readAll(t, resp), encoding)
+		}
+
+		resp := sendEncoded(t, "POST", ts.URL+"/notes/Echo", []byte(long), map[string]string{"Content-Encoding": "br"})
+		require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+		require.Contains(t, readAll(t, resp), "unsupported content encoding: br")
+
+		resp = sendEncoded(t, "POST", ts.URL+"/notes/Echo", []byte(long), map[string]string{"Content-Encoding": "gzip"})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		// Unsupported encodings are told apart from malformed bodies by their error
+		_, err := newDecompressor("br", io.NopCloser(strings.NewReader(long)), defaultDecompressionMaxBytes, 0)
+		require.ErrorIs(t, err, errUnsupportedEncoding)
+		_, err = newDecompressor(EncodingGzip, io.NopCloser(strings.NewReader(long)), defaultDecompressionMaxBytes, 0)
+		require.Error(t, err)
+		require.NotErrorIs(t, err, errUnsupportedEncoding)
+	})
+
+	t.Run("Decompression Limit", func(t *testing.T) {
+		ts := newCompressionServer(t, map[string]interface{}{"decompression_max_bytes": 1024})
+		// A small body that expands beyond the limit
+		bomb, err := compress(EncodingGzip, []byte(`{"text":"`+strings.Repeat("a", 1<<20)+`"}`))
+		require.NoError(t, err)
+		require.Less(t, len(bomb), 4096)
+		resp := sendEncoded(t, "POST", ts.URL+"/notes/Echo", bomb, map[string]string{"Content-Encoding": "gzip"})
+		require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+
+	t.Run("Oversized Zstd Window", func(t *testing.T) {
+		ts := newCompressionServer(t, map[string]interface{}{})
+		// A zstd frame declaring a 256 MiB window for a 12 byte raw block
+		frame := []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00, 18 << 3, 1 | 12<<3, 0, 0}
+		frame = append(frame, `{"text":"a"}`...)
+		resp := sendEncoded(t, "POST", ts.URL+"/notes/Echo", frame, map[string]string{"Content-Encoding": "zstd"})
+		require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+		// The same frame with a 1 KiB window is decoded
+		frame[5] = 0
+		resp = sendEncoded(t, "POST", ts.URL+"/notes/Echo", frame, map[string]string{"Content-Encoding": "zstd"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, `{"text":"a"}`, readAll(t, resp))
+	})
+
+	t.Run("Client Compression", func(t *testing.T) {
+		ts := newCompressionServer(t, map[string]interface{}{"compression_enabled": true})
+		var received []string
+		record := func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				received = append(received, req.Header.Get("Content-Encoding")+"/"+req.Header.Get("Accept-Encoding"))
+				return next(req)
+			}
+		}
+		c, err := config.New(config.WithDefault(map[string]interface{}{
+			"otel_enabled":            false,
+			"http_client_compression": EncodingZstd,
+		}))
+		require.NoError(t, err)
+		client, err := NewHTTPClient(c, WithInterceptor(record))
+		require.NoError(t, err)
+
+		var out note
+		resp, err := client.CallWithResponse("POST", ts.URL+"/notes/Echo", note{Text: strings.Repeat("z", 2048)}, &out)
+		require.NoError(t, err)
+		require.Equal(t, strings.Repeat("z", 2048), out.Text)
+		require.Equal(t, `{"text":"`+strings.Repeat("z", 2048)+`"}`, string(resp.Body), "the response is decoded")
+		require.Empty(t, resp.Header.Get("Content-Encoding"))
+
+		require.NoError(t, client.Call("POST", ts.URL+"/notes/Echo", note{Text: "small"}, &out))
+		require.Equal(t, "small", out.Text)
+		require.Equal(t, []string{"zstd/zstd, gzip", "/zstd, gzip"}, received)
+	})
+
+	t.Run("Client Limits Decoded Size", func(t *testing.T) {
+		ts := newCompressionServer(t, map[string]interface{}{"compression_enabled": true})
+		client := newLimitedClient(t, 1024)
+		var out note
+		err := client.Call("POST", ts.URL+"/notes/Echo", note{Text: strings.Repeat("z", 4096)}, &out)
+		var tooLarge *ResponseTooLargeError
+		require.ErrorAs(t, err, &tooLarge)
+	})
+
+	t.Run("Invalid Client Compression", func(t *testing.T) {
+		c, err := config.New(config.WithDefault(map[string]interface{}{
+			"otel_enabled":            false,
+			"http_client_compression": "br",
+		}))
+		require.NoError(t, err)
+		_, err = NewHTTPClient(c)
+		require.ErrorContains(t, err, "Compression")
+	})
+}
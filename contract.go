@@ -0,0 +1,426 @@
+package httpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"gopkg.in/yaml.v3"
+)
+
+// ContractDriftError lists the differences between the document a server
+// generates and the OpenAPI contract it implements
+type ContractDriftError struct {
+	Contract string // Location of the contract
+	Drift    []string
+}
+
+func (e *ContractDriftError) Error() string {
+	return fmt.Sprintf("OpenAPI document drifted from contract %s: %s", e.Contract, strings.Join(e.Drift, "; "))
+}
+
+// contractConfig holds the openapi_contract* config keys
+type contractConfig struct {
+	path string
+	mode string
+	doc  map[string]interface{}
+}
+
+func newContractConfig(c *config.Config) (contractConfig, error) {
+	cfg := contractConfig{
+		path: getStringConfig(c, "openapi_contract", ""),
+		mode: getStringConfig(c, "openapi_contract_check", DocValidationWarn),
+	}
+	if cfg.mode != DocValidationOff && cfg.mode != DocValidationWarn && cfg.mode != DocValidationFail {
+		return cfg, fmt.Errorf("unsupported openapi_contract_check %q", cfg.mode)
+	}
+	if cfg.path == "" || cfg.mode == DocValidationOff {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(cfg.path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read OpenAPI contract: %w", err)
+	}
+	if cfg.doc, err = ParseOpenAPI(data); err != nil {
+		return cfg, fmt.Errorf("invalid OpenAPI contract %s: %w", cfg.path, err)
+	}
+	return cfg, nil
+}
+
+// ParseOpenAPI decodes an OpenAPI document in JSON or YAML into the form
+// encoding/json produces, with string keys and float64 numbers
+func ParseOpenAPI(data []byte) (map[string]interface{}, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	// Unquoted YAML keys such as response codes decode as integers
+	normalized, err := json.Marshal(stringKeys(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(normalized, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	return doc, nil
+}
+
+// stringKeys converts the mapping keys of a decoded YAML value to strings
+func stringKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = stringKeys(value)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = stringKeys(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = stringKeys(value)
+		}
+	}
+	return v
+}
+
+// CheckContract compares the generated document with the openapi_contract
+// document, returning a *ContractDriftError listing their differences. It
+// returns nil when no contract is configured.
+func (s *Server) CheckContract() error {
+	if s.contract.doc == nil {
+		return nil
+	}
+	served, err := s.OpenAPIDocument()
+	if err != nil {
+		return err
+	}
+	contract, err := cloneDocument(s.contract.doc)
+	if err != nil {
+		return err
+	}
+	// Compare both documents in the same OpenAPI version
+	contractVersion, _ := contract["openapi"].(string)
+	if strings.HasPrefix(contractVersion, "3.1") && s.openAPIVersion == OpenAPIVersion30 {
+		convertOpenAPI31(served)
+	} else if !strings.HasPrefix(contractVersion, "3.1") && s.openAPIVersion == OpenAPIVersion31 {
+		convertOpenAPI31(contract)
+	}
+
+	d := &driftChecker{contract: contract, served: served, seen: map[[2]string]bool{}}
+	d.compare()
+	if len(d.drift) == 0 {
+		return nil
+	}
+	return &ContractDriftError{Contract: s.contract.path, Drift: d.drift}
+}
+
+// checkContractAtStartup runs CheckContract according to openapi_contract_check,
+// returning an error only in fail mode
+func (s *Server) checkContractAtStartup() error {
+	err := s.CheckContract()
+	if err == nil {
+		return nil
+	}
+	if s.contract.mode == DocValidationFail {
+		return err
+	}
+	if drift, ok := err.(*ContractDriftError); ok {
+		for _, d := range drift.Drift {
+			logger.Warn("OpenAPI document drifted from contract", logger.String("contract", drift.Contract), logger.String("drift", d))
+		}
+		return nil
+	}
+	logger.Warn("Failed to check OpenAPI contract", logger.ErrField(err))
+	return nil
+}
+
+// cloneDocument returns a deep copy of a decoded document
+func cloneDocument(doc map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	var clone map[string]interface{}
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, fmt.Errorf("failed to decode OpenAPI document: %w", err)
+	}
+	return clone, nil
+}
+
+// annotationKeywords only document a schema, so they are not compared
+var annotationKeywords = map[string]bool{
+	"description": true, "example": true, "examples": true, "title": true,
+	"externalDocs": true, "$comment": true,
+}
+
+// driftChecker compares the operations and schemas of a contract with the
+// served document. Documentation such as summaries and tags is not compared.
+type driftChecker struct {
+	contract map[string]interface{}
+	served   map[string]interface{}
+	seen     map[[2]string]bool // Pairs of references already compared
+	drift    []string
+}
+
+func (d *driftChecker) addf(location, format string, args ...interface{}) {
+	d.drift = append(d.drift, location+": "+fmt.Sprintf(format, args...))
+}
+
+func (d *driftChecker) compare() {
+	contractOps := documentOperations(d.contract)
+	servedOps := documentOperations(d.served)
+	for _, key := range sortedKeys(contractOps) {
+		served, ok := servedOps[key]
+		if !ok {
+			d.addf(key, "operation of the contract is not served")
+			continue
+		}
+		d.compareOperation(key, contractOps[key], served)
+	}
+	for _, key := range sortedKeys(servedOps) {
+		if _, ok := contractOps[key]; !ok {
+			d.addf(key, "operation is not in the contract")
+		}
+	}
+}
+
+// documentOperations indexes the operations of doc by "METHOD path"
+func documentOperations(doc map[string]interface{}) map[string]map[string]interface{} {
+	operations := map[string]map[string]interface{}{}
+	paths, _ := doc["paths"].(map[string]interface{})
+	for path, item := range paths {
+		item, _ := item.(map[string]interface{})
+		for method, operation := range item {
+			if operation, ok := operation.(map[string]interface{}); ok && operationMethods[method] {
+				operations[strings.ToUpper(method)+" "+path] = operation
+			}
+		}
+	}
+	return operations
+}
+
+func (d *driftChecker) compareOperation(location string, contract, served map[string]interface{}) {
+	if contract["operationId"] != served["operationId"] {
+		d.addf(location, "operationId is %v in the contract, %v when served", contract["operationId"], served["operationId"])
+	}
+
+	contractParams := operationParameters(contract)
+	servedParams := operationParameters(served)
+	for _, key := range sortedKeys(contractParams) {
+		param := contractParams[key]
+		loc := fmt.Sprintf("%s parameter %s", location, key)
+		servedParam, ok := servedParams[key]
+		if !ok {
+			d.addf(loc, "parameter of the contract is not served")
+			continue
+		}
+		if param["required"] == true != (servedParam["required"] == true) {
+			d.addf(loc, "required is %v in the contract, %v when served", param["required"] == true, servedParam["required"] == true)
+		}
+		d.compareSchemas(loc, param["schema"], servedParam["schema"])
+	}
+	for _, key := range sortedKeys(servedParams) {
+		if _, ok := contractParams[key]; !ok {
+			d.addf(fmt.Sprintf("%s parameter %s", location, key), "parameter is not in the contract")
+		}
+	}
+
+	contractBody, _ := contract["requestBody"].(map[string]interface{})
+	servedBody, _ := served["requestBody"].(map[string]interface{})
+	switch {
+	case contractBody == nil && servedBody != nil:
+		d.addf(location, "request body is not in the contract")
+	case contractBody != nil && servedBody == nil:
+		d.addf(location, "request body of the contract is not served")
+	case contractBody != nil:
+		d.compareContent(location+" request body", contractBody["content"], servedBody["content"])
+	}
+
+	contractResponses, _ := contract["responses"].(map[string]interface{})
+	servedResponses, _ := served["responses"].(map[string]interface{})
+	for _, code := range sortedKeys(contractResponses) {
+		loc := fmt.Sprintf("%s response %s", location, code)
+		servedResponse, ok := servedResponses[code].(map[string]interface{})
+		if !ok {
+			d.addf(loc, "response of the contract is not served")
+			continue
+		}
+		response, _ := contractResponses[code].(map[string]interface{})
+		d.compareContent(loc, response["content"], servedResponse["content"])
+	}
+	// Servers add error responses to every operation; only successful ones must be in the contract
+	for _, code := range sortedKeys(servedResponses) {
+		if _, ok := contractResponses[code]; !ok && strings.HasPrefix(code, "2") {
+			d.addf(fmt.Sprintf("%s response %s", location, code), "response is not in the contract")
+		}
+	}
+}
+
+// operationParameters indexes the parameters of an operation by "in name"
+func operationParameters(operation map[string]interface{}) map[string]map[string]interface{} {
+	params := map[string]map[string]interface{}{}
+	list, _ := operation["parameters"].([]interface{})
+	for _, p := range list {
+		if p, ok := p.(map[string]interface{}); ok {
+			params[fmt.Sprintf("%v %v", p["in"], p["name"])] = p
+		}
+	}
+	return params
+}
+
+// compareContent checks that every media type of the contract is served with the same schema
+func (d *driftChecker) compareContent(location string, contract, served interface{}) {
+	contractMedia, _ := contract.(map[string]interface{})
+	servedMedia, _ := served.(map[string]interface{})
+	for _, mediaType := range sortedKeys(contractMedia) {
+		servedType, ok := servedMedia[mediaType].(map[string]interface{})
+		if !ok {
+			d.addf(location, "media type %s of the contract is not served", mediaType)
+			continue
+		}
+		contractType, _ := contractMedia[mediaType].(map[string]interface{})
+		d.compareSchemas(location+" "+mediaType, contractType["schema"], servedType["schema"])
+	}
+}
+
+// compareSchemas compares two schemas with their references resolved in
+// their own document, so component names may differ
+func (d *driftChecker) compareSchemas(location string, contract, served interface{}) {
+	contractSchema, _ := contract.(map[string]interface{})
+	servedSchema, _ := served.(map[string]interface{})
+	contractRef, _ := contractSchema["$ref"].(string)
+	servedRef, _ := servedSchema["$ref"].(string)
+	if contractRef != "" && servedRef != "" {
+		// Components are compared once, and reported where the contract declares them
+		pair := [2]string{contractRef, servedRef}
+		if d.seen[pair] {
+			return
+		}
+		d.seen[pair] = true
+		location = "components.schemas." + strings.TrimPrefix(contractRef, componentsPrefix)
+	}
+	contractSchema = resolveSchema(d.contract, contractSchema)
+	servedSchema = resolveSchema(d.served, servedSchema)
+
+	keys := map[string]bool{}
+	for key := range contractSchema {
+		keys[key] = true
+	}
+	for key := range servedSchema {
+		keys[key] = true
+	}
+	for _, key := range sortedKeys(keys) {
+		if annotationKeywords[key] {
+			continue
+		}
+		c, inContract := contractSchema[key]
+		s, inServed := servedSchema[key]
+		switch {
+		case !inContract:
+			d.addf(location, "%s is not in the contract, served as %s", key, compactJSON(s))
+			continue
+		case !inServed:
+			d.addf(location, "%s is %s in the contract, not served", key, compactJSON(c))
+			continue
+		}
+		switch key {
+		case "properties":
+			d.compareProperties(location, c, s)
+		case "items", "additionalProperties", "not":
+			cm, cok := c.(map[string]interface{})
+			sm, sok := s.(map[string]interface{})
+			if cok && sok {
+				d.compareSchemas(location+"."+key, cm, sm)
+				continue
+			}
+			d.compareValues(location, key, c, s)
+		case "allOf", "anyOf", "oneOf":
+			cl, _ := c.([]interface{})
+			sl, _ := s.([]interface{})
+			if len(cl) != len(sl) {
+				d.compareValues(location, key, c, s)
+				continue
+			}
+			for i := range cl {
+				d.compareSchemas(fmt.Sprintf("%s.%s[%d]", location, key, i), cl[i], sl[i])
+			}
+		case "required", "enum", "type":
+			d.compareValues(location, key, unordered(c), unordered(s))
+		default:
+			d.compareValues(location, key, c, s)
+		}
+	}
+}
+
+func (d *driftChecker) compareProperties(location string, contract, served interface{}) {
+	contractProps, _ := contract.(map[string]interface{})
+	servedProps, _ := served.(map[string]interface{})
+	for _, name := range sortedKeys(contractProps) {
+		if _, ok := servedProps[name]; !ok {
+			d.addf(location, "property %s of the contract is not served", name)
+			continue
+		}
+		d.compareSchemas(location+"."+name, contractProps[name], servedProps[name])
+	}
+	for _, name := range sortedKeys(servedProps) {
+		if _, ok := contractProps[name]; !ok {
+			d.addf(location, "property %s is not in the contract", name)
+		}
+	}
+}
+
+func (d *driftChecker) compareValues(location, key string, contract, served interface{}) {
+	if !reflect.DeepEqual(contract, served) {
+		d.addf(location, "%s is %s in the contract, %s when served", key, compactJSON(contract), compactJSON(served))
+	}
+}
+
+// resolveSchema follows the local references of schema within doc
+func resolveSchema(doc, schema map[string]interface{}) map[string]interface{} {
+	for i := 0; i < 32; i++ {
+		ref, ok := schema["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, componentsPrefix) {
+			return schema
+		}
+		components, _ := doc["components"].(map[string]interface{})
+		schemas, _ := components["schemas"].(map[string]interface{})
+		target, ok := schemas[strings.TrimPrefix(ref, componentsPrefix)].(map[string]interface{})
+		if !ok {
+			return schema
+		}
+		schema = target
+	}
+	return schema
+}
+
+// unordered sorts a list of values whose order carries no meaning
+func unordered(v interface{}) interface{} {
+	list, ok := v.([]interface{})
+	if !ok {
+		return v
+	}
+	sorted := make([]string, len(list))
+	for i, item := range list {
+		sorted[i] = compactJSON(item)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func compactJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
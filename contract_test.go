@@ -0,0 +1,150 @@
+package httpc
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/stretchr/testify/require"
+)
+
+// newContractServer creates a server checking its document against the contract at path
+func newContractServer(t *testing.T, path, mode string) *Server {
+	c, err := config.New(config.WithDefault(map[string]interface{}{
+		"otel_enabled":           false,
+		"port":                   8080,
+		"openapi_contract":       path,
+		"openapi_contract_check": mode,
+	}))
+	require.NoError(t, err)
+	srv, err := NewServer(c)
+	require.NoError(t, err)
+	return srv
+}
+
+// writeContract writes the document of a server registering svc at prefix as a contract
+func writeContract(t *testing.T, svc interface{}, prefix string, edit func(doc map[string]interface{})) string {
+	srv := newDocServer(t, OpenAPIVersion30, DocValidationFail)
+	require.NoError(t, srv.RegisterService(svc, WithPathPrefix(prefix)))
+	doc, err := srv.OpenAPIDocument()
+	require.NoError(t, err)
+	if edit != nil {
+		edit(doc)
+	}
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "openapi.json")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	return path
+}
+
+func TestContract(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	t.Run("Matching Document", func(t *testing.T) {
+		path := writeContract(t, &CatalogService{}, "/catalog", nil)
+		srv := newContractServer(t, path, DocValidationFail)
+		require.NoError(t, srv.RegisterService(&CatalogService{}, WithPathPrefix("/catalog")))
+		require.NoError(t, srv.CheckContract())
+	})
+
+	t.Run("Reports Drift", func(t *testing.T) {
+		path := writeContract(t, &CatalogService{}, "/catalog", func(doc map[string]interface{}) {
+			book := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})["Book"].(map[string]interface{})
+			book["properties"].(map[string]interface{})["isbn"].(map[string]interface{})["minLength"] = float64(13)
+			book["properties"].(map[string]interface{})["description"] = map[string]interface{}{"type": "string"}
+			book["description"] = "Documentation is not compared"
+			paths := doc["paths"].(map[string]interface{})
+			paths["/catalog/ListBooks"] = paths["/catalog/GetBook"]
+			getBook := paths["/catalog/GetBook"].(map[string]interface{})["get"].(map[string]interface{})
+			getBook["operationId"] = "findBook"
+			getBook["summary"] = "Summaries are not compared"
+		})
+		srv := newContractServer(t, path, DocValidationFail)
+		require.NoError(t, srv.RegisterService(&CatalogService{}, WithPathPrefix("/catalog")))
+
+		err := srv.CheckContract()
+		var drift *ContractDriftError
+		require.ErrorAs(t, err, &drift)
+		require.Equal(t, path, drift.Contract)
+		require.ElementsMatch(t, []string{
+			"GET /catalog/GetBook: operationId is findBook in the contract, getBook when served",
+			"GET /catalog/ListBooks: operation of the contract is not served",
+			"components.schemas.Book.isbn: minLength is 13 in the contract, not served",
+			"components.schemas.Book: property description of the contract is not served",
+		}, drift.Drift)
+
+		// Operations outside the contract are reported too
+		require.NoError(t, srv.RegisterService(&SearchService{}, WithPathPrefix("/search")))
+		require.ErrorAs(t, srv.CheckContract(), &drift)
+		require.Contains(t, drift.Drift, "GET /search/Search: operation is not in the contract")
+	})
+
+	t.Run("Startup Check", func(t *testing.T) {
+		path := writeContract(t, &CatalogService{}, "/catalog", nil)
+
+		srv := newContractServer(t, path, DocValidationFail)
+		var drift *ContractDriftError
+		require.ErrorAs(t, srv.ListenAndServe(), &drift, "a drifted server does not start in fail mode")
+		require.Len(t, drift.Drift, 2)
+
+		srv = newContractServer(t, path, DocValidationWarn)
+		require.NoError(t, srv.checkContractAtStartup(), "drift is only logged in warn mode")
+		require.Error(t, srv.CheckContract())
+
+		srv = newContractServer(t, path, DocValidationOff)
+		require.NoError(t, srv.CheckContract())
+	})
+
+	t.Run("YAML Contract In Another Version", func(t *testing.T) {
+		contract := `
+openapi: 3.1.0
+info: {title: Search, version: "1.0"}
+paths:
+  /search/Lookup:
+    get:
+      operationId: Lookup
+      parameters:
+        - {name: id, in: query, schema: {type: string}}
+      responses:
+        200:
+          description: Successful response
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Result"}
+components:
+  schemas:
+    Result:
+      type: object
+      properties:
+        summary: {type: string}
+`
+		path := filepath.Join(t.TempDir(), "openapi.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(contract), 0o644))
+		srv := newContractServer(t, path, DocValidationFail)
+		require.NoError(t, srv.RegisterService(&SearchService{}, WithPathPrefix("/search")))
+
+		var drift *ContractDriftError
+		require.ErrorAs(t, srv.CheckContract(), &drift)
+		require.Equal(t, []string{"GET /search/Search: operation is not in the contract"}, drift.Drift)
+	})
+
+	t.Run("Invalid Settings", func(t *testing.T) {
+		for _, settings := range []map[string]interface{}{
+			{"openapi_contract_check": "strict"},
+			{"openapi_contract": filepath.Join(t.TempDir(), "missing.json")},
+		} {
+			settings["port"] = 8080
+			c, err := config.New(config.WithDefault(settings))
+			require.NoError(t, err)
+			_, err = NewServer(c)
+			require.Error(t, err)
+		}
+	})
+}
@@ -0,0 +1,232 @@
+package httpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultCORSAllowedHeaders = "Accept,Accept-Language,Authorization,Content-Type,X-Request-ID"
+	defaultCORSMaxAge         = 600
+)
+
+// CORSPolicy tells browsers which cross-origin requests may call a service.
+// Without AllowedOrigins, no cross-origin request is allowed.
+type CORSPolicy struct {
+	// AllowedOrigins lists the origins allowed, such as
+	// "https://app.example.com". "*" allows any origin, and a single "*" in
+	// an origin matches any non-empty part, as in "https://*.example.com".
+	AllowedOrigins []string
+
+	AllowedMethods   []string      // Methods allowed, defaults to those registered on the route
+	AllowedHeaders   []string      // Request headers allowed, "*" for any requested
+	ExposedHeaders   []string      // Response headers readable by scripts
+	AllowCredentials bool          // Allow cookies and authorization with requests
+	MaxAge           time.Duration // How long browsers may cache a preflight response
+}
+
+// newCORSPolicy reads the cors_* config keys, returning nil when no origin is allowed
+func newCORSPolicy(c *config.Config) (*CORSPolicy, error) {
+	origins := configList(c, "cors_allowed_origins", "")
+	if len(origins) == 0 {
+		return nil, nil
+	}
+	policy := &CORSPolicy{
+		AllowedOrigins:   origins,
+		AllowedMethods:   configList(c, "cors_allowed_methods", ""),
+		AllowedHeaders:   configList(c, "cors_allowed_headers", defaultCORSAllowedHeaders),
+		ExposedHeaders:   configList(c, "cors_exposed_headers", ""),
+		AllowCredentials: getBoolConfig(c, "cors_allow_credentials", false),
+		MaxAge:           time.Duration(getIntConfig(c, "cors_max_age_seconds", defaultCORSMaxAge)) * time.Second,
+	}
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("invalid CORS config: %w", err)
+	}
+	return policy, nil
+}
+
+// validate rejects allowing any origin with credentials, which would let
+// every site read the responses to its users' credentialed requests
+func (p *CORSPolicy) validate() error {
+	if p.AllowCredentials && slices.Contains(p.AllowedOrigins, "*") {
+		return errors.New("credentials cannot be allowed for any origin")
+	}
+	return nil
+}
+
+// configList splits the comma-separated value of a config key
+func configList(c *config.Config, key, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(getStringConfig(c, key, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// WithCORS replaces the CORS policy of the server, set by the cors_* config
+// keys, for the routes of a service. A policy without AllowedOrigins
+// disables CORS for the service, and one without AllowedHeaders allows the
+// default headers of cors_allowed_headers. RegisterService fails for a policy
+// allowing credentials from any origin.
+func WithCORS(policy CORSPolicy) ServiceOption {
+	return func(s *serviceConfig) {
+		if policy.AllowedHeaders == nil {
+			policy.AllowedHeaders = strings.Split(defaultCORSAllowedHeaders, ",")
+		}
+		s.cors = &policy
+	}
+}
+
+// allowsOrigin reports whether origin matches one of the allowed origins
+func (p *CORSPolicy) allowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range p.AllowedOrigins {
+		allowed = strings.ToLower(strings.TrimSuffix(allowed, "/"))
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		if prefix, suffix, ok := strings.Cut(allowed, "*"); ok &&
+			len(origin) > len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHeaders reports whether the headers of an
+// Access-Control-Request-Headers list are all allowed
+func (p *CORSPolicy) allowsHeaders(requested []string) bool {
+	if slices.Contains(p.AllowedHeaders, "*") {
+		return true
+	}
+	for _, name := range requested {
+		if !slices.ContainsFunc(p.AllowedHeaders, func(allowed string) bool { return strings.EqualFold(allowed, name) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// allowOrigin sets the headers allowing origin to read a response, echoing
+// the origin unless any origin is allowed, which validate rules out with
+// credentials
+func (p *CORSPolicy) allowOrigin(header http.Header, origin string) {
+	if slices.Contains(p.AllowedOrigins, "*") {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// handle adds the CORS headers of an allowed origin to the response of an
+// actual request. Responses to other origins get none, so browsers hide them
+// from scripts. Unless any origin is allowed, every response varies by
+// Origin, including those to requests without one, so that shared caches
+// keep the variants apart.
+func (p *CORSPolicy) handle(c *gin.Context) {
+	header := c.Writer.Header()
+	if !slices.Contains(p.AllowedOrigins, "*") {
+		header.Add("Vary", "Origin")
+	}
+	origin := c.GetHeader("Origin")
+	if origin == "" || !p.allowsOrigin(origin) {
+		return
+	}
+	p.allowOrigin(header, origin)
+	if len(p.ExposedHeaders) > 0 {
+		header.Set("Access-Control-Expose-Headers", strings.Join(p.ExposedHeaders, ", "))
+	}
+}
+
+// preflight answers the preflight requests of a route with its CORS policy
+type preflight struct {
+	policy  *CORSPolicy
+	methods []string // Methods registered on the route
+}
+
+// handle answers a preflight request, with 204 when the origin, method and
+// headers it asks for are allowed and 403 otherwise. Other OPTIONS requests
+// go on to the route's own OPTIONS method, or are answered with the
+// methods of the route.
+func (p *preflight) handle(hasOptions bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin, method := c.GetHeader("Origin"), c.GetHeader("Access-Control-Request-Method")
+		if origin == "" || method == "" {
+			if !hasOptions {
+				c.Header("Allow", strings.Join(append(slices.Clone(p.methods), http.MethodOptions), ", "))
+				c.AbortWithStatus(http.StatusNoContent)
+			}
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		methods := p.policy.AllowedMethods
+		if len(methods) == 0 {
+			methods = p.methods
+		}
+		var requested []string
+		for _, value := range c.Request.Header.Values("Access-Control-Request-Headers") {
+			for _, name := range strings.Split(value, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					requested = append(requested, name)
+				}
+			}
+		}
+		if !p.policy.allowsOrigin(origin) || !slices.Contains(methods, strings.ToUpper(method)) || !p.policy.allowsHeaders(requested) {
+			logger.WarnContext(c.Request.Context(), "Rejected CORS preflight",
+				logger.String("origin", origin), logger.String("method", method), logger.Any("headers", requested))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "cross-origin request not allowed"})
+			return
+		}
+
+		p.policy.allowOrigin(header, origin)
+		header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		if len(requested) > 0 {
+			allowed := p.policy.AllowedHeaders
+			if slices.Contains(allowed, "*") {
+				allowed = requested
+			}
+			header.Set("Access-Control-Allow-Headers", strings.Join(allowed, ", "))
+		}
+		if p.policy.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(int(p.policy.MaxAge/time.Second)))
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// registerCORS adds the CORS handling of policy to the routes of a service:
+// the headers of actual requests, through the handlers returned for each
+// method, and a preflight for each path. routes maps each path to the
+// methods registered on it.
+func (s *Server) registerCORS(policy *CORSPolicy, routes map[string][]string) {
+	for path, methods := range routes {
+		if p, ok := s.preflights[path]; ok {
+			// Another service registered this path first, keeping its policy
+			p.methods = append(p.methods, methods...)
+			continue
+		}
+		p := &preflight{policy: policy, methods: methods}
+		s.preflights[path] = p
+		if !slices.Contains(methods, http.MethodOptions) {
+			s.engine.OPTIONS(path, p.handle(false))
+		}
+	}
+}
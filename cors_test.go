@@ -0,0 +1,239 @@
+package httpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/stretchr/testify/require"
+)
+
+// itemRef selects an item by path
+type itemRef struct {
+	ID int `path:"id"`
+}
+
+// itemService registers GET and PUT on one path and its own OPTIONS method
+type itemService struct{}
+
+func (s *itemService) Get(ref itemRef) (note, error)     { return note{Text: "get"}, nil }
+func (s *itemService) Put(ref itemRef) (note, error)     { return note{Text: "put"}, nil }
+func (s *itemService) Options(ref itemRef) (note, error) { return note{Text: "options"}, nil }
+
+func (s *itemService) RegisterMethods() []MethodInfo {
+	methods := []MethodInfo{
+		{Name: "Get", HTTPMethod: "GET", Path: "items/:id"},
+		{Name: "Put", HTTPMethod: "PUT", Path: "items/:id"},
+		{Name: "Options", HTTPMethod: "OPTIONS", Path: "catalog"},
+	}
+	for i := range methods {
+		methods[i].InputType = reflect.TypeOf(itemRef{})
+		methods[i].OutputType = reflect.TypeOf(note{})
+		methods[i].Func = reflect.ValueOf(s).MethodByName(methods[i].Name)
+	}
+	return methods
+}
+
+// newCORSServer serves an itemService at /api with the given settings, and
+// at /partner with the given service policy, if any
+func newCORSServer(t *testing.T, settings map[string]interface{}, partner *CORSPolicy) *httptest.Server {
+	settings["otel_enabled"] = false
+	settings["port"] = 8080
+	c, err := config.New(config.WithDefault(settings))
+	require.NoError(t, err)
+	srv, err := NewServer(c)
+	require.NoError(t, err)
+	require.NoError(t, srv.RegisterService(&itemService{}, WithPathPrefix("/api")))
+	if partner != nil {
+		require.NoError(t, srv.RegisterService(&itemService{}, WithPathPrefix("/partner"), WithCORS(*partner)))
+	}
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// corsRequest sends a request to url with the given headers
+func corsRequest(t *testing.T, method, url string, header map[string]string) *http.Response {
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestCORS(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	settings := func() map[string]interface{} {
+		return map[string]interface{}{
+			"cors_allowed_origins":   "https://app.example.com, https://*.example.org",
+			"cors_exposed_headers":   "X-Request-ID",
+			"cors_allow_credentials": true,
+		}
+	}
+
+	t.Run("Preflight", func(t *testing.T) {
+		ts := newCORSServer(t, settings(), nil)
+		resp := corsRequest(t, "OPTIONS", ts.URL+"/api/items/1", map[string]string{
+			"Origin":                         "https://app.example.com",
+			"Access-Control-Request-Method":  "PUT",
+			"Access-Control-Request-Headers": "content-type, authorization",
+		})
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.Equal(t, "https://app.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "true", resp.Header.Get("Access-Control-Allow-Credentials"))
+		require.Equal(t, "GET, PUT", resp.Header.Get("Access-Control-Allow-Methods"))
+		require.Equal(t, "Accept, Accept-Language, Authorization, Content-Type, X-Request-ID", resp.Header.Get("Access-Control-Allow-Headers"))
+		require.Equal(t, "600", resp.Header.Get("Access-Control-Max-Age"))
+		require.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, resp.Header.Values("Vary"))
+	})
+
+	t.Run("Rejected Preflight", func(t *testing.T) {
+		ts := newCORSServer(t, settings(), nil)
+		for name, header := range map[string]map[string]string{
+			"Origin": {"Origin": "https://evil.example.com", "Access-Control-Request-Method": "GET"},
+			"Method": {"Origin": "https://app.example.com", "Access-Control-Request-Method": "DELETE"},
+			"Header": {"Origin": "https://app.example.com", "Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "X-Secret"},
+		} {
+			resp := corsRequest(t, "OPTIONS", ts.URL+"/api/items/1", header)
+			require.Equal(t, http.StatusForbidden, resp.StatusCode, name)
+			require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"), name)
+		}
+	})
+
+	t.Run("Actual Requests", func(t *testing.T) {
+		ts := newCORSServer(t, settings(), nil)
+		resp := corsRequest(t, "GET", ts.URL+"/api/items/1", map[string]string{"Origin": "https://shop.example.org"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "https://shop.example.org", resp.Header.Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "X-Request-ID", resp.Header.Get("Access-Control-Expose-Headers"))
+		require.Equal(t, "Origin", resp.Header.Get("Vary"))
+
+		// Other origins get the response without CORS headers, which browsers hide
+		for _, origin := range []string{"https://evil.com", "https://.example.org", "https://example.org"} {
+			resp = corsRequest(t, "GET", ts.URL+"/api/items/1", map[string]string{"Origin": origin})
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"), origin)
+		}
+
+		// Same-origin responses vary too, so shared caches keep them apart
+		resp = corsRequest(t, "GET", ts.URL+"/api/items/1", nil)
+		require.Equal(t, "Origin", resp.Header.Get("Vary"))
+		require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Own Options Method", func(t *testing.T) {
+		ts := newCORSServer(t, settings(), nil)
+		resp := corsRequest(t, "OPTIONS", ts.URL+"/api/catalog", map[string]string{"Origin": "https://app.example.com"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, `{"text":"options"}`, readAll(t, resp))
+		require.Equal(t, "https://app.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+
+		resp = corsRequest(t, "OPTIONS", ts.URL+"/api/catalog", map[string]string{
+			"Origin":                        "https://app.example.com",
+			"Access-Control-Request-Method": "OPTIONS",
+		})
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp = corsRequest(t, "OPTIONS", ts.URL+"/api/items/1", nil)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.Equal(t, "GET, PUT, OPTIONS", resp.Header.Get("Allow"))
+	})
+
+	t.Run("Any Origin", func(t *testing.T) {
+		ts := newCORSServer(t, map[string]interface{}{
+			"cors_allowed_origins": "*",
+			"cors_allowed_methods": "GET",
+			"cors_allowed_headers": "*",
+			"cors_max_age_seconds": 0,
+		}, nil)
+		resp := corsRequest(t, "OPTIONS", ts.URL+"/api/items/1", map[string]string{
+			"Origin":                         "https://anywhere.test",
+			"Access-Control-Request-Method":  "GET",
+			"Access-Control-Request-Headers": "X-Custom",
+		})
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
+		require.Empty(t, resp.Header.Get("Access-Control-Allow-Credentials"))
+		require.Equal(t, "GET", resp.Header.Get("Access-Control-Allow-Methods"))
+		require.Equal(t, "X-Custom", resp.Header.Get("Access-Control-Allow-Headers"))
+		require.Empty(t, resp.Header.Get("Access-Control-Max-Age"))
+
+		resp = corsRequest(t, "OPTIONS", ts.URL+"/api/items/1", map[string]string{
+			"Origin":                        "https://anywhere.test",
+			"Access-Control-Request-Method": "PUT",
+		})
+		require.Equal(t, http.StatusForbidden, resp.StatusCode, "only the configured methods are allowed")
+
+		resp = corsRequest(t, "GET", ts.URL+"/api/items/1", map[string]string{"Origin": "https://anywhere.test"})
+		require.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
+		require.Empty(t, resp.Header.Get("Vary"), "the response is the same for every origin")
+	})
+
+	t.Run("Credentials With Any Origin", func(t *testing.T) {
+		c, err := config.New(config.WithDefault(map[string]interface{}{
+			"otel_enabled":           false,
+			"cors_allowed_origins":   "*",
+			"cors_allow_credentials": true,
+		}))
+		require.NoError(t, err)
+		_, err = NewServer(c)
+		require.EqualError(t, err, "invalid CORS config: credentials cannot be allowed for any origin")
+
+		c, err = config.New(config.WithDefault(map[string]interface{}{"otel_enabled": false}))
+		require.NoError(t, err)
+		srv, err := NewServer(c)
+		require.NoError(t, err)
+		err = srv.RegisterService(&itemService{}, WithCORS(CORSPolicy{AllowedOrigins: []string{"https://a.test", "*"}, AllowCredentials: true}))
+		require.ErrorContains(t, err, "credentials cannot be allowed for any origin")
+	})
+
+	t.Run("Service Override", func(t *testing.T) {
+		ts := newCORSServer(t, settings(), &CORSPolicy{
+			AllowedOrigins: []string{"https://partner.test"},
+			MaxAge:         time.Hour,
+		})
+		preflight := map[string]string{"Origin": "https://partner.test", "Access-Control-Request-Method": "GET"}
+		resp := corsRequest(t, "OPTIONS", ts.URL+"/partner/items/1", preflight)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		// Service policies allow the default headers
+		resp = corsRequest(t, "OPTIONS", ts.URL+"/partner/items/1", map[string]string{
+			"Origin":                         "https://partner.test",
+			"Access-Control-Request-Method":  "PUT",
+			"Access-Control-Request-Headers": "Content-Type",
+		})
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.Equal(t, "Accept, Accept-Language, Authorization, Content-Type, X-Request-ID", resp.Header.Get("Access-Control-Allow-Headers"))
+		require.Equal(t, "https://partner.test", resp.Header.Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "3600", resp.Header.Get("Access-Control-Max-Age"))
+		require.Equal(t, http.StatusForbidden, corsRequest(t, "OPTIONS", ts.URL+"/api/items/1", preflight).StatusCode)
+
+		// A policy without origins disables CORS for the service
+		ts = newCORSServer(t, settings(), &CORSPolicy{})
+		resp = corsRequest(t, "GET", ts.URL+"/partner/items/1", map[string]string{"Origin": "https://app.example.com"})
+		require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+		resp = corsRequest(t, "OPTIONS", ts.URL+"/partner/items/1", map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "GET"})
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Disabled By Default", func(t *testing.T) {
+		ts := newCORSServer(t, map[string]interface{}{}, nil)
+		resp := corsRequest(t, "OPTIONS", ts.URL+"/api/items/1", map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "GET"})
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp = corsRequest(t, "GET", ts.URL+"/api/items/1", map[string]string{"Origin": "https://app.example.com"})
+		require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+	})
+}
@@ -0,0 +1,229 @@
+package httpc
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strings"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+//go:generate go run ui/generate.go
+
+// uiFiles holds the documentation page templates and the UI assets vendored by go generate
+//
+//go:embed ui/*.html ui/vendor
+var uiFiles embed.FS
+
+var docsTemplates = template.Must(template.ParseFS(uiFiles, "ui/*.html"))
+
+// docsAssets holds the vendored UI assets served under the docs path
+var docsAssets = mustSub(uiFiles, "ui/vendor")
+
+// Vendored assets each documentation UI loads, never fetched from a CDN
+var (
+	swaggerUIAssets = []string{"swagger-ui-bundle.js", "swagger-ui.css"}
+	redocAssets     = []string{"redoc.standalone.js"}
+)
+
+// defaultDocsPath is the base path of the documentation endpoints
+const defaultDocsPath = "/api/docs"
+
+// docsAuthScheme names the security scheme documenting docs_auth_header
+const docsAuthScheme = "docsAuth"
+
+// docsConfig holds the docs_* config keys
+type docsConfig struct {
+	enabled    bool
+	path       string
+	redoc      bool
+	authHeader string
+}
+
+func newDocsConfig(c *config.Config) (docsConfig, error) {
+	cfg := docsConfig{
+		enabled:    getBoolConfig(c, "docs_enabled", true),
+		path:       getStringConfig(c, "docs_path", defaultDocsPath),
+		redoc:      getBoolConfig(c, "docs_redoc_enabled", false),
+		authHeader: getStringConfig(c, "docs_auth_header", ""),
+	}
+	if cfg.path == "" {
+		cfg.path = defaultDocsPath
+	}
+	cfg.path = strings.TrimSuffix(cfg.path, "/")
+	if !strings.HasPrefix(cfg.path, "/") {
+		return cfg, fmt.Errorf("invalid docs_path %q: must start with / and not be the root", cfg.path)
+	}
+	return cfg, nil
+}
+
+// docsPage is the data of the documentation page templates
+type docsPage struct {
+	Title     string
+	AssetBase string
+	DocsPath  string
+	SpecURL   string
+}
+
+// mustSub returns the subtree of fsys at dir
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// requireAssets returns an error naming the first of files that is not vendored
+func requireAssets(key string, files []string) error {
+	for _, file := range files {
+		if _, err := fs.Stat(docsAssets, file); err != nil {
+			return fmt.Errorf("%s requires the vendored UI asset ui/vendor/%s; run go generate", key, file)
+		}
+	}
+	return nil
+}
+
+// registerDocs serves the OpenAPI document and its UIs under the docs path,
+// unless docs are disabled. The UIs only load the embedded assets, so it
+// fails when an enabled UI was not vendored.
+func (s *Server) registerDocs(cfg docsConfig) error {
+	if !cfg.enabled {
+		logger.Info("API documentation disabled")
+		return nil
+	}
+	if err := requireAssets("docs_enabled", swaggerUIAssets); err != nil {
+		return err
+	}
+	if cfg.redoc {
+		if err := requireAssets("docs_redoc_enabled", redocAssets); err != nil {
+			return err
+		}
+	}
+
+	assets := docsAssets
+	assetBase := cfg.path + "/assets"
+	specURL := cfg.path + "/swagger.json"
+	docs := s.engine.Group(cfg.path)
+
+	s.engine.GET(cfg.path, func(c *gin.Context) {
+		c.Redirect(http.StatusFound, cfg.path+"/index.html")
+	})
+	docs.GET("/swagger.json", func(c *gin.Context) {
+		doc, err := s.docsDocument(cfg)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, doc)
+	})
+	docs.GET("/openapi.yaml", func(c *gin.Context) {
+		doc, err := s.docsDocument(cfg)
+		if err == nil {
+			var data []byte
+			if data, err = yaml.Marshal(doc); err == nil {
+				c.Data(http.StatusOK, "application/yaml", data)
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	})
+	docs.GET("/assets/*filepath", func(c *gin.Context) {
+		c.FileFromFS(c.Param("filepath"), http.FS(assets))
+	})
+
+	swaggerPage := docsPage{
+		AssetBase: assetBase,
+		DocsPath:  cfg.path,
+		SpecURL:   specURL,
+	}
+	docs.GET("/index.html", s.renderDocsPage("swagger.html", swaggerPage))
+	docs.GET("/swagger-initializer.js", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/javascript; charset=utf-8", swaggerInitializer(specURL))
+	})
+
+	if cfg.redoc {
+		redocPage := docsPage{
+			AssetBase: assetBase,
+			DocsPath:  cfg.path,
+			SpecURL:   specURL,
+		}
+		docs.GET("/redoc.html", s.renderDocsPage("redoc.html", redocPage))
+	}
+	logger.Info("Registered API documentation", logger.String("path", cfg.path))
+	return nil
+}
+
+// renderDocsPage serves the named page template, titled after the API
+func (s *Server) renderDocsPage(name string, page docsPage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page := page
+		page.Title = "httpc API"
+		if info, ok := s.swagger["info"].(map[string]interface{}); ok {
+			if title, ok := info["title"].(string); ok && title != "" {
+				page.Title = title
+			}
+		}
+		var buf bytes.Buffer
+		if err := docsTemplates.ExecuteTemplate(&buf, name, page); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if s.security.headers {
+			c.Header("Content-Security-Policy", s.security.docsContentSecurityPolicy())
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+	}
+}
+
+// swaggerInitializer returns the script starting Swagger UI on specURL. It is
+// served as a file rather than inlined so pages need no inline scripts.
+func swaggerInitializer(specURL string) []byte {
+	options, _ := json.Marshal(map[string]interface{}{
+		"url":                  specURL,
+		"dom_id":               "#swagger-ui",
+		"deepLinking":          true,
+		"persistAuthorization": true,
+	})
+	return []byte(fmt.Sprintf("window.onload = function () {\n    window.ui = SwaggerUIBundle(%s);\n};\n", options))
+}
+
+// docsDocument returns the OpenAPI document served by the docs endpoints,
+// which alone document docs_auth_header: the server's own document, read by
+// the contract check and client generation, is left as registered
+func (s *Server) docsDocument(cfg docsConfig) (map[string]interface{}, error) {
+	doc, err := s.OpenAPIDocument()
+	if err != nil || cfg.authHeader == "" {
+		return doc, err
+	}
+	addDocsAuth(doc, cfg.authHeader)
+	return doc, nil
+}
+
+// addDocsAuth documents an optional API key sent in header, so the UI offers
+// to authorize "try it out" calls with it
+func addDocsAuth(doc map[string]interface{}, header string) {
+	components, ok := doc["components"].(map[string]interface{})
+	if !ok {
+		components = map[string]interface{}{}
+		doc["components"] = components
+	}
+	schemes, ok := components["securitySchemes"].(map[string]interface{})
+	if !ok {
+		schemes = map[string]interface{}{}
+		components["securitySchemes"] = schemes
+	}
+	schemes[docsAuthScheme] = map[string]interface{}{"type": "apiKey", "in": "header", "name": header}
+	doc["security"] = []interface{}{
+		map[string]interface{}{docsAuthScheme: []interface{}{}},
+		map[string]interface{}{},
+	}
+}
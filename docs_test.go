@@ -0,0 +1,237 @@
+package httpc
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/stretchr/testify/require"
+)
+
+// newDocsTestServer serves TestService on a server with the given docs settings
+func newDocsTestServer(t *testing.T, settings map[string]interface{}) *httptest.Server {
+	cfgMap := map[string]interface{}{"otel_enabled": false, "port": 8080}
+	for key, value := range settings {
+		cfgMap[key] = value
+	}
+	c, err := config.New(config.WithDefault(cfgMap))
+	require.NoError(t, err)
+	srv, err := NewServer(c)
+	require.NoError(t, err)
+	require.NoError(t, srv.RegisterService(&TestService{}, WithPathPrefix("/v1")))
+	return httptest.NewServer(srv.engine)
+}
+
+// withReDocAssets serves the vendored UI assets, plus a placeholder
+// redoc.standalone.js when ReDoc is not vendored, until the test ends
+func withReDocAssets(t *testing.T) {
+	if _, err := fs.Stat(docsAssets, "redoc.standalone.js"); err == nil {
+		return
+	}
+	assets := fstest.MapFS{"redoc.standalone.js": {Data: []byte("/* ReDoc */")}}
+	require.NoError(t, fs.WalkDir(docsAssets, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(docsAssets, path)
+		assets[path] = &fstest.MapFile{Data: data}
+		return err
+	}))
+	previous := docsAssets
+	docsAssets = assets
+	t.Cleanup(func() { docsAssets = previous })
+}
+
+// requireNoRemoteAssets fails when a docs page loads anything from another origin
+func requireNoRemoteAssets(t *testing.T, page string) {
+	for _, attr := range []string{`src="`, `href="`} {
+		for _, rest := range strings.Split(page, attr)[1:] {
+			require.False(t, strings.HasPrefix(rest, "https://") || strings.HasPrefix(rest, "http://") || strings.HasPrefix(rest, "//"),
+				"asset %s%s is not embedded", attr, strings.SplitN(rest, `"`, 2)[0])
+		}
+	}
+	require.NotContains(t, page, "https://")
+}
+
+// getBody returns the status and body of a GET request, without following redirects
+func getBody(t *testing.T, url string) (*http.Response, string) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestDocs(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	assets := docsAssets
+
+	t.Run("Embedded UI", func(t *testing.T) {
+		ts := newDocsTestServer(t, nil)
+		defer ts.Close()
+
+		resp, body := getBody(t, ts.URL+"/api/docs/index.html")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, body, "<title>httpc API - Swagger UI</title>")
+		require.Contains(t, body, `<script src="/api/docs/swagger-initializer.js"></script>`)
+		require.Contains(t, body, `"/api/docs/assets/swagger-ui-bundle.js"`)
+		requireNoRemoteAssets(t, body)
+		require.NotContains(t, body, "window.onload", "pages have no inline scripts")
+
+		resp, body = getBody(t, ts.URL+"/api/docs/swagger-initializer.js")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, body, `"url":"/api/docs/swagger.json"`)
+
+		resp, body = getBody(t, ts.URL+"/api/docs/assets/VERSIONS")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, body, "swagger-ui-dist")
+
+		// Swagger UI is served from the embedded copy, without the CDN
+		require.Contains(t, body, "swagger-ui-dist 5.18.2")
+		_, page := getBody(t, ts.URL+"/api/docs/index.html")
+		for file, contentType := range map[string]string{"swagger-ui-bundle.js": "javascript", "swagger-ui.css": "text/css"} {
+			embedded, err := fs.ReadFile(assets, file)
+			require.NoError(t, err, "%s is vendored", file)
+			resp, body := getBody(t, ts.URL+"/api/docs/assets/"+file)
+			require.Equal(t, http.StatusOK, resp.StatusCode, file)
+			require.Contains(t, resp.Header.Get("Content-Type"), contentType, file)
+			require.Equal(t, string(embedded), body, file)
+			require.Contains(t, page, `"/api/docs/assets/`+file+`"`, "the page loads %s from the embed", file)
+		}
+
+		resp, _ = getBody(t, ts.URL+"/api/docs")
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		require.Equal(t, "/api/docs/index.html", resp.Header.Get("Location"))
+
+		resp, _ = getBody(t, ts.URL+"/api/docs/redoc.html")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode, "ReDoc is opt-in")
+	})
+
+	t.Run("Custom Path, ReDoc and Auth Header", func(t *testing.T) {
+		withReDocAssets(t)
+		ts := newDocsTestServer(t, map[string]interface{}{
+			"docs_path":          "/internal/docs/",
+			"docs_redoc_enabled": true,
+			"docs_auth_header":   "X-API-Key",
+		})
+		defer ts.Close()
+
+		resp, _ := getBody(t, ts.URL+"/api/docs/swagger.json")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp, body := getBody(t, ts.URL+"/internal/docs/swagger.json")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(body), &doc))
+		require.NoError(t, validateOpenAPI(doc))
+		components := doc["components"].(map[string]interface{})
+		require.Contains(t, components["schemas"], "User", "registrations keep the security schemes")
+		require.Equal(t, map[string]interface{}{
+			"docsAuth": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+		}, components["securitySchemes"])
+		require.Equal(t, []interface{}{map[string]interface{}{"docsAuth": []interface{}{}}, map[string]interface{}{}}, doc["security"])
+
+		_, body = getBody(t, ts.URL+"/internal/docs/swagger-initializer.js")
+		require.Contains(t, body, `"url":"/internal/docs/swagger.json"`)
+		require.Contains(t, body, `"persistAuthorization":true`)
+
+		resp, body = getBody(t, ts.URL+"/internal/docs/redoc.html")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, body, `<redoc spec-url="/internal/docs/swagger.json"></redoc>`)
+		require.Contains(t, body, `<script src="/internal/docs/assets/redoc.standalone.js"></script>`)
+		requireNoRemoteAssets(t, body)
+		redoc, err := fs.ReadFile(docsAssets, "redoc.standalone.js")
+		require.NoError(t, err)
+		resp, body = getBody(t, ts.URL+"/internal/docs/assets/redoc.standalone.js")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, string(redoc), body)
+
+		resp, body = getBody(t, ts.URL+"/internal/docs/openapi.yaml")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, body, "docsAuth")
+	})
+
+	t.Run("Unvendored Assets", func(t *testing.T) {
+		previous := docsAssets
+		defer func() { docsAssets = previous }()
+		docsAssets = fstest.MapFS{
+			"swagger-ui-bundle.js": {Data: []byte("/* Swagger UI */")},
+			"swagger-ui.css":       {Data: []byte("/* Swagger UI */")},
+		}
+
+		for settings, message := range map[string]string{
+			"docs_redoc_enabled": "docs_redoc_enabled requires the vendored UI asset ui/vendor/redoc.standalone.js",
+			"":                   "",
+		} {
+			cfgMap := map[string]interface{}{"otel_enabled": false, "port": 8080}
+			if settings != "" {
+				cfgMap[settings] = true
+			}
+			c, err := config.New(config.WithDefault(cfgMap))
+			require.NoError(t, err)
+			_, err = NewServer(c)
+			if message == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, message)
+			}
+		}
+
+		docsAssets = fstest.MapFS{}
+		c, err := config.New(config.WithDefault(map[string]interface{}{"otel_enabled": false, "port": 8080}))
+		require.NoError(t, err)
+		_, err = NewServer(c)
+		require.ErrorContains(t, err, "docs_enabled requires the vendored UI asset ui/vendor/swagger-ui-bundle.js")
+		c, err = config.New(config.WithDefault(map[string]interface{}{"otel_enabled": false, "port": 8080, "docs_enabled": false}))
+		require.NoError(t, err)
+		_, err = NewServer(c)
+		require.NoError(t, err, "disabled docs need no assets")
+	})
+
+	t.Run("Auth Header Only In Served Document", func(t *testing.T) {
+		c, err := config.New(config.WithDefault(map[string]interface{}{"otel_enabled": false, "port": 8080, "docs_auth_header": "X-API-Key"}))
+		require.NoError(t, err)
+		srv, err := NewServer(c)
+		require.NoError(t, err)
+		require.NoError(t, srv.RegisterService(&TestService{}, WithPathPrefix("/v1")))
+
+		doc, err := srv.OpenAPIDocument()
+		require.NoError(t, err)
+		require.NotContains(t, doc, "security")
+		require.NotContains(t, doc["components"], "securitySchemes")
+		require.NotContains(t, srv.swagger, "security", "the registered document is left unchanged")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		ts := newDocsTestServer(t, map[string]interface{}{"docs_enabled": false})
+		defer ts.Close()
+
+		for _, path := range []string{"/api/docs/swagger.json", "/api/docs/openapi.yaml", "/api/docs/index.html"} {
+			resp, _ := getBody(t, ts.URL+path)
+			require.Equal(t, http.StatusNotFound, resp.StatusCode, path)
+		}
+		resp, body := getBody(t, ts.URL+"/v1/Hello?name=Ann")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, body, "Hello, Ann!")
+	})
+
+	t.Run("Invalid Path", func(t *testing.T) {
+		c, err := config.New(config.WithDefault(map[string]interface{}{"port": 8080, "docs_path": "docs"}))
+		require.NoError(t, err)
+		_, err = NewServer(c)
+		require.EqualError(t, err, `invalid docs_path "docs": must start with / and not be the root`)
+	})
+}
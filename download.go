@@ -0,0 +1,154 @@
+package httpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/gin-gonic/gin"
+)
+
+// MediaTypeOctetStream is the media type of binary content of unknown type
+const MediaTypeOctetStream = "application/octet-stream"
+
+var (
+	downloadType = reflect.TypeOf(Download{})
+	readerType   = reflect.TypeOf((*io.Reader)(nil)).Elem()
+)
+
+// Download is binary content written as a response body rather than encoded
+// by a codec. Methods return a Download, or any io.Reader, to serve a file;
+// HTTPClient.Download returns one to read a response as it arrives. Content
+// that is an io.Closer is closed once written.
+type Download struct {
+	Content io.Reader
+
+	// ContentType defaults to the type of the Filename extension or sniffed
+	// from Content that can seek, else to application/octet-stream
+	ContentType string
+
+	Filename string    // Name offered to save the content, in Content-Disposition
+	Inline   bool      // Display the content rather than save it as an attachment
+	Size     int64     // Length of Content, -1 or 0 if unknown
+	ModTime  time.Time // Sent as Last-Modified when set
+
+	// ContentRange is the Content-Range of a partial response read by
+	// HTTPClient.Download, empty when the whole content was sent
+	ContentRange string
+}
+
+// isDownload reports whether a method returning t writes binary content
+func isDownload(t reflect.Type) bool {
+	return t == downloadType || t == reflect.PointerTo(downloadType) || t.Implements(readerType)
+}
+
+// writeDownload writes the Download or io.Reader returned by a method. A
+// Content that can seek is served with http.ServeContent, which answers Range
+// and conditional requests; other content is copied as it is read.
+func writeDownload(c *gin.Context, result reflect.Value) {
+	var d Download
+	switch v := result.Interface().(type) {
+	case Download:
+		d = v
+	case *Download:
+		if v != nil {
+			d = *v
+		}
+	case io.Reader:
+		d.Content = v
+	}
+	if d.Content == nil {
+		d.Content = strings.NewReader("")
+	}
+	if closer, ok := d.Content.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	header := c.Writer.Header()
+	if d.ContentType != "" {
+		header.Set("Content-Type", d.ContentType)
+	}
+	if d.Filename != "" || d.Inline {
+		disposition := "attachment"
+		if d.Inline {
+			disposition = "inline"
+		}
+		params := map[string]string{}
+		if d.Filename != "" {
+			params["filename"] = d.Filename
+		}
+		header.Set("Content-Disposition", mime.FormatMediaType(disposition, params))
+	}
+
+	if content, ok := d.Content.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, d.Filename, d.ModTime, content)
+		return
+	}
+	if d.ContentType == "" {
+		header.Set("Content-Type", MediaTypeOctetStream)
+	}
+	if d.Size > 0 {
+		header.Set("Content-Length", strconv.FormatInt(d.Size, 10))
+	}
+	if !d.ModTime.IsZero() {
+		header.Set("Last-Modified", d.ModTime.UTC().Format(http.TimeFormat))
+	}
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, d.Content); err != nil {
+		logger.WarnContext(c.Request.Context(), "Download interrupted", logger.ErrField(err))
+	}
+}
+
+// WithRange asks for the bytes from start to end inclusive of the content,
+// or from start to its end if end is negative
+func WithRange(start, end int64) CallOption {
+	value := fmt.Sprintf("bytes=%d-", start)
+	if end >= 0 {
+		value += strconv.FormatInt(end, 10)
+	}
+	return WithHeader("Range", value)
+}
+
+// Download sends a GET request to url and returns the response body as it
+// arrives, with its metadata. The call timeout bounds the wait for the
+// response headers only; close the Content of the result once read.
+func (h *HTTPClient) Download(ctx context.Context, url string, opts ...CallOption) (*Download, error) {
+	co := h.newCallOptions(opts)
+	co.stream = true
+	if len(co.accept) == 0 {
+		co.accept = []string{"*/*"}
+	}
+	resp, err := h.send(ctx, http.MethodGet, url, nil, co)
+	if err != nil {
+		return nil, err
+	}
+	if !co.isExpected(resp.StatusCode) {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, co.errorBodyLimit()))
+		return nil, newResponseError(resp.StatusCode, body)
+	}
+
+	d := &Download{
+		Content:     resp.Body,
+		ContentType: resp.Header.Get("Content-Type"),
+		Size:        resp.ContentLength,
+	}
+	if disposition, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		d.Inline = disposition == "inline"
+		d.Filename = params["filename"]
+	}
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		d.ModTime = modTime
+	}
+	if resp.StatusCode == http.StatusPartialContent {
+		d.ContentRange = resp.Header.Get("Content-Range")
+	}
+	return d, nil
+}
@@ -0,0 +1,104 @@
+package httpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloads(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	t.Run("Serve Seekable Content", func(t *testing.T) {
+		ts, _ := newFileServer(t, map[string]interface{}{})
+		resp, err := http.Get(ts.URL + "/files/Report?name=sales")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+		require.Equal(t, `attachment; filename=sales.txt`, resp.Header.Get("Content-Disposition"))
+		require.Equal(t, "10", resp.Header.Get("Content-Length"))
+		require.Equal(t, "bytes", resp.Header.Get("Accept-Ranges"))
+		require.Equal(t, "Fri, 02 Jan 2026 03:04:05 GMT", resp.Header.Get("Last-Modified"))
+		require.Equal(t, "0123456789", readAll(t, resp))
+
+		req, err := http.NewRequest("GET", ts.URL+"/files/Report?name=sales", nil)
+		require.NoError(t, err)
+		req.Header.Set("Range", "bytes=2-4")
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+		require.Equal(t, "bytes 2-4/10", resp.Header.Get("Content-Range"))
+		require.Equal(t, "234", readAll(t, resp))
+	})
+
+	t.Run("Stream Reader", func(t *testing.T) {
+		ts, _ := newFileServer(t, map[string]interface{}{})
+		req, err := http.NewRequest("GET", ts.URL+"/files/Export?name=ada", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", MediaTypeXML) // Binary content is not negotiated
+		req.Header.Set("Range", "bytes=2-4")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, "content that cannot seek is sent whole")
+		require.Equal(t, MediaTypeOctetStream, resp.Header.Get("Content-Type"))
+		require.Equal(t, "id,name\n1,ada\n", readAll(t, resp))
+	})
+
+	t.Run("Client Download", func(t *testing.T) {
+		ts, _ := newFileServer(t, map[string]interface{}{})
+		client := newStreamClient(t)
+
+		d, err := client.Download(context.Background(), ts.URL+"/files/Report?name=sales")
+		require.NoError(t, err)
+		data, err := io.ReadAll(d.Content)
+		require.NoError(t, err)
+		require.NoError(t, d.Content.(io.Closer).Close())
+		require.Equal(t, "0123456789", string(data))
+		require.Equal(t, "sales.txt", d.Filename)
+		require.False(t, d.Inline)
+		require.Equal(t, int64(10), d.Size)
+		require.Equal(t, 2026, d.ModTime.Year())
+		require.Empty(t, d.ContentRange)
+
+		d, err = client.Download(context.Background(), ts.URL+"/files/Report?name=sales", WithRange(7, -1))
+		require.NoError(t, err)
+		data, err = io.ReadAll(d.Content)
+		require.NoError(t, err)
+		d.Content.(io.Closer).Close()
+		require.Equal(t, "789", string(data))
+		require.Equal(t, "bytes 7-9/10", d.ContentRange)
+
+		_, err = client.Download(context.Background(), ts.URL+"/files/Missing")
+		var respErr *ResponseError
+		require.ErrorAs(t, err, &respErr)
+		require.Equal(t, http.StatusNotFound, respErr.StatusCode)
+	})
+
+	t.Run("Documented Download", func(t *testing.T) {
+		ts, _ := newFileServer(t, map[string]interface{}{})
+		doc, _ := fetchDocument(t, ts)
+		paths := doc["paths"].(map[string]interface{})
+		for _, path := range []string{"/files/Report", "/files/Export"} {
+			responses := paths[path].(map[string]interface{})["get"].(map[string]interface{})["responses"].(map[string]interface{})
+			for _, status := range []string{"200", "206"} {
+				content := responses[status].(map[string]interface{})["content"].(map[string]interface{})
+				require.Equal(t, map[string]interface{}{
+					MediaTypeOctetStream: map[string]interface{}{
+						"schema": map[string]interface{}{"type": "string", "format": "binary"},
+					},
+				}, content, path)
+			}
+		}
+	})
+}
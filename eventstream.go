@@ -0,0 +1,300 @@
+package httpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+)
+
+// StreamEvent is an event read from a streamed response. NDJSON lines are
+// events with only Data.
+type StreamEvent struct {
+	ID   string
+	Name string
+	Data []byte
+}
+
+// Decode unmarshals the JSON data of the event into v
+func (e StreamEvent) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// EventStream reads the events of a Server-Sent Events or NDJSON response.
+// A Server-Sent Events stream cut before its end event is reopened with the
+// Last-Event-ID header, so the server can resume after the last event read.
+type EventStream struct {
+	h      *HTTPClient
+	ctx    context.Context
+	method string
+	url    string
+	input  interface{}
+	co     *callOptions
+
+	body     io.ReadCloser
+	reader   *bufio.Reader
+	ndjson   bool
+	event    StreamEvent
+	lastID   string
+	retry    time.Duration // Reconnection delay, set by the server's retry field
+	failures int           // Reconnections in a row that yielded no event
+	cause    error         // Why the connection being replaced was lost
+	err      error
+	done     bool
+}
+
+// Stream sends input like Call and returns the response as a stream of
+// events, read with Next. The request accepts Server-Sent Events unless
+// WithAccept asks for NDJSON. The call timeout bounds the wait for the
+// response headers only; ctx cancels the stream.
+func (h *HTTPClient) Stream(ctx context.Context, method, url string, input interface{}, opts ...CallOption) (*EventStream, error) {
+	co := h.newCallOptions(opts)
+	co.stream = true
+	if len(co.accept) == 0 {
+		co.accept = []string{MediaTypeEventStream}
+	}
+	method = strings.ToUpper(method)
+	if !isValidHTTPMethod(method) {
+		return nil, fmt.Errorf("invalid HTTP method: %s", method)
+	}
+	if err := h.checkContract(ContractRequest, method, input); err != nil {
+		return nil, err
+	}
+
+	s := &EventStream{h: h, ctx: ctx, method: method, url: url, input: input, co: co}
+	s.retry = time.Duration(h.config.BackoffBaseMs) * time.Millisecond
+	if err := s.connect(); errors.Is(err, errStreamEnd) {
+		s.done = true
+	} else if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// connect opens the stream, resuming after the last event read
+func (s *EventStream) connect() error {
+	req, err := s.h.newRequest(s.ctx, s.method, s.url, s.input, s.co)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Cache-Control", "no-cache")
+	if s.lastID != "" {
+		req.Header.Set("Last-Event-ID", s.lastID)
+	}
+	req = req.WithContext(withCallInfo(s.ctx, CallInfo{Scope: ScopeCall, MaxAttempts: s.co.maxRetries + 1}))
+	retry := func(req *http.Request) (*http.Response, error) {
+		return s.h.retry(req, s.co)
+	}
+	resp, err := chainInterceptors(retry, s.h.callInterceptors)(req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		resp.Body.Close()
+		return errStreamEnd
+	}
+	if !s.co.isExpected(resp.StatusCode) {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, s.co.errorBodyLimit()))
+		return newResponseError(resp.StatusCode, body)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case MediaTypeEventStream:
+		s.ndjson = false
+	case MediaTypeNDJSON:
+		s.ndjson = true
+	default:
+		resp.Body.Close()
+		return fmt.Errorf("unexpected content type of stream: %q", resp.Header.Get("Content-Type"))
+	}
+	s.body = resp.Body
+	s.reader = bufio.NewReader(resp.Body)
+	return nil
+}
+
+// Next reads the next event, reconnecting if a Server-Sent Events stream was
+// cut. It returns false at the end of the stream or on error; see Err.
+func (s *EventStream) Next() bool {
+	for !s.done {
+		if s.reader == nil {
+			if err := s.reconnect(); err != nil {
+				if errors.Is(err, errStreamEnd) {
+					err = nil
+				}
+				s.finish(err)
+				return false
+			}
+		}
+		event, err := s.read()
+		switch {
+		case err == nil:
+			s.failures = 0
+			s.event = event
+			return true
+		case errors.Is(err, errStreamEnd):
+			s.finish(nil)
+		case s.ctx.Err() != nil:
+			s.finish(s.ctx.Err())
+		case s.ndjson:
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+			s.finish(err)
+		default:
+			// The connection was cut: drop it and resume on the next iteration
+			logger.WarnContext(s.ctx, "Event stream interrupted", logger.ErrField(err))
+			s.body.Close()
+			s.reader = nil
+			s.cause = err
+		}
+	}
+	return false
+}
+
+// reconnect waits for the retry delay, then reopens the stream. It gives up
+// once max retries reconnections in a row produced no event.
+func (s *EventStream) reconnect() error {
+	err := s.cause
+	for s.failures < s.co.maxRetries {
+		s.failures++
+		select {
+		case <-time.After(s.retry):
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+		if err = s.connect(); err == nil || errors.Is(err, errStreamEnd) {
+			return err
+		}
+		logger.WarnContext(s.ctx, "Failed to reconnect event stream", logger.ErrField(err))
+	}
+	return fmt.Errorf("event stream lost after %d reconnections: %w", s.failures, err)
+}
+
+// errStreamEnd reports the end event of a Server-Sent Events stream, or a 204
+// response telling the client not to reconnect
+var errStreamEnd = errors.New("end of stream")
+
+// read returns the next event of the stream
+func (s *EventStream) read() (StreamEvent, error) {
+	if s.ndjson {
+		for {
+			line, err := s.reader.ReadBytes('\n')
+			line = []byte(strings.TrimSpace(string(line)))
+			if len(line) > 0 {
+				return StreamEvent{Data: line}, nil // A last line without newline is still an event
+			}
+			if err != nil {
+				return StreamEvent{}, err
+			}
+		}
+	}
+
+	var event StreamEvent
+	var data []string
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return StreamEvent{}, err // An event is only complete at its blank line
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if data == nil {
+				event = StreamEvent{} // Events without data are not dispatched
+				continue
+			}
+			event.Data = []byte(strings.Join(data, "\n"))
+			event.ID = s.lastID
+			switch event.Name {
+			case streamEndEvent:
+				return StreamEvent{}, errStreamEnd
+			case streamErrorEvent:
+				// Ends the stream with the server's error rather than reconnecting
+				s.finish(newResponseError(http.StatusOK, event.Data))
+				return StreamEvent{}, errStreamEnd
+			}
+			return event, nil
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // Comment, such as a heartbeat
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			data = append(data, value)
+		case "event":
+			event.Name = value
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// finish ends the stream with err, keeping an earlier error
+func (s *EventStream) finish(err error) {
+	if !s.done {
+		s.done = true
+		s.err = err
+	}
+	if s.body != nil {
+		s.body.Close()
+	}
+}
+
+// Event returns the event read by the last call to Next
+func (s *EventStream) Event() StreamEvent {
+	return s.event
+}
+
+// Err returns the error that ended the stream, or nil if it completed
+func (s *EventStream) Err() error {
+	return s.err
+}
+
+// LastEventID returns the id of the last Server-Sent Event read
+func (s *EventStream) LastEventID() string {
+	return s.lastID
+}
+
+// Close releases the stream's connection
+func (s *EventStream) Close() error {
+	s.finish(nil)
+	return nil
+}
+
+// Events iterates over the events of stream decoded as T, closing it when
+// the loop ends. An error ends the iteration.
+func Events[T any](stream *EventStream) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		defer stream.Close()
+		for stream.Next() {
+			var v T
+			err := stream.Event().Decode(&v)
+			if !yield(v, err) || err != nil {
+				return
+			}
+		}
+		if err := stream.Err(); err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/url"
 
 	config "github.com/T-Prohmpossadhorn/go-core-config"
 	httpc "github.com/T-Prohmpossadhorn/go-core-httpc"
@@ -53,7 +54,8 @@ func main() {
 
 	// Make GET request
 	var greetResult string
-	err = client.Call("GET", "http://localhost:8080/v1/Greet?name=World", nil, &greetResult)
+	err = client.Call("GET", "http://localhost:8080/v1/Greet", nil, &greetResult,
+		httpc.WithQuery(url.Values{"name": {"World"}}))
 	if err != nil {
 		fmt.Printf("GET request failed: %v\n", err)
 		return
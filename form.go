@@ -0,0 +1,322 @@
+package httpc
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// fieldKey returns the key for a struct field from the first of tags that
+// names it, falling back to the Go field name. skip is true for "-".
+func fieldKey(field reflect.StructField, tags []string) (key string, skip bool) {
+	for _, tag := range tags {
+		value, ok := field.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+		name := strings.Split(value, ",")[0]
+		if name == "-" {
+			return "", true
+		}
+		if name != "" {
+			return name, false
+		}
+	}
+	return field.Name, false
+}
+
+// isScalarType reports whether t is bound from a single string value
+func isScalarType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// decodeValues sets the fields of the struct pointed to by ptr from values.
+// Slices take repeated keys, pointers are only allocated when a key is
+// present and nested structs use dotted keys such as "address.city". Fields
+// bound from another request part, such as `header`, and File fields are
+// skipped.
+func decodeValues(values url.Values, ptr interface{}, tags ...string) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("form decoding requires a pointer to a struct, got %T", ptr)
+	}
+	return decodeStruct(values, v.Elem(), "", tags)
+}
+
+func decodeStruct(values url.Values, v reflect.Value, prefix string, tags []string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key, skip := fieldKey(field, tags)
+		if skip || boundElsewhere(field, tags) || isFileType(field.Type) {
+			continue
+		}
+
+		fv := v.Field(i)
+		ft := field.Type
+		if field.Anonymous && ft.Kind() == reflect.Struct && !isScalarType(ft) {
+			if err := decodeStruct(values, fv, prefix, tags); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key = prefix + key
+		if !isScalarType(ft) && (ft.Kind() == reflect.Struct || (ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct)) {
+			if !hasPrefix(values, key+".") {
+				continue
+			}
+			if ft.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					fv.Set(reflect.New(ft.Elem()))
+				}
+				fv = fv.Elem()
+			}
+			if err := decodeStruct(values, fv, key+".", tags); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, ok := values[key]
+		if !ok || len(raw) == 0 {
+			continue
+		}
+		if err := setValues(fv, raw, field); err != nil {
+			return fmt.Errorf("invalid value for field %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// hasPrefix reports whether any key in values starts with prefix
+func hasPrefix(values url.Values, prefix string) bool {
+	for key := range values {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// setValues assigns raw to v, which may be a scalar, pointer or slice
+func setValues(v reflect.Value, raw []string, field reflect.StructField) error {
+	switch {
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8:
+		slice := reflect.MakeSlice(v.Type(), len(raw), len(raw))
+		for i, s := range raw {
+			if err := setScalar(slice.Index(i), s, field); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	default:
+		return setScalar(v, raw[len(raw)-1], field)
+	}
+}
+
+// setScalar parses s into v, honouring the time_format tag for time values
+func setScalar(v reflect.Value, s string, field reflect.StructField) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setScalar(v.Elem(), s, field)
+	}
+
+	if v.Type() == timeType {
+		layout := field.Tag.Get("time_format")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		tm, err := time.Parse(layout, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(tm))
+		return nil
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// encodeValues flattens a struct into url.Values using the same key rules as decodeValues
+func encodeValues(value interface{}, tags ...string) (url.Values, error) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return url.Values{}, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("form encoding requires a struct, got %T", value)
+	}
+	values := url.Values{}
+	if err := encodeStruct(values, v, "", tags); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func encodeStruct(values url.Values, v reflect.Value, prefix string, tags []string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key, skip := fieldKey(field, tags)
+		if skip || boundElsewhere(field, tags) || isFileType(field.Type) {
+			continue
+		}
+
+		fv := v.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && !isScalarType(field.Type) {
+			if err := encodeStruct(values, fv, prefix, tags); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key = prefix + key
+		for fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Ptr {
+			continue
+		}
+		if fv.Kind() == reflect.Struct && !isScalarType(fv.Type()) {
+			if err := encodeStruct(values, fv, key+".", tags); err != nil {
+				return err
+			}
+			continue
+		}
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+			for j := 0; j < fv.Len(); j++ {
+				s, err := formatScalar(fv.Index(j), field)
+				if err != nil {
+					return fmt.Errorf("invalid value for field %s: %w", key, err)
+				}
+				values.Add(key, s)
+			}
+			continue
+		}
+		s, err := formatScalar(fv, field)
+		if err != nil {
+			return fmt.Errorf("invalid value for field %s: %w", key, err)
+		}
+		values.Set(key, s)
+	}
+	return nil
+}
+
+// formatScalar renders v as a single form value
+func formatScalar(v reflect.Value, field reflect.StructField) (string, error) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		layout := field.Tag.Get("time_format")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return v.Interface().(time.Time).Format(layout), nil
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String(), nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
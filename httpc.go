@@ -3,7 +3,6 @@ package httpc
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,40 +13,69 @@ import (
 	config "github.com/T-Prohmpossadhorn/go-core-config"
 	logger "github.com/T-Prohmpossadhorn/go-core-logger"
 	"github.com/gin-gonic/gin"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
-	"github.com/google/uuid"
 )
 
-const swaggerUIHTML = `<!DOCTYPE html>
-<html>
-<head>
-    <title>Swagger UI</title>
-    <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist/swagger-ui.css" />
-</head>
-<body>
-    <div id="swagger-ui"></div>
-    <script src="https://unpkg.com/swagger-ui-dist/swagger-ui-bundle.js"></script>
-    <script>
-    window.onload = function() {
-        SwaggerUIBundle({url: '/api/docs/swagger.json', dom_id: '#swagger-ui'});
-    }
-    </script>
-</body>
-</html>`
-
 type ServerConfig struct {
-	OtelEnabled bool `json:"otel_enabled" default:"false"`
-	Port        int  `json:"port" default:"8080" required:"true" validate:"gt=0,lte=65535"`
+	OtelEnabled       bool   `json:"otel_enabled" default:"false"`
+	Port              int    `json:"port" default:"8080" required:"true" validate:"gt=0,lte=65535"`
+	OpenAPIVersion    string `json:"openapi_version" default:"3.0.3" validate:"oneof=3.0.3 3.1.0"`
+	OpenAPIValidation string `json:"openapi_validation" default:"warn" validate:"oneof=off warn fail"`
+	DocsEnabled       bool   `json:"docs_enabled" default:"true"`
+	DocsPath          string `json:"docs_path" default:"/api/docs"`
+	DocsRedocEnabled  bool   `json:"docs_redoc_enabled" default:"false"`
+	DocsAuthHeader    string `json:"docs_auth_header"`
+	Contract          string `json:"openapi_contract"`
+	ContractCheck     string `json:"openapi_contract_check" default:"warn" validate:"oneof=off warn fail"`
+	StreamHeartbeatMs int    `json:"stream_heartbeat_ms" default:"15000" validate:"gte=0"`
+
+	WebSocketReadLimit int    `json:"websocket_read_limit" default:"1048576" validate:"gte=0"`
+	WebSocketPingMs    int    `json:"websocket_ping_interval_ms" default:"30000" validate:"gte=0"`
+	WebSocketOrigins   string `json:"websocket_allowed_origins"`
+
+	UploadMaxFileBytes    int `json:"upload_max_file_bytes" default:"10485760" validate:"gt=0"`
+	UploadMaxRequestBytes int `json:"upload_max_request_bytes" default:"33554432" validate:"gt=0"`
+
+	CompressionEnabled    bool `json:"compression_enabled" default:"false"`
+	CompressionMinBytes   int  `json:"compression_min_bytes" default:"1024" validate:"gte=0"`
+	DecompressionMaxBytes int  `json:"decompression_max_bytes" default:"33554432" validate:"gt=0"`
+
+	CORSAllowedOrigins   string `json:"cors_allowed_origins"`
+	CORSAllowedMethods   string `json:"cors_allowed_methods"`
+	CORSAllowedHeaders   string `json:"cors_allowed_headers" default:"Accept,Accept-Language,Authorization,Content-Type,X-Request-ID"`
+	CORSExposedHeaders   string `json:"cors_exposed_headers"`
+	CORSAllowCredentials bool   `json:"cors_allow_credentials" default:"false"`
+	CORSMaxAgeSeconds    int    `json:"cors_max_age_seconds" default:"600" validate:"gte=0"`
+
+	GinMode                       string `json:"gin_mode" default:"debug" validate:"oneof=debug release test"`
+	SecurityHeadersEnabled        bool   `json:"security_headers_enabled" default:"false"`
+	SecurityHSTSMaxAgeSeconds     int    `json:"security_hsts_max_age_seconds" default:"31536000" validate:"gte=0"`
+	SecurityHSTSIncludeSubdomains bool   `json:"security_hsts_include_subdomains" default:"false"`
+	SecurityFrameOptions          string `json:"security_frame_options" default:"DENY"`
+	SecurityReferrerPolicy        string `json:"security_referrer_policy" default:"strict-origin-when-cross-origin"`
+	SecurityDocsCSP               string `json:"security_docs_csp"`
+	SecurityStrictContentType     bool   `json:"security_strict_content_type" default:"false"`
+	SecurityTrustedProxies        string `json:"security_trusted_proxies"`
 }
 
 type ClientConfig struct {
-	OtelEnabled    bool  `json:"otel_enabled" default:"false"`
-	TimeoutMs      int   `json:"http_client_timeout_ms" default:"3000" required:"true" validate:"gte=100,lte=30000"`
-	MaxRetries     int   `json:"http_client_max_retries" default:"3" required:"true" validate:"gte=0,lte=5"`
-	BackoffBaseMs  int64 `json:"http_client_backoff_base_ms" default:"100" validate:"gte=50,lte=1000"`
-	BackoffMaxMs   int64 `json:"http_client_backoff_max_ms" default:"1000" validate:"gte=100,lte=5000"`
-	BackoffFactor  int   `json:"http_client_backoff_factor" default:"2" validate:"gte=1,lte=5"`
-	DisableBackoff bool  `json:"http_client_disable_backoff" default:"false"`
+	OtelEnabled       bool   `json:"otel_enabled" default:"false"`
+	TimeoutMs         int    `json:"http_client_timeout_ms" default:"3000" required:"true" validate:"gte=100,lte=30000"`
+	MaxRetries        int    `json:"http_client_max_retries" default:"3" required:"true" validate:"gte=0,lte=5"`
+	BackoffBaseMs     int64  `json:"http_client_backoff_base_ms" default:"100" validate:"gte=50,lte=1000"`
+	BackoffMaxMs      int64  `json:"http_client_backoff_max_ms" default:"1000" validate:"gte=100,lte=5000"`
+	BackoffFactor     int    `json:"http_client_backoff_factor" default:"2" validate:"gte=1,lte=5"`
+	DisableBackoff    bool   `json:"http_client_disable_backoff" default:"false"`
+	BaseURL           string `json:"http_client_base_url" validate:"omitempty,url"`
+	ValidateContracts bool   `json:"http_client_validate_contracts" default:"false"`
+	MaxResponseBytes  int64  `json:"http_client_max_response_bytes" default:"10485760" validate:"gte=0"`
+
+	Compression         string `json:"http_client_compression" validate:"omitempty,oneof=gzip zstd"`
+	CompressionMinBytes int    `json:"http_client_compression_min_bytes" default:"1024" validate:"gte=0"`
+
+	CacheEnabled    bool `json:"http_client_cache_enabled" default:"false"`
+	CacheMaxEntries int  `json:"http_client_cache_max_entries" default:"1000" validate:"gt=0"`
 }
 
 type Server struct {
@@ -56,20 +84,78 @@ type Server struct {
 	otelEnabled bool
 	config      *config.Config
 	server      *http.Server
+	validate    *validator.Validate
+	translators map[string]ut.Translator
+	rules       *validationRules
+	schemas     *schemaRegistry
+
+	openAPIVersion string
+	docValidation  string
+	contract       contractConfig
+	heartbeat      time.Duration // Interval of keep-alive messages on idle streams
+	webSocket      webSocketConfig
+	security       securityConfig
+	webSockets     webSocketSet
+	uploads        uploadConfig
+	cors           *CORSPolicy           // Nil when cross-origin requests are not allowed
+	preflights     map[string]*preflight // Preflight of each path with CORS, by route
 }
 
 type HTTPClient struct {
-	client      *http.Client
-	config      ClientConfig
-	otelEnabled bool
+	client              *http.Client
+	config              ClientConfig
+	otelEnabled         bool
+	callInterceptors    []Interceptor
+	attemptInterceptors []Interceptor
+	routes              map[string]*serviceRoute
+	validateContracts   bool
+	validate            *validator.Validate
+	trans               ut.Translator
+	cache               CacheStore // Nil when responses are not cached
 }
 
-func NewServer(c *config.Config) (*Server, error) {
+func NewServer(c *config.Config, opts ...ServerOption) (*Server, error) {
 	logger.Info("Creating new server")
-	gin.SetMode(gin.DebugMode)
+	security, err := newSecurityConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	gin.SetMode(security.ginMode)
 	engine := gin.New()
 	engine.Use(gin.Recovery())
+	if security.headers {
+		engine.Use(securityHeaders(security))
+	}
+	engine.Use(compressionMiddleware(newCompressionConfig(c)))
+
+	validate, trans, err := newValidator()
+	if err != nil {
+		return nil, err
+	}
+	openAPIVersion := getStringConfig(c, "openapi_version", OpenAPIVersion30)
+	if openAPIVersion != OpenAPIVersion30 && openAPIVersion != OpenAPIVersion31 {
+		return nil, fmt.Errorf("unsupported openapi_version %q", openAPIVersion)
+	}
+	docValidation := getStringConfig(c, "openapi_validation", DocValidationWarn)
+	if docValidation != DocValidationOff && docValidation != DocValidationWarn && docValidation != DocValidationFail {
+		return nil, fmt.Errorf("unsupported openapi_validation %q", docValidation)
+	}
 
+	docs, err := newDocsConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	cors, err := newCORSPolicy(c)
+	if err != nil {
+		return nil, err
+	}
+	contract, err := newContractConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := newValidationRules()
+	schemas := newSchemaRegistry(rules)
 	swaggerDoc := map[string]interface{}{
 		"openapi": "3.0.3",
 		"info": map[string]interface{}{
@@ -77,29 +163,49 @@ func NewServer(c *config.Config) (*Server, error) {
 			"version": "1.0.0",
 		},
 		"paths": map[string]interface{}{},
+		"components": map[string]interface{}{
+			"schemas": schemas.schemas,
+		},
 	}
 	server := &Server{
 		engine:      engine,
 		swagger:     swaggerDoc,
 		otelEnabled: c.GetBool("otel_enabled"),
 		config:      c,
+		validate:    validate,
+		translators: map[string]ut.Translator{defaultLocale: trans},
+		rules:       rules,
+		schemas:     schemas,
+
+		openAPIVersion: openAPIVersion,
+		docValidation:  docValidation,
+		contract:       contract,
+		heartbeat:      time.Duration(getIntConfig(c, "stream_heartbeat_ms", int(defaultHeartbeat.Milliseconds()))) * time.Millisecond,
+		webSocket:      newWebSocketConfig(c),
+		security:       security,
+		uploads:        newUploadConfig(c),
+		cors:           cors,
+		preflights:     map[string]*preflight{},
+	}
+	for _, opt := range opts {
+		opt(server)
 	}
 
 	engine.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 	})
-	engine.GET("/api/docs/swagger.json", func(c *gin.Context) {
-		c.JSON(http.StatusOK, server.swagger)
-	})
-	engine.GET("/api/docs/index.html", func(c *gin.Context) {
-		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIHTML))
-	})
+	if err := server.registerDocs(docs); err != nil {
+		return nil, err
+	}
 
 	logger.Info("Registering health and Swagger endpoints")
 	return server, nil
 }
 
 func (s *Server) ListenAndServe() error {
+	if err := s.checkContractAtStartup(); err != nil {
+		return err
+	}
 	port := s.config.Get("port").(int)
 	addr := fmt.Sprintf(":%d", port)
 	s.server = &http.Server{
@@ -114,12 +220,23 @@ func (s *Server) ListenAndServe() error {
 	return nil
 }
 
+// Handler returns the http.Handler serving the registered endpoints, for use
+// with a custom http.Server or httptest
+func (s *Server) Handler() http.Handler {
+	return s.engine
+}
+
 func (s *Server) Shutdown(ctx context.Context) error {
+	// WebSocket connections are hijacked, so the http.Server does not wait for them
+	wsErr := s.webSockets.closeAll(ctx)
 	if s.server == nil {
-		return nil
+		return wsErr
 	}
 	logger.Info("Shutting down server")
-	return s.server.Shutdown(ctx)
+	if err := s.server.Shutdown(ctx); err != nil {
+		return err
+	}
+	return wsErr
 }
 
 func (s *Server) RegisterService(svc interface{}, opts ...ServiceOption) error {
@@ -142,25 +259,64 @@ func (s *Server) RegisterService(svc interface{}, opts ...ServiceOption) error {
 }
 
 func (s *Server) registerMethods(methods []MethodInfo, cfg *serviceConfig, svc interface{}) error {
-	for _, m := range methods {
-		path := fmt.Sprintf("%s/%s", cfg.prefix, m.Name)
-		switch strings.ToUpper(m.HTTPMethod) {
-		case http.MethodGet:
-			s.engine.GET(path, s.handleMethod(m))
-		case http.MethodPost, http.MethodPut, http.MethodDelete,
-			http.MethodPatch, http.MethodOptions, http.MethodHead:
-			s.engine.Handle(strings.ToUpper(m.HTTPMethod), path, s.handleMethod(m))
-		default:
-			logger.Warn("Skipping invalid HTTP method", logger.String("method", m.HTTPMethod))
-			continue
+	if cfg.cors != nil {
+		if err := cfg.cors.validate(); err != nil {
+			return fmt.Errorf("failed to register service: invalid CORS policy: %w", err)
 		}
-		logger.Info("Registered endpoint", logger.String("method", m.HTTPMethod), logger.String("path", path))
 	}
 
+	// Document the service first, so an invalid document rejects it before any route exists
 	if len(methods) > 0 {
+		previous := s.snapshotDocument()
 		if err := updateSwaggerDoc(s, svc, cfg.prefix); err != nil {
 			logger.Error("Failed to update Swagger doc", logger.ErrField(err))
 		}
+		if err := s.checkOpenAPIDocument(); err != nil {
+			s.restoreDocument(previous)
+			return fmt.Errorf("failed to register service: %w", err)
+		}
+	}
+
+	// Answer the preflights of every path before its routes exist
+	cors := s.cors
+	if cfg.cors != nil {
+		cors = cfg.cors
+	}
+	if cors != nil && len(cors.AllowedOrigins) == 0 {
+		cors = nil
+	}
+	if cors != nil {
+		routes := map[string][]string{}
+		for _, m := range methods {
+			if method := strings.ToUpper(m.HTTPMethod); m.Kind != MethodWebSocket && isValidHTTPMethod(method) {
+				path := routePath(cfg.prefix, m)
+				routes[path] = append(routes[path], method)
+			}
+		}
+		s.registerCORS(cors, routes)
+	}
+
+	for _, m := range methods {
+		path := routePath(cfg.prefix, m)
+		if m.Kind == MethodWebSocket {
+			s.engine.GET(path, s.handleWebSocket(m))
+			logger.Info("Registered WebSocket endpoint", logger.String("path", path))
+			continue
+		}
+		method := strings.ToUpper(m.HTTPMethod)
+		if !isValidHTTPMethod(method) {
+			logger.Warn("Skipping invalid HTTP method", logger.String("method", m.HTTPMethod))
+			continue
+		}
+		handlers := []gin.HandlerFunc{s.handleMethod(m)}
+		if cors != nil {
+			if method == http.MethodOptions {
+				handlers = append([]gin.HandlerFunc{s.preflights[path].handle(true)}, handlers...)
+			}
+			handlers = append([]gin.HandlerFunc{cors.handle}, handlers...)
+		}
+		s.engine.Handle(method, path, handlers...)
+		logger.Info("Registered endpoint", logger.String("method", m.HTTPMethod), logger.String("path", path))
 	}
 
 	logger.Info("Registering endpoints with prefix", logger.String("prefix", cfg.prefix))
@@ -180,55 +336,78 @@ func (s *Server) handleMethod(m MethodInfo) gin.HandlerFunc {
 		}()
 
 		reqCtx := ctx
+		// Negotiate the response codec, or the stream format, before running the method
+		var respCodec Codec
+		var streamType string
+		ok := true
+		stream, _ := streamOf(m.OutputType)
+		download := isDownload(m.OutputType)
+		if stream != streamNone {
+			streamType, ok = negotiateStream(c.GetHeader("Accept"))
+		} else if !download {
+			respCodec, ok = negotiateCodec(c.GetHeader("Accept"), m.OutputType)
+		}
+		if !ok {
+			logger.ErrorContext(reqCtx, "No acceptable response media type", logger.String("accept", c.GetHeader("Accept")))
+			c.JSON(http.StatusNotAcceptable, gin.H{"error": "not acceptable: " + c.GetHeader("Accept")})
+			return
+		}
+
+		// Every body, whatever the input kind, is held to the accepted media types
+		if s.security.strictContentTypes && m.HTTPMethod != http.MethodGet {
+			if err := checkContentType(c, m); err != nil {
+				logger.ErrorContext(reqCtx, "Rejected request content type", logger.ErrField(err))
+				c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
 		var inputVal interface{}
 		inputType := m.InputType
 		if inputType.Kind() == reflect.String {
 			// For string inputs, use query parameter directly
 			if m.HTTPMethod == http.MethodGet {
-				query := c.Query("name")
-				inputVal = query
+				inputVal = c.Query(m.queryParam())
 			} else {
 				inputVal = reflect.New(inputType).Interface()
-				if err := c.ShouldBindJSON(inputVal); err != nil {
-					logger.ErrorContext(reqCtx, "JSON binding failed", logger.ErrField(err))
-					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				if status, err := decodeBody(c, inputVal, m.StrictJSON); err != nil {
+					logger.ErrorContext(reqCtx, "Body binding failed", logger.ErrField(err))
+					c.JSON(status, gin.H{"error": err.Error()})
 					return
 				}
 			}
 		} else {
-			// For struct inputs, bind and validate
+			// For struct inputs, bind every request part and validate; maps and
+			// slices are only decoded from the body
 			inputVal = reflect.New(inputType).Interface()
-			if m.HTTPMethod == http.MethodGet {
-				if err := c.ShouldBindQuery(inputVal); err != nil {
-					logger.ErrorContext(reqCtx, "Query binding failed", logger.ErrField(err))
-					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-					return
-				}
-			} else {
-				if err := c.ShouldBindJSON(inputVal); err != nil {
-					logger.ErrorContext(reqCtx, "JSON binding failed", logger.ErrField(err))
-					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			defer removeUploads(c)
+			if status, err := bindInput(c, m, inputVal, s.uploads); err != nil {
+				logger.ErrorContext(reqCtx, "Input binding failed", logger.ErrField(err))
+				c.JSON(status, gin.H{"error": err.Error()})
+				return
+			}
+			if hasStruct(inputType) {
+				if err := s.validate.Struct(inputVal); err != nil {
+					logger.ErrorContext(reqCtx, "Validation failed", logger.ErrField(err))
+					trans := s.translator(c.GetHeader("Accept-Language"))
+					c.JSON(http.StatusBadRequest, validationResponse(err, m, inputType, trans))
 					return
 				}
 			}
-			validate := validator.New()
-			if err := validate.Struct(inputVal); err != nil {
-				logger.ErrorContext(reqCtx, "Validation failed", logger.ErrField(err))
-				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("validation failed: %s", err.Error())})
-				return
-			}
 		}
 
 		// Prepare input for method call
-		var callInput reflect.Value
-		if inputType.Kind() == reflect.String {
-			callInput = reflect.ValueOf(inputVal)
-		} else {
-			callInput = reflect.ValueOf(inputVal).Elem()
+		callInput := reflect.ValueOf(inputVal)
+		if callInput.Kind() == reflect.Ptr {
+			callInput = callInput.Elem()
 		}
 
-		// Call the method without context
-		results := m.Func.Call([]reflect.Value{callInput})
+		// Pass the request context to methods accepting one
+		args := []reflect.Value{callInput}
+		if m.Func.Type().NumIn() == 2 {
+			args = append([]reflect.Value{reflect.ValueOf(reqCtx)}, args...)
+		}
+		results := m.Func.Call(args)
 		if !results[1].IsNil() {
 			err := results[1].Interface().(error)
 			logger.ErrorContext(reqCtx, "Method execution failed", logger.ErrField(err))
@@ -238,7 +417,23 @@ func (s *Server) handleMethod(m MethodInfo) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, results[0].Interface())
+		if stream != streamNone {
+			s.writeStream(c, results[0], stream, streamType)
+			return
+		}
+		if m.Cache != nil && (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) {
+			if download {
+				c.Header("Cache-Control", m.Cache.cacheControl())
+			} else {
+				writeCached(c, m.Cache, respCodec, results[0].Interface())
+				return
+			}
+		}
+		if download {
+			writeDownload(c, results[0])
+			return
+		}
+		writeResult(c, http.StatusOK, respCodec, results[0].Interface())
 	}
 }
 
@@ -260,16 +455,34 @@ func getBoolConfig(c *config.Config, key string, defaultValue bool) bool {
 	return defaultValue
 }
 
-func NewHTTPClient(c *config.Config) (*HTTPClient, error) {
+func getStringConfig(c *config.Config, key string, defaultValue string) string {
+	if val := c.Get(key); val != nil {
+		if strVal, ok := val.(string); ok {
+			return strVal
+		}
+	}
+	return defaultValue
+}
+
+func NewHTTPClient(c *config.Config, opts ...ClientOption) (*HTTPClient, error) {
 	logger.Info("Creating new HTTP client")
 	cfg := ClientConfig{
-		OtelEnabled:    getBoolConfig(c, "otel_enabled", false),
-		TimeoutMs:      getIntConfig(c, "http_client_timeout_ms", 3000),
-		MaxRetries:     getIntConfig(c, "http_client_max_retries", 3),
-		BackoffBaseMs:  int64(getIntConfig(c, "http_client_backoff_base_ms", 100)),
-		BackoffMaxMs:   int64(getIntConfig(c, "http_client_backoff_max_ms", 1000)),
-		BackoffFactor:  getIntConfig(c, "http_client_backoff_factor", 2),
-		DisableBackoff: getBoolConfig(c, "http_client_disable_backoff", false),
+		OtelEnabled:       getBoolConfig(c, "otel_enabled", false),
+		TimeoutMs:         getIntConfig(c, "http_client_timeout_ms", 3000),
+		MaxRetries:        getIntConfig(c, "http_client_max_retries", 3),
+		BackoffBaseMs:     int64(getIntConfig(c, "http_client_backoff_base_ms", 100)),
+		BackoffMaxMs:      int64(getIntConfig(c, "http_client_backoff_max_ms", 1000)),
+		BackoffFactor:     getIntConfig(c, "http_client_backoff_factor", 2),
+		DisableBackoff:    getBoolConfig(c, "http_client_disable_backoff", false),
+		BaseURL:           getStringConfig(c, "http_client_base_url", ""),
+		ValidateContracts: getBoolConfig(c, "http_client_validate_contracts", false),
+		MaxResponseBytes:  int64(getIntConfig(c, "http_client_max_response_bytes", defaultMaxResponseBytes)),
+
+		Compression:         getStringConfig(c, "http_client_compression", ""),
+		CompressionMinBytes: getIntConfig(c, "http_client_compression_min_bytes", defaultCompressionMinBytes),
+
+		CacheEnabled:    getBoolConfig(c, "http_client_cache_enabled", false),
+		CacheMaxEntries: getIntConfig(c, "http_client_cache_max_entries", defaultCacheMaxEntries),
 	}
 
 	validate := validator.New()
@@ -280,103 +493,252 @@ func NewHTTPClient(c *config.Config) (*HTTPClient, error) {
 	logger.Info("Using HTTP client timeout", logger.Int("timeout_ms", cfg.TimeoutMs))
 	logger.Info("Using HTTP max retries", logger.Int("max_retries", cfg.MaxRetries))
 
-	client := &http.Client{
-		Timeout: time.Duration(cfg.TimeoutMs) * time.Millisecond,
+	// Timeouts are applied per attempt so that calls can override them
+	client := &http.Client{}
+	h := &HTTPClient{
+		client:            client,
+		config:            cfg,
+		otelEnabled:       cfg.OtelEnabled,
+		validateContracts: cfg.ValidateContracts,
+	}
+	if cfg.CacheEnabled {
+		h.cache = NewMemoryCache(cfg.CacheMaxEntries)
 	}
-	return &HTTPClient{
-		client:      client,
-		config:      cfg,
-		otelEnabled: cfg.OtelEnabled,
-	}, nil
-}
 
-func (h *HTTPClient) Call(method, url string, input, output interface{}) error {
-	// Placeholder: no-op for tracing
-	ctx := context.Background()
-	var span interface{} // Placeholder
-	defer func() {
-		if span != nil {
-			// No-op
+	// Built-in interceptors run outermost, before any registered via options
+	if h.otelEnabled {
+		h.attemptInterceptors = append(h.attemptInterceptors, TracingInterceptor())
+	}
+	h.attemptInterceptors = append(h.attemptInterceptors, RequestIDInterceptor(), LoggingInterceptor())
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.validateContracts && h.validate == nil {
+		validate, trans, err := newValidator()
+		if err != nil {
+			return nil, err
+		}
+		h.validate, h.trans = validate, trans
+	} else if h.validateContracts && h.trans == nil {
+		trans, err := newTranslator(h.validate)
+		if err != nil {
+			return nil, err
 		}
-	}()
+		h.trans = trans
+	}
+	return h, nil
+}
+
+// Call sends input as JSON to url and decodes a successful response into output.
+// opts customise headers, query parameters, timeout and retries for this call only.
+// A JSON response is decoded as it is read, without keeping the raw body.
+func (h *HTTPClient) Call(method, url string, input, output interface{}, opts ...CallOption) error {
+	_, err := h.call(context.Background(), method, url, input, output, h.newCallOptions(opts))
+	return err
+}
+
+// CallWithResponse behaves like Call but also returns the response metadata.
+// The Response is non-nil whenever a response was received, including error statuses.
+func (h *HTTPClient) CallWithResponse(method, url string, input, output interface{}, opts ...CallOption) (*Response, error) {
+	co := h.newCallOptions(opts)
+	co.keepBody = true
+	return h.call(context.Background(), method, url, input, output, co)
+}
 
-	reqCtx := ctx
+// call sends a call within ctx, see CallWithResponse
+func (h *HTTPClient) call(ctx context.Context, method, url string, input, output interface{}, co *callOptions) (*Response, error) {
+	start := time.Now()
 	method = strings.ToUpper(method)
 	if !isValidHTTPMethod(method) {
 		err := fmt.Errorf("invalid HTTP method: %s", method)
-		logger.ErrorContext(reqCtx, "Invalid HTTP method", logger.ErrField(err))
-		return err
+		logger.ErrorContext(ctx, "Invalid HTTP method", logger.ErrField(err))
+		return nil, err
 	}
 
-	var bodyData []byte
-	var err error
-	if input != nil {
-		bodyData, err = json.Marshal(input)
-		if err != nil {
-			return fmt.Errorf("failed to marshal input: %w", err)
+	if err := h.checkContract(ContractRequest, method, input); err != nil {
+		logger.ErrorContext(ctx, "Request violates contract", logger.ErrField(err))
+		return nil, err
+	}
+
+	resp, err := h.send(ctx, method, url, input, co)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	response := &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Attempts:   co.attempts,
+		Cached:     co.cached,
+	}
+	defer func() { response.Duration = time.Since(start) }()
+
+	if co.isExpected(resp.StatusCode) {
+		if output != nil && resp.StatusCode != http.StatusNoContent {
+			if err := h.decodeResponse(resp, response, output, co); err != nil {
+				logger.ErrorContext(ctx, "Failed to decode response body", logger.ErrField(err))
+				return response, err
+			}
+			if err := h.checkContract(ContractResponse, method, output); err != nil {
+				logger.ErrorContext(ctx, "Response violates contract", logger.ErrField(err))
+				return response, err
+			}
+		} else if response.Body, err = io.ReadAll(limitBody(resp, co.maxResponseBytes)); err != nil {
+			logger.ErrorContext(ctx, "Failed to read response body", logger.ErrField(err))
+			return response, fmt.Errorf("failed to read response body: %w", err)
 		}
+		logger.InfoContext(ctx, "Request completed successfully")
+		return response, nil
 	}
 
-	for attempt := 1; attempt <= h.config.MaxRetries+1; attempt++ {
-		var body io.Reader
-		if bodyData != nil {
-			body = bytes.NewReader(bodyData) // Fresh reader for each attempt
-			logger.InfoContext(reqCtx, "Request body", logger.Int("length", len(bodyData)), logger.Int("attempt", attempt))
+	// Error bodies beyond the limit are truncated rather than failing the call
+	bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, co.errorBodyLimit()))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to read response body", logger.ErrField(err))
+		return response, fmt.Errorf("failed to read response body: %w", err)
+	}
+	response.Body = bodyBytes
+	logger.InfoContext(ctx, "Error response body", logger.String("body", string(bodyBytes)))
+	logger.InfoContext(ctx, "Response headers", logger.Any("headers", resp.Header))
+	respErr := newResponseError(resp.StatusCode, bodyBytes)
+	logger.ErrorContext(ctx, "Request failed with status", logger.Int("status", resp.StatusCode), logger.String("error", respErr.Message))
+	return response, respErr
+}
+
+// send builds the request of a call and sends it through the interceptors
+// and retries
+func (h *HTTPClient) send(ctx context.Context, method, url string, input interface{}, co *callOptions) (*http.Response, error) {
+	req, err := h.newRequest(ctx, method, url, input, co)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(withCallInfo(ctx, CallInfo{Scope: ScopeCall, MaxAttempts: co.maxRetries + 1}))
+	retry := func(req *http.Request) (*http.Response, error) {
+		return h.retry(req, co)
+	}
+	if h.cache != nil {
+		// The cache sees requests as the call interceptors leave them
+		return chainInterceptors(func(req *http.Request) (*http.Response, error) {
+			return h.sendCached(req, co, retry)
+		}, h.callInterceptors)(req)
+	}
+	return chainInterceptors(retry, h.callInterceptors)(req)
+}
+
+// newRequest builds the request of a call, encoding input with the call's
+// content type and applying its headers and query parameters
+func (h *HTTPClient) newRequest(ctx context.Context, method, url string, input interface{}, co *callOptions) (*http.Request, error) {
+	if co.contentType == MediaTypeMultipart {
+		return h.newUploadRequest(ctx, method, url, input, co)
+	}
+	var reqCodec Codec = jsonCodec{}
+	if co.contentType != "" {
+		c, ok := CodecFor(co.contentType)
+		if !ok {
+			return nil, fmt.Errorf("no codec registered for content type %s", co.contentType)
 		}
+		reqCodec = c
+	}
 
-		req, err := http.NewRequestWithContext(ctx, method, url, body)
+	var body io.Reader
+	var encoding string
+	if input != nil {
+		bodyData, err := reqCodec.Marshal(input)
 		if err != nil {
-			return fmt.Errorf("failed to create request: %w", err)
+			return nil, fmt.Errorf("failed to marshal input: %w", err)
 		}
-
-		if bodyData != nil {
-			req.Header.Set("Content-Type", "application/json")
+		if bodyData, encoding, err = h.compressBody(bodyData); err != nil {
+			return nil, err
 		}
-		req.Header.Set("X-Request-ID", uuid.New().String())
+		body = bytes.NewReader(bodyData)
+	}
+
+	url, err := h.resolveURL(url)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", reqCodec.MediaType())
+	}
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	req.Header.Set("Accept", reqCodec.MediaType())
+	co.apply(req)
+	return req, nil
+}
 
-		logger.InfoContext(reqCtx, "Sending request", logger.String("method", method), logger.String("url", url), logger.Int("attempt", attempt))
+// retry sends req through the per-attempt interceptor chain, retrying
+// transport errors and 5xx responses. It is the terminal of the per-call chain.
+func (h *HTTPClient) retry(req *http.Request, co *callOptions) (*http.Response, error) {
+	send := chainInterceptors(h.client.Do, h.attemptInterceptors)
+	maxAttempts := co.maxRetries + 1
+	tried := map[string]bool{}
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		co.attempts = attempt
+		var ctx context.Context
+		var cancelTimeout context.CancelFunc
+		var headerTimer *time.Timer
+		if co.stream {
+			// Streams only await their response headers within the timeout, then
+			// are read for as long as they last
+			ctx, cancelTimeout = context.WithCancel(req.Context())
+			headerTimer = time.AfterFunc(co.timeout, cancelTimeout)
+		} else {
+			ctx, cancelTimeout = context.WithTimeout(req.Context(), co.timeout)
+		}
+		attemptReq := req.Clone(withCallInfo(ctx, CallInfo{Scope: ScopeAttempt, Attempt: attempt, MaxAttempts: maxAttempts}))
+		release, err := h.pickEndpoint(attemptReq, req.URL.Host, tried)
+		if err != nil {
+			cancelTimeout()
+			return nil, err
+		}
+		cancel := func() {
+			cancelTimeout()
+			release()
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody() // Fresh reader for each attempt
+			if err != nil {
+				cancel()
+				return nil, fmt.Errorf("failed to create request: %w", err)
+			}
+			attemptReq.Body = body
+		}
+		acceptCompressed(attemptReq)
 
-		resp, err := h.client.Do(req)
+		resp, err := send(attemptReq)
+		if headerTimer != nil {
+			headerTimer.Stop()
+		}
 		if err != nil {
-			logger.ErrorContext(reqCtx, "Request attempt failed", logger.Int("attempt", attempt), logger.ErrField(err))
-			if attempt == h.config.MaxRetries+1 {
-				return fmt.Errorf("request failed: %w", err)
+			cancel()
+			if attempt == maxAttempts {
+				return nil, fmt.Errorf("request failed: %w", err)
 			}
 			continue
 		}
-		defer resp.Body.Close()
+		if err := decompressResponse(attemptReq, resp); err != nil {
+			resp.Body.Close()
+			cancel()
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
 
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			if output != nil {
-				bodyBytes, err := io.ReadAll(resp.Body)
-				if err != nil {
-					logger.ErrorContext(reqCtx, "Failed to read response body", logger.ErrField(err))
-					return fmt.Errorf("failed to read response body: %w", err)
-				}
-				if err := json.Unmarshal(bodyBytes, output); err != nil {
-					return fmt.Errorf("failed to unmarshal response: %w", err)
-				}
-			}
-			logger.InfoContext(reqCtx, "Request completed successfully")
-			return nil
-		}
-
-		if resp.StatusCode < 500 || attempt == h.config.MaxRetries+1 {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			logger.InfoContext(reqCtx, "Error response body", logger.String("body", string(bodyBytes)))
-			logger.InfoContext(reqCtx, "Response headers", logger.Any("headers", resp.Header))
-			var errResp map[string]string
-			if len(bodyBytes) > 0 {
-				if err := json.Unmarshal(bodyBytes, &errResp); err == nil && errResp["error"] != "" {
-					logger.ErrorContext(reqCtx, "Request failed with status", logger.Int("status", resp.StatusCode), logger.String("error", errResp["error"]))
-					return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, errResp["error"])
-				}
-			}
-			logger.ErrorContext(reqCtx, "Request failed with status", logger.Int("status", resp.StatusCode), logger.String("error", "unknown error"))
-			return fmt.Errorf("request failed with status %d: unknown error", resp.StatusCode)
+		if resp.StatusCode < 500 || co.isExpected(resp.StatusCode) || attempt == maxAttempts {
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
 		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		cancel()
 
-		logger.ErrorContext(reqCtx, "Request attempt failed with status", logger.Int("attempt", attempt), logger.Int("status", resp.StatusCode))
+		logger.ErrorContext(req.Context(), "Request attempt failed with status", logger.Int("attempt", attempt), logger.Int("status", resp.StatusCode))
 
 		if h.config.DisableBackoff {
 			continue
@@ -389,5 +751,5 @@ func (h *HTTPClient) Call(method, url string, input, output interface{}) error {
 		time.Sleep(time.Duration(backoff) * time.Millisecond)
 	}
 
-	return fmt.Errorf("all retry attempts failed")
+	return nil, fmt.Errorf("all retry attempts failed")
 }
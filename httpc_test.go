@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"reflect"
 	"testing"
@@ -230,8 +231,7 @@ func TestHTTPC(t *testing.T) {
 			require.True(t, ok)
 			jsonContent, ok := content["application/json"].(map[string]interface{})
 			require.True(t, ok)
-			schema, ok := jsonContent["schema"].(map[string]interface{})
-			require.True(t, ok)
+			schema := resolveRef(t, doc, jsonContent["schema"])
 			properties, ok := schema["properties"].(map[string]interface{})
 			require.True(t, ok)
 
@@ -284,8 +284,7 @@ func TestHTTPC(t *testing.T) {
 			require.True(t, ok)
 			jsonContent, ok := content["application/json"].(map[string]interface{})
 			require.True(t, ok)
-			schema, ok := jsonContent["schema"].(map[string]interface{})
-			require.True(t, ok)
+			schema := resolveRef(t, doc, jsonContent["schema"])
 			properties, ok := schema["properties"].(map[string]interface{})
 			require.True(t, ok)
 
@@ -300,8 +299,7 @@ func TestHTTPC(t *testing.T) {
 			require.Equal(t, float64(18), ageProp["minimum"])
 			require.Equal(t, float64(120), ageProp["maximum"])
 
-			addressProp, ok := properties["address"].(map[string]interface{})
-			require.True(t, ok)
+			addressProp := resolveRef(t, doc, properties["address"])
 			require.Equal(t, "object", addressProp["type"])
 			addressProps, ok := addressProp["properties"].(map[string]interface{})
 			require.True(t, ok)
@@ -343,4 +341,43 @@ func TestHTTPC(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "Hello, Test!", result)
 	})
+
+	t.Run("Struct Query Binding", func(t *testing.T) {
+		svc := &SearchService{}
+		ts := setupServer(t, serverCfg, svc, "/v1")
+		defer ts.Close()
+
+		client, err := NewHTTPClient(clientDefaultCfg)
+		require.NoError(t, err)
+
+		var output SearchResult
+		query := url.Values{
+			"q":           {"go"},
+			"tag":         {"a", "b"},
+			"limit":       {"5"},
+			"since":       {"2024-03-01"},
+			"page.number": {"2"},
+			"page.size":   {"20"},
+		}
+		err = client.Call("GET", ts.URL+"/v1/Search", nil, &output, WithQuery(query))
+		require.NoError(t, err)
+		require.Equal(t, "q=go tags=a|b limit=5 since=2024-03-01 page=2/20", output.Summary)
+
+		err = client.Call("GET", ts.URL+"/v1/Search", nil, &output, WithQuery(url.Values{"q": {"go"}}))
+		require.NoError(t, err)
+		require.Equal(t, "q=go tags= limit=none since=0001-01-01 page=0/0", output.Summary)
+
+		err = client.Call("GET", ts.URL+"/v1/Search", nil, &output, WithQuery(url.Values{"q": {"go"}, "limit": {"many"}}))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "request failed with status 400")
+		require.Contains(t, err.Error(), "field limit")
+
+		err = client.Call("GET", ts.URL+"/v1/Search", nil, &output, WithQuery(url.Values{"tag": {"a"}}))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "validation failed")
+
+		err = client.Call("GET", ts.URL+"/v1/Lookup", nil, &output, WithQuery(url.Values{"id": {"42"}}))
+		require.NoError(t, err)
+		require.Equal(t, "id=42", output.Summary)
+	})
 }
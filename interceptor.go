@@ -0,0 +1,201 @@
+package httpc
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RoundTripFunc performs a single HTTP exchange
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// Interceptor wraps a RoundTripFunc with cross-cutting behaviour such as
+// header injection, logging, signing or tracing
+type Interceptor func(next RoundTripFunc) RoundTripFunc
+
+// InterceptorScope tells an interceptor where in a Call it is running
+type InterceptorScope int
+
+const (
+	// ScopeAttempt interceptors run once per retry attempt
+	ScopeAttempt InterceptorScope = iota
+	// ScopeCall interceptors run once per logical Call, around all retries
+	ScopeCall
+)
+
+// String returns the scope name
+func (s InterceptorScope) String() string {
+	if s == ScopeCall {
+		return "call"
+	}
+	return "attempt"
+}
+
+// CallInfo describes the call an interceptor is running in
+type CallInfo struct {
+	Scope       InterceptorScope
+	Attempt     int // 1-based attempt number, 0 for ScopeCall
+	MaxAttempts int
+}
+
+type callInfoKey struct{}
+
+// withCallInfo stores call information in the request context
+func withCallInfo(ctx context.Context, info CallInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, info)
+}
+
+// CallInfoFromContext returns the call information for the request being intercepted
+func CallInfoFromContext(ctx context.Context) (CallInfo, bool) {
+	info, ok := ctx.Value(callInfoKey{}).(CallInfo)
+	return info, ok
+}
+
+// ClientOption configures an HTTPClient
+type ClientOption func(*HTTPClient)
+
+// WithInterceptor registers interceptors that run once per retry attempt.
+// Interceptors run in registration order, the first being outermost.
+func WithInterceptor(interceptors ...Interceptor) ClientOption {
+	return func(h *HTTPClient) {
+		h.attemptInterceptors = append(h.attemptInterceptors, interceptors...)
+	}
+}
+
+// WithCallInterceptor registers interceptors that run once per logical Call,
+// wrapping the whole retry loop. They observe the final response only.
+func WithCallInterceptor(interceptors ...Interceptor) ClientOption {
+	return func(h *HTTPClient) {
+		h.callInterceptors = append(h.callInterceptors, interceptors...)
+	}
+}
+
+// chainInterceptors composes interceptors around a terminal RoundTripFunc
+func chainInterceptors(terminal RoundTripFunc, interceptors []Interceptor) RoundTripFunc {
+	next := terminal
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		next = interceptors[i](next)
+	}
+	return next
+}
+
+// RequestIDInterceptor sets a fresh X-Request-ID header unless one is already present
+func RequestIDInterceptor() Interceptor {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("X-Request-ID") == "" {
+				req.Header.Set("X-Request-ID", uuid.New().String())
+			}
+			return next(req)
+		}
+	}
+}
+
+// LoggingInterceptor logs each request and its outcome
+func LoggingInterceptor() Interceptor {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			info, _ := CallInfoFromContext(ctx)
+			if req.ContentLength > 0 {
+				logger.InfoContext(ctx, "Request body", logger.Int("length", int(req.ContentLength)), logger.Int("attempt", info.Attempt))
+			}
+			logger.InfoContext(ctx, "Sending request", logger.String("method", req.Method), logger.String("url", req.URL.String()),
+				logger.Int("attempt", info.Attempt), logger.String("scope", info.Scope.String()))
+
+			resp, err := next(req)
+			if err != nil {
+				logger.ErrorContext(ctx, "Request attempt failed", logger.Int("attempt", info.Attempt), logger.ErrField(err))
+				return nil, err
+			}
+			logger.InfoContext(ctx, "Received response", logger.Int("status", resp.StatusCode), logger.Int("attempt", info.Attempt))
+			return resp, nil
+		}
+	}
+}
+
+// BearerAuthInterceptor sets an Authorization bearer token on every request
+func BearerAuthInterceptor(token string) Interceptor {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("Authorization", "Bearer "+token)
+			return next(req)
+		}
+	}
+}
+
+// BasicAuthInterceptor sets HTTP basic auth credentials on every request
+func BasicAuthInterceptor(username, password string) Interceptor {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.SetBasicAuth(username, password)
+			return next(req)
+		}
+	}
+}
+
+// TracingInterceptor starts a client span for each request and propagates
+// the trace context in the request headers
+func TracingInterceptor() Interceptor {
+	tracer := otel.Tracer("github.com/T-Prohmpossadhorn/go-core-httpc")
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			ctx, span := tracer.Start(req.Context(), "HTTP "+req.Method, trace.WithSpanKind(trace.SpanKindClient))
+			defer span.End()
+
+			info, _ := CallInfoFromContext(ctx)
+			span.SetAttributes(
+				attribute.String("http.request.method", req.Method),
+				attribute.String("url.full", req.URL.String()),
+			)
+			if info.Attempt > 1 {
+				// The first attempt is not a resend
+				span.SetAttributes(attribute.Int("http.request.resend_count", info.Attempt-1))
+			}
+
+			req = req.WithContext(ctx)
+			otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+			resp, err := next(req)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return nil, err
+			}
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+			if resp.StatusCode >= 500 {
+				span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+			}
+			return resp, nil
+		}
+	}
+}
+
+// MetricsRecorder receives one observation per intercepted request
+type MetricsRecorder interface {
+	ObserveRequest(method, host string, status int, duration time.Duration, err error)
+}
+
+// MetricsInterceptor reports the method, host, status and latency of each request
+func MetricsInterceptor(recorder MetricsRecorder) Interceptor {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+			status := 0
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			recorder.ObserveRequest(req.Method, req.URL.Host, status, time.Since(start), err)
+			return resp, err
+		}
+	}
+}
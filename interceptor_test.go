@@ -0,0 +1,176 @@
+package httpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type testMetricsRecorder struct {
+	mu       sync.Mutex
+	statuses []int
+}
+
+func (r *testMetricsRecorder) ObserveRequest(method, host string, status int, duration time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses = append(r.statuses, status)
+}
+
+func TestInterceptors(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	clientCfg, err := config.New(config.WithDefault(map[string]interface{}{
+		"otel_enabled":                false,
+		"http_client_timeout_ms":      1000,
+		"http_client_max_retries":     2,
+		"http_client_disable_backoff": true,
+	}))
+	require.NoError(t, err)
+
+	t.Run("Call And Attempt Scopes", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"always failing"}`))
+		}))
+		defer ts.Close()
+
+		var mu sync.Mutex
+		var seen []CallInfo
+		record := func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				info, ok := CallInfoFromContext(req.Context())
+				require.True(t, ok)
+				mu.Lock()
+				seen = append(seen, info)
+				mu.Unlock()
+				return next(req)
+			}
+		}
+
+		client, err := NewHTTPClient(clientCfg, WithCallInterceptor(record), WithInterceptor(record))
+		require.NoError(t, err)
+
+		err = client.Call("GET", ts.URL, nil, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "always failing")
+
+		require.Len(t, seen, 4)
+		require.Equal(t, CallInfo{Scope: ScopeCall, MaxAttempts: 3}, seen[0])
+		for i, info := range seen[1:] {
+			require.Equal(t, ScopeAttempt, info.Scope)
+			require.Equal(t, i+1, info.Attempt)
+		}
+	})
+
+	t.Run("Registration Order", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`"` + r.Header.Get("X-Order") + `"`))
+		}))
+		defer ts.Close()
+
+		appendHeader := func(v string) Interceptor {
+			return func(next RoundTripFunc) RoundTripFunc {
+				return func(req *http.Request) (*http.Response, error) {
+					req.Header.Set("X-Order", req.Header.Get("X-Order")+v)
+					return next(req)
+				}
+			}
+		}
+
+		client, err := NewHTTPClient(clientCfg, WithInterceptor(appendHeader("a"), appendHeader("b")), WithInterceptor(appendHeader("c")))
+		require.NoError(t, err)
+
+		var result string
+		require.NoError(t, client.Call("GET", ts.URL, nil, &result))
+		require.Equal(t, "abc", result)
+	})
+
+	t.Run("Built-in Interceptors", func(t *testing.T) {
+		var gotAuth, gotRequestID string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotAuth = r.Header.Get("Authorization")
+			gotRequestID = r.Header.Get("X-Request-ID")
+			w.Write([]byte(`"ok"`))
+		}))
+		defer ts.Close()
+
+		recorder := &testMetricsRecorder{}
+		client, err := NewHTTPClient(clientCfg,
+			WithInterceptor(BearerAuthInterceptor("secret"), MetricsInterceptor(recorder)),
+		)
+		require.NoError(t, err)
+
+		var result string
+		require.NoError(t, client.Call("GET", ts.URL, nil, &result))
+		require.Equal(t, "Bearer secret", gotAuth)
+		require.NotEmpty(t, gotRequestID)
+		require.Equal(t, []int{http.StatusOK}, recorder.statuses)
+	})
+
+	t.Run("Tracing Resend Count", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		defer otel.SetTracerProvider(previous)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer ts.Close()
+
+		client, err := NewHTTPClient(clientCfg, WithInterceptor(TracingInterceptor()))
+		require.NoError(t, err)
+		require.Error(t, client.Call("GET", ts.URL, nil, nil))
+
+		var counts []int64
+		for _, span := range recorder.Ended() {
+			count := int64(-1)
+			for _, attr := range span.Attributes() {
+				if attr.Key == attribute.Key("http.request.resend_count") {
+					count = attr.Value.AsInt64()
+				}
+			}
+			counts = append(counts, count)
+		}
+		require.Equal(t, []int64{-1, 1, 2}, counts, "the first attempt has no resend count")
+	})
+
+	t.Run("Body Replayed On Retry", func(t *testing.T) {
+		var mu sync.Mutex
+		var bodies []int64
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			bodies = append(bodies, r.ContentLength)
+			mu.Unlock()
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer ts.Close()
+
+		client, err := NewHTTPClient(clientCfg)
+		require.NoError(t, err)
+
+		err = client.Call("POST", ts.URL, MultiInput{Value: "retry"}, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "request failed with status 503")
+		require.Len(t, bodies, 3)
+		for _, n := range bodies {
+			require.Greater(t, n, int64(0))
+		}
+	})
+}
@@ -0,0 +1,543 @@
+package httpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+)
+
+// Supported values of the openapi_version config key
+const (
+	OpenAPIVersion30 = "3.0.3"
+	OpenAPIVersion31 = "3.1.0"
+)
+
+// Supported values of the openapi_validation config key
+const (
+	DocValidationOff  = "off"  // Do not check the generated document
+	DocValidationWarn = "warn" // Log problems of the generated document
+	DocValidationFail = "fail" // Reject services that make the document invalid
+)
+
+// jsonSchemaDialect is the schema dialect declared by OpenAPI 3.1 documents
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// DocumentError lists the problems found in a generated OpenAPI document
+type DocumentError struct {
+	Problems []string
+}
+
+func (e *DocumentError) Error() string {
+	return "invalid OpenAPI document: " + strings.Join(e.Problems, "; ")
+}
+
+// OpenAPIDocument returns a copy of the generated document in the configured OpenAPI
+// version, as served at swagger.json
+func (s *Server) OpenAPIDocument() (map[string]interface{}, error) {
+	data, err := json.Marshal(s.swagger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode OpenAPI document: %w", err)
+	}
+	if s.openAPIVersion == OpenAPIVersion31 {
+		convertOpenAPI31(doc)
+	}
+	return doc, nil
+}
+
+// checkOpenAPIDocument validates the generated document according to the
+// openapi_validation mode, returning an error only in fail mode
+func (s *Server) checkOpenAPIDocument() error {
+	if s.docValidation == DocValidationOff {
+		return nil
+	}
+	doc, err := s.OpenAPIDocument()
+	if err == nil {
+		err = validateOpenAPI(doc)
+	}
+	if err == nil {
+		return nil
+	}
+	if s.docValidation == DocValidationFail {
+		return err
+	}
+	logger.Warn("Generated OpenAPI document is invalid", logger.String("error", err.Error()))
+	return nil
+}
+
+// docSnapshot holds what registering a service adds to the document: its
+// paths, component schemas and the names reserved for them
+type docSnapshot struct {
+	paths   map[string]interface{}
+	schemas map[string]interface{}
+	names   map[reflect.Type]string
+}
+
+// snapshotDocument records the document down to the operations and component
+// schemas, so a failed registration can restore it with restoreDocument
+func (s *Server) snapshotDocument() docSnapshot {
+	paths, _ := s.swagger["paths"].(map[string]interface{})
+	snap := docSnapshot{
+		paths:   make(map[string]interface{}, len(paths)),
+		schemas: make(map[string]interface{}, len(s.schemas.schemas)),
+		names:   make(map[reflect.Type]string, len(s.schemas.names)),
+	}
+	for path, item := range paths {
+		operations := map[string]interface{}{}
+		for method, operation := range item.(map[string]interface{}) {
+			operations[method] = operation
+		}
+		snap.paths[path] = operations
+	}
+	for name, schema := range s.schemas.schemas {
+		snap.schemas[name] = schema
+	}
+	for t, name := range s.schemas.names {
+		snap.names[t] = name
+	}
+	return snap
+}
+
+// restoreDocument undoes the changes made to the document since snap. The
+// schema maps are restored in place, as the document's components share them.
+func (s *Server) restoreDocument(snap docSnapshot) {
+	s.swagger["paths"] = snap.paths
+	for name := range s.schemas.schemas {
+		if _, ok := snap.schemas[name]; !ok {
+			delete(s.schemas.schemas, name)
+		}
+	}
+	for name, schema := range snap.schemas {
+		s.schemas.schemas[name] = schema
+	}
+	for t := range s.schemas.names {
+		if _, ok := snap.names[t]; !ok {
+			delete(s.schemas.names, t)
+		}
+	}
+}
+
+// walkSchemas calls fn with the location of every top-level schema of a
+// decoded document: components, parameters, request bodies and responses.
+// The schema is replaced by the result of fn.
+func walkSchemas(doc map[string]interface{}, fn func(location string, schema map[string]interface{}) map[string]interface{}) {
+	visit := func(location string, holder map[string]interface{}) {
+		if schema, ok := holder["schema"].(map[string]interface{}); ok {
+			holder["schema"] = fn(location, schema)
+		}
+	}
+	visitContent := func(location string, content interface{}) {
+		media, _ := content.(map[string]interface{})
+		for _, mediaType := range sortedKeys(media) {
+			if holder, ok := media[mediaType].(map[string]interface{}); ok {
+				visit(location+".content["+mediaType+"].schema", holder)
+			}
+		}
+	}
+
+	if components, ok := doc["components"].(map[string]interface{}); ok {
+		schemas, _ := components["schemas"].(map[string]interface{})
+		for _, name := range sortedKeys(schemas) {
+			if schema, ok := schemas[name].(map[string]interface{}); ok {
+				schemas[name] = fn("components.schemas."+name, schema)
+			}
+		}
+	}
+
+	paths, _ := doc["paths"].(map[string]interface{})
+	for _, path := range sortedKeys(paths) {
+		item, _ := paths[path].(map[string]interface{})
+		for _, method := range sortedKeys(item) {
+			operation, ok := item[method].(map[string]interface{})
+			if !ok || !operationMethods[method] {
+				continue
+			}
+			location := "paths[" + path + "]." + method
+			params, _ := operation["parameters"].([]interface{})
+			for i, p := range params {
+				if param, ok := p.(map[string]interface{}); ok {
+					visit(fmt.Sprintf("%s.parameters[%d].schema", location, i), param)
+				}
+			}
+			if body, ok := operation["requestBody"].(map[string]interface{}); ok {
+				visitContent(location+".requestBody", body["content"])
+			}
+			responses, _ := operation["responses"].(map[string]interface{})
+			for _, status := range sortedKeys(responses) {
+				if response, ok := responses[status].(map[string]interface{}); ok {
+					visitContent(location+".responses."+status, response["content"])
+				}
+			}
+		}
+	}
+}
+
+// subschemas calls fn for every schema nested directly in schema
+func subschemas(schema map[string]interface{}, fn func(keyword string, sub map[string]interface{}) map[string]interface{}) {
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		for _, name := range sortedKeys(properties) {
+			if sub, ok := properties[name].(map[string]interface{}); ok {
+				properties[name] = fn("properties."+name, sub)
+			}
+		}
+	}
+	for _, keyword := range []string{"items", "additionalProperties", "not"} {
+		if sub, ok := schema[keyword].(map[string]interface{}); ok {
+			schema[keyword] = fn(keyword, sub)
+		}
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		list, _ := schema[keyword].([]interface{})
+		for i, item := range list {
+			if sub, ok := item.(map[string]interface{}); ok {
+				list[i] = fn(fmt.Sprintf("%s[%d]", keyword, i), sub)
+			}
+		}
+	}
+}
+
+// convertOpenAPI31 rewrites a decoded OpenAPI 3.0 document as OpenAPI 3.1
+func convertOpenAPI31(doc map[string]interface{}) {
+	doc["openapi"] = OpenAPIVersion31
+	doc["jsonSchemaDialect"] = jsonSchemaDialect
+	walkSchemas(doc, func(_ string, schema map[string]interface{}) map[string]interface{} {
+		return convertSchema31(schema)
+	})
+}
+
+// convertSchema31 rewrites the OpenAPI 3.0 keywords of a schema as JSON Schema 2020-12:
+// nullable becomes a "null" type, boolean exclusive bounds become numeric ones,
+// example becomes examples and binary strings declare a content media type
+func convertSchema31(schema map[string]interface{}) map[string]interface{} {
+	subschemas(schema, func(_ string, sub map[string]interface{}) map[string]interface{} {
+		return convertSchema31(sub)
+	})
+
+	for exclusive, bound := range map[string]string{"exclusiveMinimum": "minimum", "exclusiveMaximum": "maximum"} {
+		if flag, ok := schema[exclusive].(bool); ok {
+			delete(schema, exclusive)
+			if flag {
+				schema[exclusive] = schema[bound]
+				delete(schema, bound)
+			}
+		}
+	}
+	if example, ok := schema["example"]; ok {
+		schema["examples"] = []interface{}{example}
+		delete(schema, "example")
+	}
+	if schema["format"] == "binary" {
+		delete(schema, "format")
+		schema["contentMediaType"] = MediaTypeOctetStream
+	}
+
+	nullable, _ := schema["nullable"].(bool)
+	delete(schema, "nullable")
+	if !nullable {
+		return schema
+	}
+	if typ, ok := schema["type"].(string); ok {
+		schema["type"] = []interface{}{typ, "null"}
+		if enum, ok := schema["enum"].([]interface{}); ok {
+			schema["enum"] = append(enum, nil)
+		}
+		return schema
+	}
+	// A nullable reference is wrapped in a single-element allOf
+	if allOf, ok := schema["allOf"].([]interface{}); ok && len(allOf) == 1 {
+		delete(schema, "allOf")
+		schema["anyOf"] = []interface{}{allOf[0], map[string]interface{}{"type": "null"}}
+	}
+	return schema
+}
+
+// operationMethods are the operation keys of a path item
+var operationMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true, "trace": true,
+}
+
+// pathItemFields are the non-operation keys of a path item
+var pathItemFields = map[string]bool{
+	"$ref": true, "summary": true, "description": true, "servers": true, "parameters": true,
+}
+
+// parameterLocations are the valid values of a parameter's in field
+var parameterLocations = map[string]bool{"query": true, "header": true, "path": true, "cookie": true}
+
+// schemaTypes are the OpenAPI 3.0 schema types; 3.1 adds "null"
+var schemaTypes = map[string]bool{
+	"array": true, "boolean": true, "integer": true, "number": true, "object": true, "string": true,
+}
+
+var (
+	statusCodePattern    = regexp.MustCompile(`^[1-5](\d\d|XX)$`)
+	componentNamePattern = regexp.MustCompile(`^[a-zA-Z0-9.\-_]+$`)
+	pathTemplatePattern  = regexp.MustCompile(`\{([^{}]+)\}`)
+)
+
+// validateOpenAPI checks the structure of a decoded OpenAPI 3.0 or 3.1 document,
+// returning a *DocumentError listing every problem found
+func validateOpenAPI(doc map[string]interface{}) error {
+	v := &docValidator{doc: doc}
+	v.validate()
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &DocumentError{Problems: v.problems}
+}
+
+type docValidator struct {
+	doc      map[string]interface{}
+	v31      bool
+	problems []string
+}
+
+func (v *docValidator) addf(location, format string, args ...interface{}) {
+	v.problems = append(v.problems, location+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *docValidator) validate() {
+	version, _ := v.doc["openapi"].(string)
+	switch {
+	case strings.HasPrefix(version, "3.0."):
+	case strings.HasPrefix(version, "3.1."):
+		v.v31 = true
+	default:
+		v.addf("openapi", "unsupported version %q", version)
+		return
+	}
+
+	info, ok := v.doc["info"].(map[string]interface{})
+	if !ok {
+		v.addf("info", "missing info object")
+	} else {
+		for _, field := range []string{"title", "version"} {
+			if value, _ := info[field].(string); value == "" {
+				v.addf("info."+field, "must be a non-empty string")
+			}
+		}
+	}
+
+	if components, ok := v.doc["components"].(map[string]interface{}); ok {
+		schemas, _ := components["schemas"].(map[string]interface{})
+		for _, name := range sortedKeys(schemas) {
+			if !componentNamePattern.MatchString(name) {
+				v.addf("components.schemas."+name, "invalid component name")
+			}
+		}
+	}
+
+	paths, ok := v.doc["paths"].(map[string]interface{})
+	if !ok && !v.v31 {
+		v.addf("paths", "missing paths object")
+	}
+	operationIDs := map[string]string{}
+	for _, path := range sortedKeys(paths) {
+		location := "paths[" + path + "]"
+		if !strings.HasPrefix(path, "/") {
+			v.addf(location, "path must start with /")
+		}
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			v.addf(location, "path item must be an object")
+			continue
+		}
+		for _, method := range sortedKeys(item) {
+			if !operationMethods[method] {
+				if !pathItemFields[method] {
+					v.addf(location, "unknown field %q", method)
+				}
+				continue
+			}
+			operation, ok := item[method].(map[string]interface{})
+			if !ok {
+				v.addf(location+"."+method, "operation must be an object")
+				continue
+			}
+			v.validateOperation(location+"."+method, path, operation, operationIDs)
+		}
+	}
+
+	walkSchemas(v.doc, func(location string, schema map[string]interface{}) map[string]interface{} {
+		v.validateSchema(location, schema)
+		return schema
+	})
+}
+
+func (v *docValidator) validateOperation(location, path string, operation map[string]interface{}, operationIDs map[string]string) {
+	if id, ok := operation["operationId"].(string); ok {
+		if previous, taken := operationIDs[id]; taken {
+			v.addf(location, "operationId %q is already used by %s", id, previous)
+		} else {
+			operationIDs[id] = location
+		}
+	}
+	if tags, ok := operation["tags"]; ok {
+		list, ok := tags.([]interface{})
+		for _, tag := range list {
+			if _, isString := tag.(string); !isString {
+				ok = false
+			}
+		}
+		if !ok {
+			v.addf(location+".tags", "must be an array of strings")
+		}
+	}
+
+	templateParams := map[string]bool{}
+	for _, match := range pathTemplatePattern.FindAllStringSubmatch(path, -1) {
+		templateParams[match[1]] = true
+	}
+	declared := map[string]bool{}
+	params, _ := operation["parameters"].([]interface{})
+	for i, p := range params {
+		paramLocation := fmt.Sprintf("%s.parameters[%d]", location, i)
+		param, ok := p.(map[string]interface{})
+		if !ok {
+			v.addf(paramLocation, "parameter must be an object")
+			continue
+		}
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		if name == "" {
+			v.addf(paramLocation, "missing name")
+		}
+		if !parameterLocations[in] {
+			v.addf(paramLocation, "invalid location %q", in)
+		}
+		if declared[in+":"+name] {
+			v.addf(paramLocation, "duplicate %s parameter %q", in, name)
+		}
+		declared[in+":"+name] = true
+		_, hasSchema := param["schema"]
+		_, hasContent := param["content"]
+		if hasSchema == hasContent {
+			v.addf(paramLocation, "exactly one of schema and content is required")
+		}
+		if in == "path" {
+			if required, _ := param["required"].(bool); !required {
+				v.addf(paramLocation, "path parameter %q must be required", name)
+			}
+			if !templateParams[name] {
+				v.addf(paramLocation, "path parameter %q is not in the path template", name)
+			}
+		}
+	}
+	for _, name := range sortedKeys(templateParams) {
+		if !declared["path:"+name] {
+			v.addf(location, "path template parameter %q is not declared", name)
+		}
+	}
+
+	if body, ok := operation["requestBody"]; ok {
+		body, _ := body.(map[string]interface{})
+		if content, _ := body["content"].(map[string]interface{}); len(content) == 0 {
+			v.addf(location+".requestBody", "content must not be empty")
+		}
+	}
+
+	responses, _ := operation["responses"].(map[string]interface{})
+	if len(responses) == 0 {
+		v.addf(location, "missing responses")
+	}
+	for _, status := range sortedKeys(responses) {
+		responseLocation := location + ".responses." + status
+		if status != "default" && !statusCodePattern.MatchString(status) {
+			v.addf(responseLocation, "invalid status code")
+		}
+		response, _ := responses[status].(map[string]interface{})
+		if _, ok := response["description"].(string); !ok {
+			v.addf(responseLocation, "missing description")
+		}
+	}
+}
+
+func (v *docValidator) validateSchema(location string, schema map[string]interface{}) {
+	if ref, ok := schema["$ref"]; ok {
+		ref, _ := ref.(string)
+		if name := strings.TrimPrefix(ref, componentsPrefix); name != ref {
+			components, _ := v.doc["components"].(map[string]interface{})
+			schemas, _ := components["schemas"].(map[string]interface{})
+			if _, ok := schemas[name]; !ok {
+				v.addf(location, "unresolved reference %q", ref)
+			}
+		}
+		if !v.v31 && len(schema) > 1 {
+			v.addf(location, "$ref must not have sibling keywords in OpenAPI 3.0")
+		}
+	}
+
+	switch typ := schema["type"].(type) {
+	case nil:
+	case string:
+		if !schemaTypes[typ] && !(v.v31 && typ == "null") {
+			v.addf(location, "invalid type %q", typ)
+		}
+	case []interface{}:
+		if !v.v31 {
+			v.addf(location, "type arrays require OpenAPI 3.1")
+			break
+		}
+		for _, t := range typ {
+			if name, _ := t.(string); !schemaTypes[name] && name != "null" {
+				v.addf(location, "invalid type %v", t)
+			}
+		}
+	default:
+		v.addf(location, "invalid type %v", typ)
+	}
+
+	if v.v31 {
+		if _, ok := schema["nullable"]; ok {
+			v.addf(location, "nullable is not supported in OpenAPI 3.1")
+		}
+	} else {
+		if schema["type"] == "array" && schema["items"] == nil {
+			v.addf(location, "array schema requires items")
+		}
+	}
+	for _, keyword := range []string{"exclusiveMinimum", "exclusiveMaximum"} {
+		value, ok := schema[keyword]
+		if !ok {
+			continue
+		}
+		if _, isBool := value.(bool); isBool == v.v31 {
+			v.addf(location, "%s has the wrong type for this OpenAPI version", keyword)
+		}
+	}
+	if required, ok := schema["required"]; ok {
+		list, ok := required.([]interface{})
+		for _, name := range list {
+			if _, isString := name.(string); !isString {
+				ok = false
+			}
+		}
+		if !ok {
+			v.addf(location+".required", "must be an array of strings")
+		}
+	}
+
+	subschemas(schema, func(keyword string, sub map[string]interface{}) map[string]interface{} {
+		v.validateSchema(location+"."+keyword, sub)
+		return sub
+	})
+}
+
+// sortedKeys returns the keys of m in order, so walks are deterministic
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
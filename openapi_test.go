@@ -0,0 +1,256 @@
+package httpc
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// duplicateIDService declares the same explicit operationId twice
+type duplicateIDService struct{}
+
+func (s duplicateIDService) First(input MultiInput) (MultiOutput, error) {
+	return MultiOutput{Result: input.Value}, nil
+}
+
+func (s duplicateIDService) Second(input MultiInput) (MultiOutput, error) {
+	return MultiOutput{Result: input.Value}, nil
+}
+
+func (s duplicateIDService) RegisterMethods() []MethodInfo {
+	return []MethodInfo{
+		{
+			Name:        "First",
+			HTTPMethod:  "POST",
+			InputType:   reflect.TypeOf(MultiInput{}),
+			OutputType:  reflect.TypeOf(MultiOutput{}),
+			Func:        reflect.ValueOf(s).MethodByName("First"),
+			OperationID: "process",
+		},
+		{
+			Name:        "Second",
+			HTTPMethod:  "POST",
+			InputType:   reflect.TypeOf(MultiInput{}),
+			OutputType:  reflect.TypeOf(MultiOutput{}),
+			Func:        reflect.ValueOf(s).MethodByName("Second"),
+			OperationID: "process",
+		},
+	}
+}
+
+// newDocServer creates a server with the given OpenAPI settings
+func newDocServer(t *testing.T, version, validation string) *Server {
+	c, err := config.New(config.WithDefault(map[string]interface{}{
+		"otel_enabled":       false,
+		"port":               8080,
+		"openapi_version":    version,
+		"openapi_validation": validation,
+	}))
+	require.NoError(t, err)
+	srv, err := NewServer(c)
+	require.NoError(t, err)
+	return srv
+}
+
+// fetchDocument decodes the JSON and YAML documents served by ts
+func fetchDocument(t *testing.T, ts *httptest.Server) (map[string]interface{}, map[string]interface{}) {
+	resp, err := http.Get(ts.URL + "/api/docs/swagger.json")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var jsonDoc map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&jsonDoc))
+
+	resp, err = http.Get(ts.URL + "/api/docs/openapi.yaml")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/yaml", resp.Header.Get("Content-Type"))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var yamlDoc map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(body, &yamlDoc))
+	return jsonDoc, yamlDoc
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	services := map[string]interface{}{
+		"/":        &TestService{},
+		"/invalid": &InvalidMethodService{},
+		"/multi":   &MultiMethodService{},
+		"/custom":  &CustomPathService{},
+		"/search":  &SearchService{},
+		"/v1":      &UserService{},
+		"/orders":  &OrderService{},
+		"/catalog": &CatalogService{},
+	}
+
+	for _, version := range []string{OpenAPIVersion30, OpenAPIVersion31} {
+		t.Run("Valid For Test Services "+version, func(t *testing.T) {
+			srv := newDocServer(t, version, DocValidationFail)
+			for prefix, svc := range services {
+				require.NoError(t, srv.RegisterService(svc, WithPathPrefix(prefix)), "service at %s", prefix)
+			}
+			require.Error(t, srv.RegisterService(&InvalidSigService{}, WithPathPrefix("/sig")))
+			ts := httptest.NewServer(srv.engine)
+			defer ts.Close()
+
+			jsonDoc, yamlDoc := fetchDocument(t, ts)
+			require.Equal(t, version, jsonDoc["openapi"])
+			require.NoError(t, validateOpenAPI(jsonDoc))
+			require.NoError(t, validateOpenAPI(yamlDoc))
+			require.Equal(t, version, yamlDoc["openapi"])
+			require.Len(t, yamlDoc["paths"], len(jsonDoc["paths"].(map[string]interface{})))
+		})
+	}
+
+	t.Run("OpenAPI 3.1 Schemas", func(t *testing.T) {
+		srv := newDocServer(t, OpenAPIVersion31, DocValidationFail)
+		require.NoError(t, srv.RegisterService(&CatalogService{}, WithPathPrefix("/catalog")))
+		ts := httptest.NewServer(srv.engine)
+		defer ts.Close()
+
+		doc, _ := fetchDocument(t, ts)
+		require.Equal(t, jsonSchemaDialect, doc["jsonSchemaDialect"])
+		book := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})["Book"].(map[string]interface{})
+		props := book["properties"].(map[string]interface{})
+		require.Equal(t, map[string]interface{}{
+			"anyOf":       []interface{}{map[string]interface{}{"$ref": "#/components/schemas/BookAuthor"}, map[string]interface{}{"type": "null"}},
+			"description": "Primary author",
+		}, props["author"])
+		require.Equal(t, []interface{}{"9780134190440"}, props["isbn"].(map[string]interface{})["examples"])
+
+		schema := convertSchema31(map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"status": map[string]interface{}{"type": "string", "enum": []interface{}{"a", "b"}, "nullable": true},
+				"rating": map[string]interface{}{"type": "number", "minimum": float64(0), "exclusiveMinimum": true, "maximum": float64(5), "exclusiveMaximum": false},
+			},
+		})
+		props = schema["properties"].(map[string]interface{})
+		require.Equal(t, map[string]interface{}{"type": []interface{}{"string", "null"}, "enum": []interface{}{"a", "b", nil}}, props["status"])
+		require.Equal(t, map[string]interface{}{"type": "number", "exclusiveMinimum": float64(0), "maximum": float64(5)}, props["rating"])
+	})
+
+	t.Run("Registration Check", func(t *testing.T) {
+		srv := newDocServer(t, OpenAPIVersion30, DocValidationFail)
+		err := srv.RegisterService(&duplicateIDService{}, WithPathPrefix("/dup"))
+		var docErr *DocumentError
+		require.True(t, errors.As(err, &docErr))
+		require.Equal(t, []string{`paths[/dup/Second].post: operationId "process" is already used by paths[/dup/First].post`}, docErr.Problems)
+
+		ts := httptest.NewServer(srv.engine)
+		defer ts.Close()
+		resp, err := http.Post(ts.URL+"/dup/First", MediaTypeJSON, nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode, "rejected services are not routed")
+		doc, _ := fetchDocument(t, ts)
+		require.Empty(t, doc["paths"])
+
+		srv = newDocServer(t, OpenAPIVersion30, DocValidationWarn)
+		require.NoError(t, srv.RegisterService(&duplicateIDService{}, WithPathPrefix("/dup")))
+	})
+
+	t.Run("Rejected Registration Leaves Document Unchanged", func(t *testing.T) {
+		srv := newDocServer(t, OpenAPIVersion30, DocValidationFail)
+		require.NoError(t, srv.RegisterService(&CatalogService{}, WithPathPrefix("/catalog")))
+		ts := httptest.NewServer(srv.engine)
+		defer ts.Close()
+		served := func() (string, string) {
+			_, jsonDoc := getBody(t, ts.URL+"/api/docs/swagger.json")
+			_, yamlDoc := getBody(t, ts.URL+"/api/docs/openapi.yaml")
+			return jsonDoc, yamlDoc
+		}
+		jsonBefore, yamlBefore := served()
+
+		require.Error(t, srv.RegisterService(&duplicateIDService{}, WithPathPrefix("/dup")))
+		jsonAfter, yamlAfter := served()
+		require.Equal(t, jsonBefore, jsonAfter)
+		require.Equal(t, yamlBefore, yamlAfter)
+		require.NotContains(t, srv.schemas.names, reflect.TypeOf(MultiInput{}), "names of rejected schemas are released")
+
+		// The schemas are registered again, under the same names, with a valid service
+		require.NoError(t, srv.RegisterService(&MultiMethodService{}, WithPathPrefix("/multi")))
+		doc, _ := fetchDocument(t, ts)
+		require.NoError(t, validateOpenAPI(doc))
+		require.Equal(t, "MultiInput", srv.schemas.names[reflect.TypeOf(MultiInput{})])
+		require.Contains(t, doc["components"].(map[string]interface{})["schemas"], "MultiInput")
+	})
+
+	t.Run("Default OperationId Collisions", func(t *testing.T) {
+		srv := newDocServer(t, OpenAPIVersion30, DocValidationFail)
+		require.NoError(t, srv.RegisterService(&TestService{}, WithPathPrefix("/v1")))
+		require.NoError(t, srv.RegisterService(&TestService{}, WithPathPrefix("/api/v2")))
+		paths := srv.swagger["paths"].(map[string]interface{})
+		operationID := func(path string) interface{} {
+			return paths[path].(map[string]interface{})["get"].(map[string]interface{})["operationId"]
+		}
+		require.Equal(t, "Hello", operationID("/v1/Hello"))
+		require.Equal(t, "api_v2_Hello", operationID("/api/v2/Hello"))
+	})
+
+	t.Run("Invalid Documents", func(t *testing.T) {
+		err := validateOpenAPI(map[string]interface{}{
+			"openapi": "3.0.3",
+			"info":    map[string]interface{}{"title": "x"},
+			"paths": map[string]interface{}{
+				"/items/{id}": map[string]interface{}{
+					"get": map[string]interface{}{
+						"parameters": []interface{}{
+							map[string]interface{}{"name": "q", "in": "body", "schema": map[string]interface{}{"type": "string"}},
+						},
+						"responses": map[string]interface{}{
+							"200": map[string]interface{}{
+								"content": map[string]interface{}{
+									MediaTypeJSON: map[string]interface{}{
+										"schema": map[string]interface{}{"type": []interface{}{"string", "null"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"components": map[string]interface{}{
+				"schemas": map[string]interface{}{
+					"Item": map[string]interface{}{
+						"type":       "object",
+						"properties": map[string]interface{}{"next": map[string]interface{}{"$ref": "#/components/schemas/Missing", "description": "x"}},
+					},
+				},
+			},
+		})
+		var docErr *DocumentError
+		require.True(t, errors.As(err, &docErr))
+		require.Equal(t, []string{
+			"info.version: must be a non-empty string",
+			`paths[/items/{id}].get.parameters[0]: invalid location "body"`,
+			`paths[/items/{id}].get: path template parameter "id" is not declared`,
+			"paths[/items/{id}].get.responses.200: missing description",
+			`components.schemas.Item.properties.next: unresolved reference "#/components/schemas/Missing"`,
+			"components.schemas.Item.properties.next: $ref must not have sibling keywords in OpenAPI 3.0",
+			"paths[/items/{id}].get.responses.200.content[application/json].schema: type arrays require OpenAPI 3.1",
+		}, docErr.Problems)
+	})
+
+	t.Run("Unsupported Settings", func(t *testing.T) {
+		c, err := config.New(config.WithDefault(map[string]interface{}{"port": 8080, "openapi_version": "2.0"}))
+		require.NoError(t, err)
+		_, err = NewServer(c)
+		require.EqualError(t, err, `unsupported openapi_version "2.0"`)
+	})
+}
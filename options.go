@@ -0,0 +1,164 @@
+package httpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CallOption customises a single HTTPClient.Call on top of ClientConfig
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	header           http.Header
+	query            url.Values
+	timeout          time.Duration
+	maxRetries       int
+	expectedStatus   []int
+	contentType      string
+	accept           []string
+	maxResponseBytes int64
+	attempts         int  // attempts made so far, reported in Response
+	stream           bool // the response is read as a stream, see HTTPClient.Stream
+	keepBody         bool // the raw body of a decoded response is kept in Response
+	cached           bool // the response came from the client cache, reported in Response
+}
+
+// newCallOptions returns the client defaults with opts applied
+func (h *HTTPClient) newCallOptions(opts []CallOption) *callOptions {
+	co := &callOptions{
+		header:           http.Header{},
+		query:            url.Values{},
+		timeout:          time.Duration(h.config.TimeoutMs) * time.Millisecond,
+		maxRetries:       h.config.MaxRetries,
+		maxResponseBytes: h.config.MaxResponseBytes,
+	}
+	for _, opt := range opts {
+		opt(co)
+	}
+	return co
+}
+
+// WithHeader sets a request header for this call, replacing any existing value
+func WithHeader(key, value string) CallOption {
+	return func(co *callOptions) {
+		co.header.Set(key, value)
+	}
+}
+
+// WithQuery merges query parameters into the request URL. Values are escaped
+// and appended to any parameters already present in the URL.
+func WithQuery(values url.Values) CallOption {
+	return func(co *callOptions) {
+		for key, vals := range values {
+			for _, v := range vals {
+				co.query.Add(key, v)
+			}
+		}
+	}
+}
+
+// WithTimeout overrides http_client_timeout_ms for each attempt of this call
+func WithTimeout(timeout time.Duration) CallOption {
+	return func(co *callOptions) {
+		if timeout > 0 {
+			co.timeout = timeout
+		}
+	}
+}
+
+// WithMaxRetries overrides http_client_max_retries for this call
+func WithMaxRetries(n int) CallOption {
+	return func(co *callOptions) {
+		if n < 0 {
+			n = 0
+		}
+		co.maxRetries = n
+	}
+}
+
+// WithoutRetry sends this call exactly once
+func WithoutRetry() CallOption {
+	return WithMaxRetries(0)
+}
+
+// WithExpectedStatus treats only the given status codes as success for this
+// call, instead of any 2xx. An expected status is never retried.
+func WithExpectedStatus(codes ...int) CallOption {
+	return func(co *callOptions) {
+		co.expectedStatus = append(co.expectedStatus, codes...)
+	}
+}
+
+// WithContentType encodes the request body with the codec registered for
+// mediaType instead of JSON
+func WithContentType(mediaType string) CallOption {
+	return func(co *callOptions) {
+		co.contentType = mediaType
+	}
+}
+
+// WithAccept sets the media types accepted for the response. By default the
+// request's content type is accepted.
+func WithAccept(mediaTypes ...string) CallOption {
+	return func(co *callOptions) {
+		co.accept = append(co.accept, mediaTypes...)
+	}
+}
+
+// WithMaxResponseBytes overrides http_client_max_response_bytes for this
+// call; 0 removes the limit
+func WithMaxResponseBytes(n int64) CallOption {
+	return func(co *callOptions) {
+		if n >= 0 {
+			co.maxResponseBytes = n
+		}
+	}
+}
+
+// apply adds the call headers and query parameters to req
+func (co *callOptions) apply(req *http.Request) {
+	if len(co.accept) > 0 {
+		req.Header.Set("Accept", strings.Join(co.accept, ", "))
+	}
+	for key, vals := range co.header {
+		req.Header[key] = vals
+	}
+	if len(co.query) > 0 {
+		q := req.URL.Query()
+		for key, vals := range co.query {
+			for _, v := range vals {
+				q.Add(key, v)
+			}
+		}
+		req.URL.RawQuery = q.Encode()
+	}
+}
+
+// isExpected reports whether status counts as a successful response
+func (co *callOptions) isExpected(status int) bool {
+	if len(co.expectedStatus) == 0 {
+		return status >= 200 && status < 300
+	}
+	for _, code := range co.expectedStatus {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// cancelOnClose releases an attempt's timeout context once its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
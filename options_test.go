@@ -0,0 +1,141 @@
+package httpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallOptions(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	serverCfg := ServerConfig{
+		OtelEnabled: false,
+		Port:        8080,
+	}
+	clientCfg, err := config.New(config.WithDefault(map[string]interface{}{
+		"otel_enabled":                false,
+		"http_client_timeout_ms":      1000,
+		"http_client_max_retries":     2,
+		"http_client_disable_backoff": true,
+	}))
+	require.NoError(t, err)
+
+	t.Run("Query Parameters", func(t *testing.T) {
+		svc := &TestService{}
+		ts := setupServer(t, serverCfg, svc, "/v1")
+		defer ts.Close()
+
+		client, err := NewHTTPClient(clientCfg)
+		require.NoError(t, err)
+
+		var result string
+		err = client.Call("GET", ts.URL+"/v1/Hello", nil, &result, WithQuery(url.Values{"name": {"A&B World"}}))
+		require.NoError(t, err)
+		require.Equal(t, "Hello, A&B World!", result)
+	})
+
+	t.Run("Query Merged With URL", func(t *testing.T) {
+		var gotQuery url.Values
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotQuery = r.URL.Query()
+			w.Write([]byte(`"ok"`))
+		}))
+		defer ts.Close()
+
+		client, err := NewHTTPClient(clientCfg)
+		require.NoError(t, err)
+
+		err = client.Call("GET", ts.URL+"?page=1", nil, nil, WithQuery(url.Values{"tag": {"a", "b"}}))
+		require.NoError(t, err)
+		require.Equal(t, "1", gotQuery.Get("page"))
+		require.Equal(t, []string{"a", "b"}, gotQuery["tag"])
+	})
+
+	t.Run("Headers", func(t *testing.T) {
+		var gotTenant, gotRequestID string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotTenant = r.Header.Get("X-Tenant")
+			gotRequestID = r.Header.Get("X-Request-ID")
+		}))
+		defer ts.Close()
+
+		client, err := NewHTTPClient(clientCfg)
+		require.NoError(t, err)
+
+		err = client.Call("GET", ts.URL, nil, nil, WithHeader("X-Tenant", "acme"), WithHeader("X-Request-ID", "fixed-id"))
+		require.NoError(t, err)
+		require.Equal(t, "acme", gotTenant)
+		require.Equal(t, "fixed-id", gotRequestID)
+	})
+
+	t.Run("Retry Overrides", func(t *testing.T) {
+		var hits int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer ts.Close()
+
+		client, err := NewHTTPClient(clientCfg)
+		require.NoError(t, err)
+
+		require.Error(t, client.Call("GET", ts.URL, nil, nil, WithoutRetry()))
+		require.Equal(t, int32(1), atomic.SwapInt32(&hits, 0))
+
+		require.Error(t, client.Call("GET", ts.URL, nil, nil, WithMaxRetries(4)))
+		require.Equal(t, int32(5), atomic.SwapInt32(&hits, 0))
+
+		require.Error(t, client.Call("GET", ts.URL, nil, nil))
+		require.Equal(t, int32(3), atomic.SwapInt32(&hits, 0))
+	})
+
+	t.Run("Timeout Override", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte(`"slow"`))
+		}))
+		defer ts.Close()
+
+		client, err := NewHTTPClient(clientCfg)
+		require.NoError(t, err)
+
+		err = client.Call("GET", ts.URL, nil, nil, WithTimeout(50*time.Millisecond), WithoutRetry())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "context deadline exceeded")
+
+		var result string
+		require.NoError(t, client.Call("GET", ts.URL, nil, &result))
+		require.Equal(t, "slow", result)
+	})
+
+	t.Run("Expected Status", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`"created"`))
+		}))
+		defer ts.Close()
+
+		client, err := NewHTTPClient(clientCfg)
+		require.NoError(t, err)
+
+		var result string
+		require.NoError(t, client.Call("POST", ts.URL, nil, &result, WithExpectedStatus(http.StatusCreated)))
+		require.Equal(t, "created", result)
+
+		err = client.Call("POST", ts.URL, nil, &result, WithExpectedStatus(http.StatusOK))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "request failed with status 201")
+	})
+}
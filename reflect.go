@@ -2,6 +2,7 @@ package httpc
 
 import (
 	"fmt"
+	"net/http"
 	"reflect"
 
 	logger "github.com/T-Prohmpossadhorn/go-core-logger"
@@ -39,6 +40,13 @@ func getServiceInfo(service interface{}) ([]MethodInfo, error) {
 		return nil, fmt.Errorf("RegisterMethods did not return []MethodInfo")
 	}
 
+	// WebSocket methods are always served on GET
+	for i := range methods {
+		if methods[i].Kind == MethodWebSocket && methods[i].HTTPMethod == "" {
+			methods[i].HTTPMethod = http.MethodGet
+		}
+	}
+
 	// Validate methods
 	for _, method := range methods {
 		if method.Name == "" || method.HTTPMethod == "" {
@@ -49,8 +57,16 @@ func getServiceInfo(service interface{}) ([]MethodInfo, error) {
 		if !ok {
 			return nil, fmt.Errorf("method %s not found", method.Name)
 		}
-		if meth.Type.NumIn() != 2 || meth.Type.NumOut() != 2 ||
-			meth.Type.Out(1) != reflect.TypeOf((*error)(nil)).Elem() {
+		if method.Kind == MethodWebSocket {
+			if err := checkWebSocketSignature(method, meth.Type); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// The input may follow a context.Context, canceled when the client goes away
+		numIn := meth.Type.NumIn()
+		validIn := numIn == 2 || numIn == 3 && meth.Type.In(1) == contextType
+		if !validIn || meth.Type.NumOut() != 2 || meth.Type.Out(1) != errorType {
 			return nil, fmt.Errorf("invalid signature for method %s", method.Name)
 		}
 		// Set Func field
@@ -0,0 +1,359 @@
+package httpc
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Resolver maps a logical service name to the base URLs of its endpoints,
+// e.g. "http://10.0.0.1:8080"
+type Resolver interface {
+	Resolve(ctx context.Context, service string) ([]string, error)
+}
+
+// LoadBalancer picks one endpoint per attempt. tried holds endpoints already
+// used by earlier attempts of the same call, which should be avoided when
+// possible. The returned release func is called once the attempt completes.
+type LoadBalancer interface {
+	Pick(endpoints []string, tried map[string]bool) (endpoint string, release func())
+}
+
+// serviceRoute binds a resolver and load balancer to a logical service name
+type serviceRoute struct {
+	resolver Resolver
+	balancer LoadBalancer
+}
+
+// WithBaseURL sets the base URL that relative Call URLs are resolved against,
+// overriding http_client_base_url
+func WithBaseURL(baseURL string) ClientOption {
+	return func(h *HTTPClient) {
+		h.config.BaseURL = baseURL
+	}
+}
+
+// WithResolver routes requests whose host is the logical name service to the
+// endpoints returned by r. A nil balancer defaults to round-robin.
+func WithResolver(service string, r Resolver, lb LoadBalancer) ClientOption {
+	return func(h *HTTPClient) {
+		if lb == nil {
+			lb = NewRoundRobinBalancer()
+		}
+		if h.routes == nil {
+			h.routes = map[string]*serviceRoute{}
+		}
+		h.routes[service] = &serviceRoute{resolver: r, balancer: lb}
+	}
+}
+
+// resolveURL joins a relative rawURL onto the configured base URL
+func (h *HTTPClient) resolveURL(rawURL string) (string, error) {
+	if h.config.BaseURL == "" {
+		return rawURL, nil
+	}
+	ref, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	if ref.IsAbs() {
+		return rawURL, nil
+	}
+	base, err := url.Parse(h.config.BaseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %w", h.config.BaseURL, err)
+	}
+	joined := *base
+	if err := setEscapedPath(&joined, strings.TrimSuffix(base.EscapedPath(), "/")+"/"+strings.TrimPrefix(ref.EscapedPath(), "/")); err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	joined.RawQuery = ref.RawQuery
+	joined.Fragment = ref.Fragment
+	return joined.String(), nil
+}
+
+// setEscapedPath sets both the decoded and the raw path of u from escaped, so
+// escaped segments such as %2F survive being joined onto another path
+func setEscapedPath(u *url.URL, escaped string) error {
+	path, err := url.PathUnescape(escaped)
+	if err != nil {
+		return err
+	}
+	u.Path = path
+	u.RawPath = escaped
+	return nil
+}
+
+// pickEndpoint rewrites req to target an endpoint of its logical service,
+// preferring endpoints not yet in tried. Requests to hosts without a
+// registered resolver are left untouched.
+func (h *HTTPClient) pickEndpoint(req *http.Request, service string, tried map[string]bool) (func(), error) {
+	route, ok := h.routes[service]
+	if !ok {
+		return func() {}, nil
+	}
+	endpoints, err := route.resolver.Resolve(req.Context(), service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve service %s: %w", service, err)
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("failed to resolve service %s: no endpoints available", service)
+	}
+
+	endpoint, release := route.balancer.Pick(endpoints, tried)
+	target, err := url.Parse(endpoint)
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("invalid endpoint %q for service %s: %w", endpoint, service, err)
+	}
+	tried[endpoint] = true
+
+	req.URL.Scheme = target.Scheme
+	req.URL.Host = target.Host
+	if err := setEscapedPath(req.URL, strings.TrimSuffix(target.EscapedPath(), "/")+req.URL.EscapedPath()); err != nil {
+		release()
+		return nil, fmt.Errorf("invalid endpoint %q for service %s: %w", endpoint, service, err)
+	}
+	req.Host = target.Host
+	logger.InfoContext(req.Context(), "Resolved service endpoint", logger.String("service", service), logger.String("endpoint", endpoint))
+	return release, nil
+}
+
+// untried returns the endpoints not in tried, or all of them if every endpoint has been tried
+func untried(endpoints []string, tried map[string]bool) []string {
+	var candidates []string
+	for _, e := range endpoints {
+		if !tried[e] {
+			candidates = append(candidates, e)
+		}
+	}
+	if len(candidates) == 0 {
+		return endpoints
+	}
+	return candidates
+}
+
+type roundRobinBalancer struct {
+	next uint64
+}
+
+// NewRoundRobinBalancer cycles through endpoints in order
+func NewRoundRobinBalancer() LoadBalancer {
+	return &roundRobinBalancer{}
+}
+
+func (b *roundRobinBalancer) Pick(endpoints []string, tried map[string]bool) (string, func()) {
+	candidates := untried(endpoints, tried)
+	n := atomic.AddUint64(&b.next, 1) - 1
+	return candidates[n%uint64(len(candidates))], func() {}
+}
+
+type leastOutstandingBalancer struct {
+	mu       sync.Mutex
+	inFlight map[string]int
+}
+
+// NewLeastOutstandingBalancer picks the endpoint with the fewest requests in flight
+func NewLeastOutstandingBalancer() LoadBalancer {
+	return &leastOutstandingBalancer{inFlight: map[string]int{}}
+}
+
+func (b *leastOutstandingBalancer) Pick(endpoints []string, tried map[string]bool) (string, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	candidates := untried(endpoints, tried)
+	best := candidates[0]
+	for _, e := range candidates[1:] {
+		if b.inFlight[e] < b.inFlight[best] {
+			best = e
+		}
+	}
+	b.inFlight[best]++
+
+	var once sync.Once
+	return best, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.inFlight[best]--
+		})
+	}
+}
+
+type staticResolver struct {
+	endpoints []string
+}
+
+// NewStaticResolver always resolves to the given endpoints
+func NewStaticResolver(endpoints ...string) Resolver {
+	return &staticResolver{endpoints: endpoints}
+}
+
+func (r *staticResolver) Resolve(ctx context.Context, service string) ([]string, error) {
+	return r.endpoints, nil
+}
+
+// DNSSRVConfig configures a resolver of DNS SRV records
+type DNSSRVConfig struct {
+	Scheme string // Scheme of the returned endpoints, "http" or "https", defaults to "http"
+
+	// Domain the _<service>._tcp.<Domain> records are looked up in. Without
+	// it, _<service>._tcp is looked up relative to the system's search domains.
+	Domain string
+
+	// Name is the full SRV name to look up, such as
+	// "_users._tcp.prod.example.com", for every service. It overrides Domain.
+	Name string
+
+	Resolver *net.Resolver // Defaults to net.DefaultResolver
+}
+
+type dnsSRVResolver struct {
+	cfg DNSSRVConfig
+}
+
+// NewDNSSRVResolver resolves _<service>._tcp SRV records in the system's
+// search domains, using scheme ("http" or "https") for the returned endpoints
+func NewDNSSRVResolver(scheme string) Resolver {
+	return NewDNSSRVResolverWithConfig(DNSSRVConfig{Scheme: scheme})
+}
+
+// NewDNSSRVResolverWithConfig resolves the SRV records named by cfg
+func NewDNSSRVResolverWithConfig(cfg DNSSRVConfig) Resolver {
+	if cfg.Scheme == "" {
+		cfg.Scheme = "http"
+	}
+	if cfg.Resolver == nil {
+		cfg.Resolver = net.DefaultResolver
+	}
+	return &dnsSRVResolver{cfg: cfg}
+}
+
+// name returns the SRV name looked up for service
+func (r *dnsSRVResolver) name(service string) string {
+	if r.cfg.Name != "" {
+		return r.cfg.Name
+	}
+	name := "_" + service + "._tcp"
+	if r.cfg.Domain != "" {
+		name += "." + strings.TrimSuffix(r.cfg.Domain, ".") + "."
+	}
+	return name
+}
+
+func (r *dnsSRVResolver) Resolve(ctx context.Context, service string) ([]string, error) {
+	// With an empty service and proto, LookupSRV looks up name as given
+	_, records, err := r.cfg.Resolver.LookupSRV(ctx, "", "", r.name(service))
+	if err != nil {
+		return nil, err
+	}
+	endpoints := make([]string, 0, len(records))
+	for _, rec := range records {
+		host := strings.TrimSuffix(rec.Target, ".")
+		endpoints = append(endpoints, fmt.Sprintf("%s://%s", r.cfg.Scheme, net.JoinHostPort(host, fmt.Sprint(rec.Port))))
+	}
+	return endpoints, nil
+}
+
+// FileResolver serves endpoints listed one per line in a file, reloading
+// the list whenever the file changes. Blank lines and lines starting with
+// '#' are ignored.
+type FileResolver struct {
+	path      string
+	mu        sync.RWMutex
+	endpoints []string
+	watcher   *fsnotify.Watcher
+}
+
+// NewFileResolver loads path and starts watching it for changes
+func NewFileResolver(path string) (*FileResolver, error) {
+	r := &FileResolver{path: path}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch endpoint file: %w", err)
+	}
+	// Watch the directory so that atomic renames over the file are seen
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch endpoint file: %w", err)
+	}
+	r.watcher = watcher
+	go r.watch()
+	return r, nil
+}
+
+func (r *FileResolver) Resolve(ctx context.Context, service string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.endpoints, nil
+}
+
+// Close stops watching the endpoint file
+func (r *FileResolver) Close() error {
+	return r.watcher.Close()
+}
+
+func (r *FileResolver) watch() {
+	for {
+		select {
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(r.path) || event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			if err := r.load(); err != nil {
+				logger.Error("Failed to reload endpoint file", logger.String("path", r.path), logger.ErrField(err))
+			}
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Error("Endpoint file watcher error", logger.ErrField(err))
+		}
+	}
+}
+
+func (r *FileResolver) load() error {
+	f, err := os.Open(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to read endpoint file: %w", err)
+	}
+	defer f.Close()
+
+	var endpoints []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		endpoints = append(endpoints, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read endpoint file: %w", err)
+	}
+
+	r.mu.Lock()
+	r.endpoints = endpoints
+	r.mu.Unlock()
+	logger.Info("Loaded service endpoints", logger.String("path", r.path), logger.Int("count", len(endpoints)))
+	return nil
+}
@@ -0,0 +1,262 @@
+package httpc
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// stubDNS returns a resolver answering SRV queries from records, by name,
+// over in-memory connections. asked receives the name of every query.
+func stubDNS(t *testing.T, records map[string][]net.SRV, asked chan<- string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go serveDNS(t, server, records, asked)
+			return client, nil
+		},
+	}
+}
+
+// serveDNS answers length-prefixed DNS queries on conn until it is closed
+func serveDNS(t *testing.T, conn net.Conn, records map[string][]net.SRV, asked chan<- string) {
+	defer conn.Close()
+	for {
+		var size uint16
+		if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+			return
+		}
+		query := make([]byte, size)
+		if _, err := io.ReadFull(conn, query); err != nil {
+			return
+		}
+		var msg dnsmessage.Message
+		if err := msg.Unpack(query); err != nil || len(msg.Questions) != 1 {
+			t.Errorf("invalid DNS query: %v", err)
+			return
+		}
+		question := msg.Questions[0]
+		if question.Type == dnsmessage.TypeSRV {
+			asked <- question.Name.String()
+		}
+		msg.Header.Response = true
+		msg.Header.Authoritative = true
+		msg.Header.RCode = dnsmessage.RCodeNameError
+		for _, srv := range records[question.Name.String()] {
+			if question.Type != dnsmessage.TypeSRV {
+				break
+			}
+			msg.Header.RCode = dnsmessage.RCodeSuccess
+			msg.Answers = append(msg.Answers, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeSRV, Class: dnsmessage.ClassINET, TTL: 60},
+				Body: &dnsmessage.SRVResource{
+					Priority: srv.Priority, Weight: srv.Weight, Port: srv.Port,
+					Target: dnsmessage.MustNewName(srv.Target),
+				},
+			})
+		}
+		answer, err := msg.Pack()
+		if err != nil {
+			t.Errorf("failed to pack DNS answer: %v", err)
+			return
+		}
+		if err := binary.Write(conn, binary.BigEndian, uint16(len(answer))); err != nil {
+			return
+		}
+		if _, err := conn.Write(answer); err != nil {
+			return
+		}
+	}
+}
+
+func TestResolver(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	serverCfg := ServerConfig{
+		OtelEnabled: false,
+		Port:        8080,
+	}
+	clientCfgMap := map[string]interface{}{
+		"otel_enabled":                false,
+		"http_client_timeout_ms":      1000,
+		"http_client_max_retries":     2,
+		"http_client_disable_backoff": true,
+	}
+
+	t.Run("Base URL", func(t *testing.T) {
+		svc := &TestService{}
+		ts := setupServer(t, serverCfg, svc, "/v1")
+		defer ts.Close()
+
+		cfgMap := map[string]interface{}{"http_client_base_url": ts.URL + "/v1"}
+		for k, v := range clientCfgMap {
+			cfgMap[k] = v
+		}
+		cfg, err := config.New(config.WithDefault(cfgMap))
+		require.NoError(t, err)
+
+		client, err := NewHTTPClient(cfg)
+		require.NoError(t, err)
+
+		var result string
+		require.NoError(t, client.Call("GET", "/Hello?name=Base", nil, &result))
+		require.Equal(t, "Hello, Base!", result)
+
+		// Absolute URLs bypass the base URL
+		require.NoError(t, client.Call("GET", ts.URL+"/v1/Hello?name=Abs", nil, &result))
+		require.Equal(t, "Hello, Abs!", result)
+	})
+
+	t.Run("Retry Prefers Different Endpoint", func(t *testing.T) {
+		var badHits, goodHits int32
+		bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&badHits, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer bad.Close()
+		good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&goodHits, 1)
+			w.Write([]byte(`"` + r.URL.Path + `"`))
+		}))
+		defer good.Close()
+
+		cfg, err := config.New(config.WithDefault(clientCfgMap))
+		require.NoError(t, err)
+		client, err := NewHTTPClient(cfg,
+			WithBaseURL("http://users/api"),
+			WithResolver("users", NewStaticResolver(bad.URL, good.URL), NewLeastOutstandingBalancer()),
+		)
+		require.NoError(t, err)
+
+		for i := 0; i < 4; i++ {
+			var result string
+			// A single retry only succeeds if it avoids the endpoint that failed
+			require.NoError(t, client.Call("GET", "/v1/Get", nil, &result, WithMaxRetries(1)))
+			require.Equal(t, "/api/v1/Get", result)
+		}
+		require.Equal(t, int32(4), atomic.LoadInt32(&goodHits))
+		require.Equal(t, int32(4), atomic.LoadInt32(&badHits))
+	})
+
+	t.Run("Escaped Path Segments", func(t *testing.T) {
+		seen := make(chan string, 2)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen <- r.RequestURI
+			w.Write([]byte(`"ok"`))
+		}))
+		defer ts.Close()
+
+		cfg, err := config.New(config.WithDefault(clientCfgMap))
+		require.NoError(t, err)
+		client, err := NewHTTPClient(cfg,
+			WithBaseURL("http://files/api%2Fv1"),
+			WithResolver("files", NewStaticResolver(ts.URL+"/root"), nil),
+		)
+		require.NoError(t, err)
+
+		// An escaped slash stays a single segment through the base URL and the endpoint path
+		var result string
+		require.NoError(t, client.Call("GET", "/docs/a%2Fb?v=1", nil, &result))
+		require.Equal(t, "/root/api%2Fv1/docs/a%2Fb?v=1", <-seen)
+
+		require.NoError(t, client.Call("GET", "http://files/plain/path", nil, &result))
+		require.Equal(t, "/root/plain/path", <-seen)
+	})
+
+	t.Run("Unresolvable Service", func(t *testing.T) {
+		cfg, err := config.New(config.WithDefault(clientCfgMap))
+		require.NoError(t, err)
+		client, err := NewHTTPClient(cfg, WithResolver("empty", NewStaticResolver(), nil))
+		require.NoError(t, err)
+
+		err = client.Call("GET", "http://empty/v1/Get", nil, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to resolve service empty")
+	})
+
+	t.Run("Least Outstanding Balancer", func(t *testing.T) {
+		lb := NewLeastOutstandingBalancer()
+		endpoints := []string{"http://a", "http://b"}
+
+		first, releaseFirst := lb.Pick(endpoints, map[string]bool{})
+		second, releaseSecond := lb.Pick(endpoints, map[string]bool{})
+		require.NotEqual(t, first, second)
+
+		releaseFirst()
+		third, releaseThird := lb.Pick(endpoints, map[string]bool{})
+		require.Equal(t, first, third)
+		releaseSecond()
+		releaseThird()
+
+		picked, release := lb.Pick(endpoints, map[string]bool{"http://a": true})
+		require.Equal(t, "http://b", picked)
+		release()
+	})
+
+	t.Run("DNS SRV Resolver", func(t *testing.T) {
+		records := map[string][]net.SRV{
+			"_users._tcp.example.com.": {{Target: "a.example.com.", Port: 8080, Priority: 10}},
+			"_api._tcp.prod.example.net.": {
+				{Target: "b.example.net.", Port: 9443, Priority: 10},
+				{Target: "c.example.net.", Port: 9443, Priority: 20},
+			},
+		}
+		asked := make(chan string, 10)
+		dns := stubDNS(t, records, asked)
+
+		r := NewDNSSRVResolverWithConfig(DNSSRVConfig{Domain: "example.com", Resolver: dns})
+		endpoints, err := r.Resolve(context.Background(), "users")
+		require.NoError(t, err)
+		require.Equal(t, []string{"http://a.example.com:8080"}, endpoints)
+		require.Equal(t, "_users._tcp.example.com.", <-asked)
+
+		// A full name is looked up for every service
+		r = NewDNSSRVResolverWithConfig(DNSSRVConfig{Scheme: "https", Name: "_api._tcp.prod.example.net.", Resolver: dns})
+		endpoints, err = r.Resolve(context.Background(), "billing")
+		require.NoError(t, err)
+		require.Equal(t, []string{"https://b.example.net:9443", "https://c.example.net:9443"}, endpoints)
+		require.Equal(t, "_api._tcp.prod.example.net.", <-asked)
+
+		_, err = NewDNSSRVResolverWithConfig(DNSSRVConfig{Domain: "example.org", Resolver: dns}).Resolve(context.Background(), "users")
+		require.Error(t, err)
+
+		// Without a domain, the name is relative to the search domains
+		require.Equal(t, "_users._tcp", NewDNSSRVResolver("http").(*dnsSRVResolver).name("users"))
+	})
+
+	t.Run("File Resolver Reload", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "endpoints.txt")
+		require.NoError(t, os.WriteFile(path, []byte("# replicas\nhttp://a:8080\n\nhttp://b:8080\n"), 0o644))
+
+		r, err := NewFileResolver(path)
+		require.NoError(t, err)
+		defer r.Close()
+
+		endpoints, err := r.Resolve(context.Background(), "users")
+		require.NoError(t, err)
+		require.Equal(t, []string{"http://a:8080", "http://b:8080"}, endpoints)
+
+		require.NoError(t, os.WriteFile(path, []byte("http://c:8080\n"), 0o644))
+		require.Eventually(t, func() bool {
+			endpoints, _ := r.Resolve(context.Background(), "users")
+			return len(endpoints) == 1 && endpoints[0] == "http://c:8080"
+		}, 2*time.Second, 20*time.Millisecond)
+	})
+}
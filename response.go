@@ -0,0 +1,200 @@
+package httpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// defaultMaxResponseBytes is the default of http_client_max_response_bytes
+const defaultMaxResponseBytes = 10 << 20
+
+// ResponseTooLargeError reports a response body over the size limit of the
+// client, set by http_client_max_response_bytes or WithMaxResponseBytes
+type ResponseTooLargeError struct {
+	StatusCode int
+	Limit      int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response body with status %d exceeds the limit of %d bytes", e.StatusCode, e.Limit)
+}
+
+// limitedBody reads a response body, failing with a ResponseTooLargeError
+// once more than limit bytes were read
+type limitedBody struct {
+	r     io.Reader
+	read  int64
+	limit int64
+	err   *ResponseTooLargeError
+}
+
+// limitBody returns the body of resp limited to limit bytes, or unlimited if
+// limit is 0. A body whose declared length is over the limit fails on the
+// first Read, without being read.
+func limitBody(resp *http.Response, limit int64) io.Reader {
+	if limit <= 0 {
+		return resp.Body
+	}
+	l := &limitedBody{
+		r:     io.LimitReader(resp.Body, limit+1),
+		limit: limit,
+		err:   &ResponseTooLargeError{StatusCode: resp.StatusCode, Limit: limit},
+	}
+	if resp.ContentLength > limit {
+		l.read = resp.ContentLength
+	}
+	return l
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.read > l.limit {
+		return 0, l.err
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return n - int(l.read-l.limit), l.err
+	}
+	return n, err
+}
+
+// errorBodyLimit returns the number of bytes read from an error response
+func (co *callOptions) errorBodyLimit() int64 {
+	if co.maxResponseBytes <= 0 {
+		return math.MaxInt64
+	}
+	return co.maxResponseBytes
+}
+
+// decodeResponse decodes the body of a successful response into output. JSON
+// is decoded as it is read, and only copied to response.Body when the call
+// keeps the body; other codecs get the whole body. An empty body leaves
+// output untouched.
+func (h *HTTPClient) decodeResponse(resp *http.Response, response *Response, output interface{}, co *callOptions) error {
+	body := limitBody(resp, co.maxResponseBytes)
+	respCodec, ok := CodecFor(resp.Header.Get("Content-Type"))
+	if !ok {
+		respCodec = jsonCodec{}
+	}
+	if _, ok := respCodec.(jsonCodec); ok {
+		var raw bytes.Buffer
+		if co.keepBody {
+			body = io.TeeReader(body, &raw)
+		}
+		err := json.NewDecoder(body).Decode(output)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+		if co.keepBody {
+			if _, err := io.Copy(io.Discard, body); err != nil {
+				return fmt.Errorf("failed to read response body: %w", err)
+			}
+			response.Body = raw.Bytes()
+		}
+		return nil
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	response.Body = data
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if err := respCodec.Unmarshal(data, output); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// CallBody sends input like Call and returns the body of a successful
+// response unread, for the caller to stream and close, with the response
+// metadata. The call timeout bounds the wait for the response headers only,
+// and the size limit of the client does not apply.
+func (h *HTTPClient) CallBody(ctx context.Context, method, url string, input interface{}, opts ...CallOption) (io.ReadCloser, *Response, error) {
+	co := h.newCallOptions(opts)
+	co.stream = true
+	method = strings.ToUpper(method)
+	if !isValidHTTPMethod(method) {
+		return nil, nil, fmt.Errorf("invalid HTTP method: %s", method)
+	}
+	if err := h.checkContract(ContractRequest, method, input); err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := h.send(ctx, method, url, input, co)
+	if err != nil {
+		return nil, nil, err
+	}
+	response := &Response{StatusCode: resp.StatusCode, Header: resp.Header, Attempts: co.attempts}
+	if !co.isExpected(resp.StatusCode) {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, co.errorBodyLimit()))
+		response.Body = body
+		return nil, response, newResponseError(resp.StatusCode, body)
+	}
+	return resp.Body, response, nil
+}
+
+// DecodeArray iterates over the elements of the JSON array read from body,
+// decoding one element at a time, and closes body when the loop ends. An
+// error ends the iteration.
+func DecodeArray[T any](body io.ReadCloser) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		defer body.Close()
+		var zero T
+		dec := json.NewDecoder(body)
+		token, err := dec.Token()
+		if err != nil {
+			yield(zero, fmt.Errorf("failed to read JSON array: %w", err))
+			return
+		}
+		if token == nil {
+			return // null, as encoding/json writes a nil slice
+		}
+		if token != json.Delim('[') {
+			yield(zero, fmt.Errorf("expected a JSON array, got %v", token))
+			return
+		}
+		for dec.More() {
+			var v T
+			if err := dec.Decode(&v); err != nil {
+				yield(zero, fmt.Errorf("failed to decode array element: %w", err))
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			yield(zero, fmt.Errorf("failed to read JSON array: %w", err))
+		}
+	}
+}
+
+// CallArray sends input like Call and iterates over the elements of the JSON
+// array in the response as they arrive, see CallBody and DecodeArray
+func CallArray[T any](ctx context.Context, h *HTTPClient, method, url string, input interface{}, opts ...CallOption) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		body, _, err := h.CallBody(ctx, method, url, input, opts...)
+		if err != nil {
+			var zero T
+			yield(zero, err)
+			return
+		}
+		for v, err := range DecodeArray[T](body) {
+			if !yield(v, err) {
+				return
+			}
+		}
+	}
+}
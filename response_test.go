@@ -0,0 +1,207 @@
+package httpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/stretchr/testify/require"
+)
+
+// newLimitedClient creates a client reading at most maxBytes of responses
+func newLimitedClient(t *testing.T, maxBytes int) *HTTPClient {
+	c, err := config.New(config.WithDefault(map[string]interface{}{
+		"otel_enabled":                   false,
+		"http_client_max_retries":        0,
+		"http_client_max_response_bytes": maxBytes,
+	}))
+	require.NoError(t, err)
+	client, err := NewHTTPClient(c)
+	require.NoError(t, err)
+	return client
+}
+
+// itemsServer serves a JSON array of count items, streamed without a
+// Content-Length when chunked is set, and a large error at /fail
+func itemsServer(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprintf(w, `{"error":"upstream down","detail":"%s"}`, strings.Repeat("x", 4096))
+			return
+		}
+		w.Header().Set("Content-Type", MediaTypeJSON)
+		count := 0
+		fmt.Sscan(r.URL.Query().Get("count"), &count)
+		if count < 0 {
+			fmt.Fprint(w, "null")
+			return
+		}
+		items := make([]string, count)
+		for i := range items {
+			items[i] = fmt.Sprintf(`{"n":%d}`, i+1)
+		}
+		body := "[" + strings.Join(items, ",") + "]"
+		if r.URL.Query().Get("chunked") == "" {
+			w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		}
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestResponseLimits(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	t.Run("Max Response Size", func(t *testing.T) {
+		ts := itemsServer(t)
+		client := newLimitedClient(t, 256)
+
+		var items []tick
+		require.NoError(t, client.Call("GET", ts.URL+"?count=10", nil, &items))
+		require.Len(t, items, 10)
+
+		for _, query := range []string{"?count=100", "?count=100&chunked=1"} {
+			err := client.Call("GET", ts.URL+query, nil, &items)
+			var tooLarge *ResponseTooLargeError
+			require.ErrorAs(t, err, &tooLarge, query)
+			require.Equal(t, &ResponseTooLargeError{StatusCode: http.StatusOK, Limit: 256}, tooLarge)
+
+			_, err = client.CallWithResponse("GET", ts.URL+query, nil, nil)
+			require.ErrorAs(t, err, &tooLarge, query)
+		}
+
+		require.NoError(t, client.Call("GET", ts.URL+"?count=100", nil, &items, WithMaxResponseBytes(0)))
+		require.Len(t, items, 100)
+	})
+
+	t.Run("Error Bodies Are Truncated", func(t *testing.T) {
+		ts := itemsServer(t)
+		resp, err := newLimitedClient(t, 256).CallWithResponse("GET", ts.URL+"/fail", nil, nil)
+		var respErr *ResponseError
+		require.ErrorAs(t, err, &respErr)
+		require.Equal(t, http.StatusBadGateway, respErr.StatusCode)
+		require.Len(t, resp.Body, 256)
+	})
+
+	t.Run("Keep Raw Body", func(t *testing.T) {
+		ts := itemsServer(t)
+		var items []tick
+		resp, err := newLimitedClient(t, 256).CallWithResponse("GET", ts.URL+"?count=2", nil, &items)
+		require.NoError(t, err)
+		require.Equal(t, []tick{{N: 1}, {N: 2}}, items)
+		require.Equal(t, `[{"n":1},{"n":2}]`, string(resp.Body))
+	})
+
+	t.Run("Invalid Limit", func(t *testing.T) {
+		c, err := config.New(config.WithDefault(map[string]interface{}{
+			"otel_enabled":                   false,
+			"http_client_max_response_bytes": -1,
+		}))
+		require.NoError(t, err)
+		_, err = NewHTTPClient(c)
+		require.ErrorContains(t, err, "MaxResponseBytes")
+	})
+}
+
+func TestResponseStreaming(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	t.Run("Call Body", func(t *testing.T) {
+		ts := itemsServer(t)
+		client := newLimitedClient(t, 256)
+
+		body, resp, err := client.CallBody(context.Background(), "GET", ts.URL+"?count=100", nil)
+		require.NoError(t, err, "the size limit does not apply to streamed bodies")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		data, err := io.ReadAll(body)
+		require.NoError(t, err)
+		require.NoError(t, body.Close())
+		require.Greater(t, len(data), 256)
+
+		_, resp, err = client.CallBody(context.Background(), "GET", ts.URL+"/fail", nil, WithMaxResponseBytes(0))
+		var respErr *ResponseError
+		require.ErrorAs(t, err, &respErr)
+		require.Equal(t, "upstream down", respErr.Message)
+		require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	})
+
+	t.Run("Limited Error Bodies", func(t *testing.T) {
+		ts := itemsServer(t)
+		client := newLimitedClient(t, 256)
+		var respErr *ResponseError
+
+		_, err := client.Stream(context.Background(), "GET", ts.URL+"/fail", nil)
+		require.ErrorAs(t, err, &respErr)
+		require.Equal(t, http.StatusBadGateway, respErr.StatusCode)
+		require.Len(t, respErr.Body, 256, "streams read at most the response limit of an error")
+
+		_, err = DialWebSocket[ChatMessage, ChatReply](context.Background(), client, ts.URL+"/fail")
+		require.ErrorAs(t, err, &respErr)
+		require.Equal(t, http.StatusBadGateway, respErr.StatusCode)
+		require.Len(t, respErr.Body, 256, "refused handshakes read at most the response limit of an error")
+	})
+
+	t.Run("Call Array", func(t *testing.T) {
+		ts := itemsServer(t)
+		client := newLimitedClient(t, 256)
+
+		var ticks []int
+		for item, err := range CallArray[tick](context.Background(), client, "GET", ts.URL+"?count=100&chunked=1", nil) {
+			require.NoError(t, err)
+			ticks = append(ticks, item.N)
+		}
+		require.Len(t, ticks, 100)
+		require.Equal(t, 100, ticks[99])
+
+		// Stopping early closes the body
+		ticks = nil
+		for item, err := range CallArray[tick](context.Background(), client, "GET", ts.URL+"?count=100", nil) {
+			require.NoError(t, err)
+			if ticks = append(ticks, item.N); len(ticks) == 3 {
+				break
+			}
+		}
+		require.Equal(t, []int{1, 2, 3}, ticks)
+
+		for _, err := range CallArray[tick](context.Background(), client, "GET", ts.URL+"?count=-1", nil) {
+			t.Fatalf("null has no elements, got error %v", err)
+		}
+		for _, err := range CallArray[tick](context.Background(), client, "GET", ts.URL+"/fail", nil) {
+			var respErr *ResponseError
+			require.ErrorAs(t, err, &respErr)
+		}
+	})
+
+	t.Run("Decode Array Errors", func(t *testing.T) {
+		for body, message := range map[string]string{
+			`{"n":1}`:       "expected a JSON array",
+			`[{"n":1},{"n"`: "failed to decode array element",
+			`[{"n":"one"}]`: "failed to decode array element",
+			``:              "failed to read JSON array",
+		} {
+			var errs []error
+			for _, err := range DecodeArray[tick](io.NopCloser(strings.NewReader(body))) {
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}
+			require.Len(t, errs, 1, body)
+			require.ErrorContains(t, errs[0], message, body)
+		}
+	})
+}
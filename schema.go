@@ -0,0 +1,464 @@
+package httpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// componentsPrefix is the JSON pointer prefix of schemas under components/schemas
+const componentsPrefix = "#/components/schemas/"
+
+// schemaRegistry generates schemas for Go types. Named structs are added to
+// schemas, which backs components/schemas, and referenced with $ref.
+type schemaRegistry struct {
+	rules   *validationRules
+	schemas map[string]interface{}
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry(rules *validationRules) *schemaRegistry {
+	return &schemaRegistry{
+		rules:   rules,
+		schemas: map[string]interface{}{},
+		names:   map[reflect.Type]string{},
+	}
+}
+
+// generateSchema generates an OpenAPI schema for a Go type, naming fields as
+// encoding/json does. The validation rules of the registry expand aliases and
+// add the schema hints of custom rules.
+func (r *schemaRegistry) generateSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return r.typeSchema(t)
+}
+
+// resolve follows a $ref to the registered schema
+func (r *schemaRegistry) resolve(schema map[string]interface{}) map[string]interface{} {
+	if ref, ok := schema["$ref"].(string); ok {
+		if target, ok := r.schemas[strings.TrimPrefix(ref, componentsPrefix)].(map[string]interface{}); ok {
+			return target
+		}
+	}
+	return schema
+}
+
+// typeSchema maps a Go type to its schema
+func (r *schemaRegistry) typeSchema(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		if t.Elem() == fileType {
+			return binarySchema() // A missing file is an absent part, not null
+		}
+		schema := r.typeSchema(t.Elem())
+		if _, ok := schema["$ref"]; ok {
+			// Siblings of $ref are ignored, so wrap it to mark it nullable
+			return map[string]interface{}{"allOf": []interface{}{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	}
+
+	switch {
+	case t == fileType:
+		return binarySchema()
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == durationType:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return map[string]interface{}{} // Custom JSON encoding, any value
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32", "minimum": float64(0)}
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer", "format": "int64", "minimum": float64(0)}
+	case reflect.Float32:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"} // Base64, as encoding/json writes it
+		}
+		return map[string]interface{}{"type": "array", "items": r.typeSchema(t.Elem())}
+	case reflect.Array:
+		return map[string]interface{}{
+			"type":     "array",
+			"items":    r.typeSchema(t.Elem()),
+			"minItems": float64(t.Len()),
+			"maxItems": float64(t.Len()),
+		}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": r.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		return r.ref(t)
+	}
+	return map[string]interface{}{} // Interfaces hold any value
+}
+
+// ref registers a named struct under components/schemas on first use and
+// returns a reference to it. The name is reserved before the fields are
+// expanded, so recursive types refer to themselves.
+func (r *schemaRegistry) ref(t reflect.Type) map[string]interface{} {
+	name, ok := r.names[t]
+	if !ok {
+		name = r.componentName(t)
+		r.names[t] = name
+		r.schemas[name] = map[string]interface{}{}
+		r.schemas[name] = r.structSchema(t)
+	}
+	return map[string]interface{}{"$ref": componentsPrefix + name}
+}
+
+// invalidComponentChars matches characters not allowed in component names
+var invalidComponentChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// componentName derives a unique component name from the Go type name. A
+// name already taken by a type from another package is qualified with the
+// package name, then numbered.
+func (r *schemaRegistry) componentName(t reflect.Type) string {
+	name := t.Name()
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		// Generic instantiations such as Page[example.com/pkg.Item] become Page_Item
+		args := strings.Split(strings.TrimSuffix(name[i+1:], "]"), ",")
+		for j, arg := range args {
+			arg = arg[strings.LastIndex(arg, "/")+1:]
+			args[j] = arg[strings.LastIndex(arg, ".")+1:]
+		}
+		name = name[:i] + "_" + strings.Join(args, "_")
+	}
+	name = strings.Trim(invalidComponentChars.ReplaceAllString(name, "_"), "_")
+
+	candidates := []string{name}
+	if pkg := path.Base(t.PkgPath()); pkg != "." && pkg != "" {
+		candidates = append(candidates, invalidComponentChars.ReplaceAllString(pkg, "_")+"."+name)
+	}
+	for _, candidate := range candidates {
+		if _, taken := r.schemas[candidate]; !taken {
+			return candidate
+		}
+	}
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s_%d", candidates[len(candidates)-1], n)
+		if _, taken := r.schemas[candidate]; !taken {
+			return candidate
+		}
+	}
+}
+
+// structSchema describes the JSON object of a struct. Embedded structs
+// without a JSON name are combined with allOf, as their fields are promoted.
+func (r *schemaRegistry) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	var embedded []interface{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts := jsonFieldName(field)
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !isScalarType(ft) {
+				embedded = append(embedded, r.typeSchema(ft))
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if _, _, bound := fieldSource(field); bound {
+			continue // Bound from the path, query, headers or cookies
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		validateTag := r.rules.expand(field.Tag.Get("validate"))
+		fieldSchema := r.typeSchema(field.Type)
+		applyValidateRules(fieldSchema, field.Type, validateTag, r.rules)
+		properties[name] = annotateSchema(fieldSchema, field)
+		if isRequiredField(validateTag, opts) {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	if len(embedded) > 0 {
+		return map[string]interface{}{"allOf": append(embedded, schema)}
+	}
+	return schema
+}
+
+// hasBodyFields reports whether a struct has fields decoded from the request body
+func hasBodyFields(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name, _ := jsonFieldName(field); name == "-" {
+			continue
+		}
+		if field.Anonymous {
+			if hasBodyFields(field.Type) {
+				return true
+			}
+			continue
+		}
+		if _, _, bound := fieldSource(field); field.IsExported() && !bound {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonFieldName returns the name and options of a field's json tag
+func jsonFieldName(field reflect.StructField) (name string, opts []string) {
+	parts := strings.Split(field.Tag.Get("json"), ",")
+	return parts[0], parts[1:]
+}
+
+// isRequiredField reports whether a field must be present: it has a plain
+// required rule and neither its json nor validate tag allows omitting it
+func isRequiredField(validateTag string, jsonOpts []string) bool {
+	for _, opt := range jsonOpts {
+		if opt == "omitempty" {
+			return false
+		}
+	}
+	parts := strings.Split(validateTag, ",")
+	for _, part := range parts {
+		switch part {
+		case "dive":
+			return false
+		case "omitempty":
+			return false
+		case "required":
+			return true
+		}
+	}
+	return false
+}
+
+// Patterns of validator rules that have no JSON Schema format
+var rulePatterns = map[string]string{
+	"alpha":    "^[a-zA-Z]+$",
+	"alphanum": "^[a-zA-Z0-9]+$",
+	"numeric":  `^[-+]?[0-9]+(?:\.[0-9]+)?$`,
+	"hexcolor": "^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$",
+}
+
+// Formats of validator rules
+var ruleFormats = map[string]string{
+	"email":            "email",
+	"uuid":             "uuid",
+	"uuid3":            "uuid",
+	"uuid4":            "uuid",
+	"uuid5":            "uuid",
+	"uuid_rfc4122":     "uuid",
+	"url":              "uri",
+	"uri":              "uri",
+	"http_url":         "uri",
+	"hostname":         "hostname",
+	"hostname_rfc1123": "hostname",
+	"ipv4":             "ipv4",
+	"ip4_addr":         "ipv4",
+	"ipv6":             "ipv6",
+	"ip6_addr":         "ipv6",
+}
+
+// applyValidateRules maps the rules of a validate tag onto schema, which
+// describes a value of type t. Rules after "dive" apply to the elements of
+// slices, arrays and maps.
+func applyValidateRules(schema map[string]interface{}, t reflect.Type, validateTag string, rules *validationRules) {
+	if validateTag == "" {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	parts := strings.Split(validateTag, ",")
+	for i, part := range parts {
+		rule, param, _ := strings.Cut(part, "=")
+		param = strings.ReplaceAll(strings.ReplaceAll(param, "0x2C", ","), "0x7C", "|")
+		switch rule {
+		case "dive":
+			elemTag := strings.Join(parts[i+1:], ",")
+			switch t.Kind() {
+			case reflect.Slice, reflect.Array:
+				if items, ok := schema["items"].(map[string]interface{}); ok {
+					applyValidateRules(items, t.Elem(), elemTag, rules)
+				}
+			case reflect.Map:
+				if values, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+					applyValidateRules(values, t.Elem(), elemTag, rules)
+				}
+			}
+			return
+		case "min", "max", "len", "gt", "gte", "lt", "lte":
+			applyBound(schema, t, rule, param)
+		case "oneof":
+			schema["enum"] = enumValues(t, param)
+		case "unique":
+			schema["uniqueItems"] = true
+		case "regexp":
+			schema["pattern"] = param
+		case "datetime":
+			if param == "2006-01-02" {
+				schema["format"] = "date"
+			} else {
+				schema["format"] = "date-time"
+			}
+		default:
+			if format, ok := ruleFormats[rule]; ok {
+				schema["format"] = format
+			} else if pattern, ok := rulePatterns[rule]; ok {
+				schema["pattern"] = pattern
+			}
+		}
+		rules.applyHints(schema, part)
+	}
+}
+
+// applyBound maps a size or range rule to the keyword matching the kind of t:
+// lengths for strings, item counts for slices, property counts for maps and
+// bounds for numbers
+func applyBound(schema map[string]interface{}, t reflect.Type, rule, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	var minKey, maxKey string
+	switch t.Kind() {
+	case reflect.String:
+		minKey, maxKey = "minLength", "maxLength"
+	case reflect.Slice, reflect.Array:
+		minKey, maxKey = "minItems", "maxItems"
+	case reflect.Map:
+		minKey, maxKey = "minProperties", "maxProperties"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		switch rule {
+		case "min", "gte":
+			schema["minimum"] = n
+		case "max", "lte":
+			schema["maximum"] = n
+		case "len":
+			schema["minimum"], schema["maximum"] = n, n
+		case "gt":
+			schema["minimum"], schema["exclusiveMinimum"] = n, true
+		case "lt":
+			schema["maximum"], schema["exclusiveMaximum"] = n, true
+		}
+		return
+	default:
+		return
+	}
+
+	switch rule {
+	case "min", "gte":
+		schema[minKey] = n
+	case "max", "lte":
+		schema[maxKey] = n
+	case "len":
+		schema[minKey], schema[maxKey] = n, n
+	case "gt":
+		schema[minKey] = n + 1
+	case "lt":
+		schema[maxKey] = n - 1
+	}
+}
+
+// oneofValue matches a value of a oneof rule, which may be quoted to contain spaces
+var oneofValue = regexp.MustCompile(`'[^']*'|\S+`)
+
+// enumValues converts the values of a oneof rule to the JSON type of t
+func enumValues(t reflect.Type, param string) []interface{} {
+	var values []interface{}
+	for _, s := range oneofValue.FindAllString(param, -1) {
+		s = strings.Trim(s, "'")
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				values = append(values, n)
+				continue
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+				values = append(values, n)
+				continue
+			}
+		case reflect.Float32, reflect.Float64:
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				values = append(values, f)
+				continue
+			}
+		}
+		values = append(values, s)
+	}
+	return values
+}
+
+// compiledPatterns caches the expressions of regexp rules
+var compiledPatterns sync.Map
+
+// validateRegexp implements the regexp=<pattern> rule for string fields.
+// Commas and pipes in the pattern are written as 0x2C and 0x7C.
+func validateRegexp(fl validator.FieldLevel) bool {
+	pattern := fl.Param()
+	re, ok := compiledPatterns.Load(pattern)
+	if !ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return false
+		}
+		re, _ = compiledPatterns.LoadOrStore(pattern, compiled)
+	}
+	field := fl.Field()
+	if field.Kind() != reflect.String {
+		return false
+	}
+	return re.(*regexp.Regexp).MatchString(field.String())
+}
@@ -0,0 +1,162 @@
+package httpc
+
+import (
+	"net"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+)
+
+// httpcAddress is the package's Address type, named apart from the test-local one
+type httpcAddress = Address
+
+type schemaBase struct {
+	ID        string    `json:"id" validate:"required,uuid"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type schemaNode struct {
+	Value    int           `json:"value"`
+	Children []*schemaNode `json:"children"`
+}
+
+type schemaDocument struct {
+	schemaBase
+	Title    string            `json:"title" validate:"required,len=8"`
+	Status   string            `json:"status" validate:"oneof=draft published 'in review'"`
+	Priority int               `json:"priority" validate:"oneof=1 2 3"`
+	Rating   float32           `json:"rating" validate:"gt=0,lt=5"`
+	Count    uint16            `json:"count"`
+	Active   bool              `json:"active"`
+	Homepage string            `json:"homepage,omitempty" validate:"required,url"`
+	Slug     string            `json:"slug" validate:"omitempty,regexp=^[a-z0-9-]+$"`
+	Tags     []string          `json:"tags" validate:"min=1,max=5,unique,dive,alphanum,max=10"`
+	Labels   map[string]string `json:"labels" validate:"max=3,dive,oneof=a b"`
+	Owner    *schemaBase       `json:"owner"`
+	Parent   *schemaNode       `json:"parent"`
+	Data     []byte            `json:"data"`
+	Extra    interface{}       `json:"extra"`
+	Address  net.IP            `json:"address"`
+	Grid     [2]int            `json:"grid"`
+	Untagged string
+	Skipped  string `json:"-"`
+	hidden   string
+}
+
+type schemaPage[T any] struct {
+	Items []T `json:"items"`
+}
+
+func TestGenerateSchema(t *testing.T) {
+	r := newSchemaRegistry(nil)
+	schema := r.generateSchema(reflect.TypeOf(&schemaDocument{}))
+	require.Equal(t, map[string]interface{}{"$ref": "#/components/schemas/schemaDocument"}, schema)
+	component := func(name string) map[string]interface{} {
+		require.Contains(t, r.schemas, name)
+		return r.schemas[name].(map[string]interface{})
+	}
+	ref := func(name string) map[string]interface{} {
+		return map[string]interface{}{"$ref": componentsPrefix + name}
+	}
+	nullableRef := func(name string) map[string]interface{} {
+		return map[string]interface{}{"allOf": []interface{}{ref(name)}, "nullable": true}
+	}
+
+	allOf := r.resolve(schema)["allOf"].([]interface{})
+	require.Len(t, allOf, 2)
+	require.Equal(t, ref("schemaBase"), allOf[0])
+	base := component("schemaBase")
+	require.Equal(t, []string{"id"}, base["required"])
+	baseProps := base["properties"].(map[string]interface{})
+	require.Equal(t, map[string]interface{}{"type": "string", "format": "uuid"}, baseProps["id"])
+	require.Equal(t, map[string]interface{}{"type": "string", "format": "date-time"}, baseProps["created_at"])
+
+	doc := allOf[1].(map[string]interface{})
+	require.Equal(t, []string{"title"}, doc["required"], "omitempty fields are optional")
+	props := doc["properties"].(map[string]interface{})
+	prop := func(name string) map[string]interface{} { return props[name].(map[string]interface{}) }
+
+	require.Equal(t, map[string]interface{}{"type": "string", "minLength": float64(8), "maxLength": float64(8)}, prop("title"))
+	require.Equal(t, []interface{}{"draft", "published", "in review"}, prop("status")["enum"])
+	require.Equal(t, []interface{}{int64(1), int64(2), int64(3)}, prop("priority")["enum"])
+	require.Equal(t, map[string]interface{}{
+		"type": "number", "format": "float",
+		"minimum": float64(0), "exclusiveMinimum": true,
+		"maximum": float64(5), "exclusiveMaximum": true,
+	}, prop("rating"))
+	require.Equal(t, float64(0), prop("count")["minimum"])
+	require.Equal(t, "boolean", prop("active")["type"])
+	require.Equal(t, "uri", prop("homepage")["format"])
+	require.Equal(t, "^[a-z0-9-]+$", prop("slug")["pattern"])
+
+	require.Equal(t, map[string]interface{}{
+		"type":        "array",
+		"minItems":    float64(1),
+		"maxItems":    float64(5),
+		"uniqueItems": true,
+		"items":       map[string]interface{}{"type": "string", "pattern": "^[a-zA-Z0-9]+$", "maxLength": float64(10)},
+	}, prop("tags"))
+	require.Equal(t, map[string]interface{}{
+		"type":                 "object",
+		"maxProperties":        float64(3),
+		"additionalProperties": map[string]interface{}{"type": "string", "enum": []interface{}{"a", "b"}},
+	}, prop("labels"))
+
+	require.Equal(t, nullableRef("schemaBase"), prop("owner"))
+	require.Equal(t, nullableRef("schemaNode"), prop("parent"))
+	children := component("schemaNode")["properties"].(map[string]interface{})["children"].(map[string]interface{})
+	require.Equal(t, nullableRef("schemaNode"), children["items"], "recursive types refer to themselves")
+
+	require.Equal(t, map[string]interface{}{"type": "string", "format": "byte"}, prop("data"))
+	require.Equal(t, map[string]interface{}{}, prop("extra"))
+	require.Equal(t, map[string]interface{}{"type": "string"}, prop("address"))
+	require.Equal(t, float64(2), prop("grid")["minItems"])
+	require.Contains(t, props, "Untagged")
+	require.NotContains(t, props, "Skipped")
+	require.NotContains(t, props, "hidden")
+}
+
+func TestSchemaComponentNames(t *testing.T) {
+	type Address struct {
+		Line string `json:"line"`
+	}
+
+	r := newSchemaRegistry(nil)
+	require.Equal(t, componentsPrefix+"Address", r.generateSchema(reflect.TypeOf(httpcAddress{}))["$ref"])
+	require.Equal(t, componentsPrefix+"mail.Address", r.generateSchema(reflect.TypeOf(mail.Address{}))["$ref"])
+	require.Equal(t, componentsPrefix+"go-core-httpc.Address", r.generateSchema(reflect.TypeOf(Address{}))["$ref"])
+	require.Equal(t, componentsPrefix+"Address", r.generateSchema(reflect.TypeOf(&httpcAddress{}))["$ref"], "names are stable per type")
+
+	page := r.generateSchema(reflect.TypeOf(schemaPage[httpcAddress]{}))
+	require.Equal(t, componentsPrefix+"schemaPage_Address", page["$ref"])
+	items := r.resolve(page)["properties"].(map[string]interface{})["items"].(map[string]interface{})
+	require.Equal(t, map[string]interface{}{"$ref": componentsPrefix + "Address"}, items["items"])
+
+	anonymous := r.generateSchema(reflect.TypeOf(struct {
+		Name string `json:"name"`
+	}{}))
+	require.Equal(t, "object", anonymous["type"], "anonymous structs are inlined")
+}
+
+func TestRegexpRule(t *testing.T) {
+	validate, _, err := newValidator()
+	require.NoError(t, err)
+
+	type slug struct {
+		Value string `validate:"regexp=^[a-z]{2}0x2C[0-9]+$"`
+	}
+	require.NoError(t, validate.Struct(slug{Value: "ab,12"}))
+	err = validate.Struct(slug{Value: "AB12"})
+	require.Error(t, err)
+	require.Equal(t, "regexp", err.(validator.ValidationErrors)[0].Tag())
+
+	r := newSchemaRegistry(nil)
+	schema := r.resolve(r.generateSchema(reflect.TypeOf(slug{})))
+	pattern := schema["properties"].(map[string]interface{})["Value"].(map[string]interface{})["pattern"].(string)
+	require.True(t, regexp.MustCompile(pattern).MatchString("ab,12"))
+}
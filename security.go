@@ -0,0 +1,263 @@
+package httpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultHSTSMaxAge     = 31536000 // One year
+	defaultFrameOptions   = "DENY"
+	defaultReferrerPolicy = "strict-origin-when-cross-origin"
+)
+
+// securityConfig holds the hardening settings of a Server
+type securityConfig struct {
+	ginMode            string
+	headers            bool         // Send the security headers
+	hstsMaxAge         int          // Strict-Transport-Security max-age, 0 to not send it
+	hstsSubdomains     bool         // Extend HSTS to subdomains
+	frameOptions       string       // X-Frame-Options, empty to not send it
+	referrerPolicy     string       // Referrer-Policy, empty to not send it
+	docsCSP            string       // Content-Security-Policy of the docs UI, empty for the default
+	strictContentTypes bool         // Reject bodies in media types the method does not accept
+	trustedProxies     []*net.IPNet // Peers whose X-Forwarded-Proto is believed
+}
+
+// newSecurityConfig reads the gin_mode and security_* config keys
+func newSecurityConfig(c *config.Config) (securityConfig, error) {
+	cfg := securityConfig{
+		ginMode:            getStringConfig(c, "gin_mode", gin.DebugMode),
+		headers:            getBoolConfig(c, "security_headers_enabled", false),
+		hstsMaxAge:         getIntConfig(c, "security_hsts_max_age_seconds", defaultHSTSMaxAge),
+		hstsSubdomains:     getBoolConfig(c, "security_hsts_include_subdomains", false),
+		frameOptions:       getStringConfig(c, "security_frame_options", defaultFrameOptions),
+		referrerPolicy:     getStringConfig(c, "security_referrer_policy", defaultReferrerPolicy),
+		docsCSP:            getStringConfig(c, "security_docs_csp", ""),
+		strictContentTypes: getBoolConfig(c, "security_strict_content_type", false),
+	}
+	for _, proxy := range configList(c, "security_trusted_proxies", "") {
+		// A single address is a network of one
+		cidr := proxy
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid security_trusted_proxies entry %q", proxy)
+		}
+		cfg.trustedProxies = append(cfg.trustedProxies, network)
+	}
+	switch cfg.ginMode {
+	case gin.DebugMode, gin.ReleaseMode, gin.TestMode:
+	default:
+		return cfg, fmt.Errorf("unsupported gin_mode %q", cfg.ginMode)
+	}
+	switch strings.ToUpper(cfg.frameOptions) {
+	case "", "DENY", "SAMEORIGIN":
+	default:
+		return cfg, fmt.Errorf("unsupported security_frame_options %q", cfg.frameOptions)
+	}
+	return cfg, nil
+}
+
+// securityHeaders sets the security headers on every response. HSTS is only
+// sent over HTTPS, including behind a trusted proxy reporting it with
+// X-Forwarded-Proto, since browsers ignore it otherwise.
+func securityHeaders(cfg securityConfig) gin.HandlerFunc {
+	hsts := "max-age=" + strconv.Itoa(cfg.hstsMaxAge)
+	if cfg.hstsSubdomains {
+		hsts += "; includeSubDomains"
+	}
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		if cfg.frameOptions != "" {
+			header.Set("X-Frame-Options", strings.ToUpper(cfg.frameOptions))
+		}
+		if cfg.referrerPolicy != "" {
+			header.Set("Referrer-Policy", cfg.referrerPolicy)
+		}
+		if cfg.hstsMaxAge > 0 && (c.Request.TLS != nil || cfg.forwardedHTTPS(c.Request)) {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+	}
+}
+
+// forwardedHTTPS reports whether a trusted proxy received r over HTTPS.
+// X-Forwarded-Proto from any other peer is ignored, since clients can set it.
+func (cfg securityConfig) forwardedHTTPS(r *http.Request) bool {
+	if !strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		return false
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range cfg.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// docsContentSecurityPolicy returns the Content-Security-Policy of the docs
+// pages: only the page's origin, which serves the embedded assets, may
+// provide scripts. The UIs need inline styles, data: images and, for ReDoc,
+// blob: workers.
+func (cfg securityConfig) docsContentSecurityPolicy() string {
+	if cfg.docsCSP != "" {
+		return cfg.docsCSP
+	}
+	sources := "'self'"
+	return strings.Join([]string{
+		"default-src 'self'",
+		"script-src " + sources,
+		"style-src " + sources + " 'unsafe-inline'",
+		"img-src 'self' data: https:",
+		"font-src " + sources + " data:",
+		"worker-src 'self' blob:",
+		"connect-src 'self'",
+		"object-src 'none'",
+		"base-uri 'self'",
+		"form-action 'self'",
+		"frame-ancestors 'none'",
+	}, "; ")
+}
+
+// hasBody reports whether a request carries a body
+func hasBody(r *http.Request) bool {
+	return r.ContentLength > 0 || (r.ContentLength < 0 && r.Body != nil && r.Body != http.NoBody)
+}
+
+// checkContentType returns an error when the body of a request is in a media
+// type the method does not accept: a registered codec, or multipart/form-data
+// for an input with files, which accepts nothing else
+func checkContentType(c *gin.Context, m MethodInfo) error {
+	if !hasBody(c.Request) {
+		return nil
+	}
+	contentType := c.ContentType()
+	if contentType == "" {
+		return errors.New("missing content type")
+	}
+	bodyType := m.InputType
+	if body, ok := bodyField(bodyType); ok {
+		bodyType = body.field.Type
+	}
+	files := len(fileFields(bodyType)) > 0
+	if contentType == MediaTypeMultipart {
+		if !files {
+			return fmt.Errorf("unsupported content type: %s", contentType)
+		}
+		return nil
+	}
+	if _, ok := CodecFor(contentType); !ok || files {
+		return fmt.Errorf("unsupported content type: %s", contentType)
+	}
+	return nil
+}
+
+// unmarshalStrictJSON decodes data into v like json.Unmarshal, but fails on
+// unknown fields, duplicate keys and data after the value
+func unmarshalStrictJSON(data []byte, v interface{}) error {
+	if err := checkDuplicateKeys(json.NewDecoder(bytes.NewReader(data)), nil); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("invalid JSON: unexpected data after the value")
+	}
+	return nil
+}
+
+// maxJSONDepth bounds the nesting checkDuplicateKeys follows, as encoding/json
+// bounds the nesting it decodes
+const maxJSONDepth = 10000
+
+// jsonPathStep is a key, or an index when index is not negative, on the way
+// to a value
+type jsonPathStep struct {
+	key   string
+	index int
+}
+
+// jsonPath locates a value within a document as $.key[index]
+func jsonPath(steps []jsonPathStep) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, step := range steps {
+		if step.index < 0 {
+			b.WriteString("." + step.key)
+		} else {
+			b.WriteString("[" + strconv.Itoa(step.index) + "]")
+		}
+	}
+	return b.String()
+}
+
+// checkDuplicateKeys reads the next JSON value from dec, failing on an object
+// repeating a key. Keys are compared case-insensitively, as encoding/json
+// matches them to fields. path holds the steps to the value, shared across
+// the recursion so that memory grows with the depth only, and is formatted
+// when a duplicate is found.
+func checkDuplicateKeys(dec *json.Decoder, path []jsonPathStep) error {
+	if len(path) > maxJSONDepth {
+		return fmt.Errorf("invalid JSON: exceeded max depth of %d", maxJSONDepth)
+	}
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch token {
+	case json.Delim('{'):
+		keys := map[string]bool{}
+		for dec.More() {
+			token, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key := token.(string)
+			if keys[strings.ToLower(key)] {
+				return fmt.Errorf("invalid JSON: duplicate key %q in %s", key, jsonPath(path))
+			}
+			keys[strings.ToLower(key)] = true
+			if err := checkDuplicateKeys(dec, append(path, jsonPathStep{key: key, index: -1})); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			if err := checkDuplicateKeys(dec, append(path, jsonPathStep{index: i})); err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+	_, err = dec.Token() // The closing delimiter
+	return err
+}
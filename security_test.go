@@ -0,0 +1,305 @@
+package httpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// order is a nested input decoded strictly by strictService
+type order struct {
+	ID    int    `json:"id"`
+	Items []note `json:"items"`
+}
+
+// strictService decodes the same input strictly and leniently
+type strictService struct{}
+
+func (s *strictService) Strict(ctx context.Context, in order) (order, error)  { return in, nil }
+func (s *strictService) Lenient(ctx context.Context, in order) (order, error) { return in, nil }
+func (s *strictService) Labels(ctx context.Context, in map[string]string) (map[string]string, error) {
+	return in, nil
+}
+func (s *strictService) Batch(ctx context.Context, in []order) ([]order, error) { return in, nil }
+func (s *strictService) Rename(ctx context.Context, in string) (string, error)  { return in, nil }
+
+func (s *strictService) RegisterMethods() []MethodInfo {
+	methods := []MethodInfo{
+		{Name: "Strict", StrictJSON: true, InputType: reflect.TypeOf(order{})},
+		{Name: "Lenient", InputType: reflect.TypeOf(order{})},
+		{Name: "Labels", InputType: reflect.TypeOf(map[string]string{})},
+		{Name: "Batch", InputType: reflect.TypeOf([]order{})},
+		{Name: "Rename", InputType: reflect.TypeOf("")},
+	}
+	for i := range methods {
+		methods[i].HTTPMethod = "POST"
+		methods[i].OutputType = methods[i].InputType
+		methods[i].Func = reflect.ValueOf(s).MethodByName(methods[i].Name)
+	}
+	return methods
+}
+
+// newHardenedServer serves a strictService at /orders and a fileService at
+// /files with the given settings
+func newHardenedServer(t *testing.T, settings map[string]interface{}) *httptest.Server {
+	settings["otel_enabled"] = false
+	settings["port"] = 8080
+	c, err := config.New(config.WithDefault(settings))
+	require.NoError(t, err)
+	srv, err := NewServer(c)
+	require.NoError(t, err)
+	require.NoError(t, srv.RegisterService(&strictService{}, WithPathPrefix("/orders")))
+	require.NoError(t, srv.RegisterService(&fileService{}, WithPathPrefix("/files")))
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// postBody posts body to url with contentType, if any, and the given headers
+func postBody(t *testing.T, url, contentType, body string, header map[string]string) *http.Response {
+	req, err := http.NewRequest("POST", url, strings.NewReader(body))
+	require.NoError(t, err)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestSecurity(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	t.Run("Security Headers", func(t *testing.T) {
+		ts := newHardenedServer(t, map[string]interface{}{"security_headers_enabled": true})
+		resp, err := http.Get(ts.URL + "/health")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+		require.Equal(t, "DENY", resp.Header.Get("X-Frame-Options"))
+		require.Equal(t, "strict-origin-when-cross-origin", resp.Header.Get("Referrer-Policy"))
+		require.Empty(t, resp.Header.Get("Strict-Transport-Security"), "HSTS is only sent over HTTPS")
+
+		// X-Forwarded-Proto is ignored from peers that are not trusted proxies
+		forwarded := map[string]string{"X-Forwarded-Proto": "https"}
+		resp = postBody(t, ts.URL+"/orders/Strict", MediaTypeJSON, `{"id":1}`, forwarded)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Empty(t, resp.Header.Get("Strict-Transport-Security"))
+		require.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+	})
+
+	t.Run("Trusted Proxies", func(t *testing.T) {
+		for proxies, trusted := range map[string]bool{
+			"127.0.0.1":               true,
+			"10.0.0.0/8, 127.0.0.0/8": true,
+			"::1, 10.0.0.1":           false,
+		} {
+			ts := newHardenedServer(t, map[string]interface{}{"security_headers_enabled": true, "security_trusted_proxies": proxies})
+			resp := postBody(t, ts.URL+"/orders/Strict", MediaTypeJSON, `{"id":1}`, map[string]string{"X-Forwarded-Proto": "https"})
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			if trusted {
+				require.Equal(t, "max-age=31536000", resp.Header.Get("Strict-Transport-Security"), proxies)
+			} else {
+				require.Empty(t, resp.Header.Get("Strict-Transport-Security"), proxies)
+			}
+			resp = postBody(t, ts.URL+"/orders/Strict", MediaTypeJSON, `{"id":1}`, map[string]string{"X-Forwarded-Proto": "http"})
+			require.Empty(t, resp.Header.Get("Strict-Transport-Security"), proxies)
+		}
+
+		c, err := config.New(config.WithDefault(map[string]interface{}{"otel_enabled": false, "security_trusted_proxies": "10.0.0.0/33"}))
+		require.NoError(t, err)
+		_, err = NewServer(c)
+		require.ErrorContains(t, err, `security_trusted_proxies entry "10.0.0.0/33"`)
+	})
+
+	t.Run("Configured Headers", func(t *testing.T) {
+		ts := newHardenedServer(t, map[string]interface{}{
+			"security_headers_enabled":         true,
+			"security_hsts_max_age_seconds":    600,
+			"security_hsts_include_subdomains": true,
+			"security_frame_options":           "sameorigin",
+			"security_referrer_policy":         "no-referrer",
+		})
+		tls := httptest.NewTLSServer(ts.Config.Handler)
+		defer tls.Close()
+		resp, err := tls.Client().Get(tls.URL + "/health")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, "max-age=600; includeSubDomains", resp.Header.Get("Strict-Transport-Security"))
+		require.Equal(t, "SAMEORIGIN", resp.Header.Get("X-Frame-Options"))
+		require.Equal(t, "no-referrer", resp.Header.Get("Referrer-Policy"))
+	})
+
+	t.Run("Docs Content Security Policy", func(t *testing.T) {
+		withReDocAssets(t)
+		ts := newHardenedServer(t, map[string]interface{}{"security_headers_enabled": true, "docs_redoc_enabled": true})
+		for _, page := range []string{"/api/docs/index.html", "/api/docs/redoc.html"} {
+			resp, err := http.Get(ts.URL + page)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode, page)
+			csp := resp.Header.Get("Content-Security-Policy")
+			require.Contains(t, csp, "default-src 'self'", page)
+			require.Contains(t, csp, "frame-ancestors 'none'", page)
+			require.NotContains(t, csp, "'unsafe-eval'", page)
+		}
+
+		resp, err := http.Get(ts.URL + "/health")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Empty(t, resp.Header.Get("Content-Security-Policy"), "API responses are not HTML")
+
+		cfg := securityConfig{}
+		require.Contains(t, cfg.docsContentSecurityPolicy(), "script-src 'self';")
+		cfg.docsCSP = "default-src 'none'"
+		require.Equal(t, "default-src 'none'", cfg.docsContentSecurityPolicy())
+	})
+
+	t.Run("Disabled By Default", func(t *testing.T) {
+		ts := newHardenedServer(t, map[string]interface{}{})
+		resp, err := http.Get(ts.URL + "/api/docs/index.html")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Empty(t, resp.Header.Get("X-Content-Type-Options"))
+		require.Empty(t, resp.Header.Get("Content-Security-Policy"))
+
+		// A body without a content type is decoded as JSON
+		resp = postBody(t, ts.URL+"/orders/Lenient", "", `{"id":1}`, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Strict Content Types", func(t *testing.T) {
+		ts := newHardenedServer(t, map[string]interface{}{"security_strict_content_type": true})
+		for contentType, status := range map[string]int{
+			MediaTypeJSON:                       http.StatusOK,
+			"application/json; charset=utf-8":   http.StatusOK,
+			MediaTypeXML:                        http.StatusOK,
+			"":                                  http.StatusUnsupportedMediaType,
+			"text/plain":                        http.StatusUnsupportedMediaType,
+			MediaTypeMultipart + "; boundary=x": http.StatusUnsupportedMediaType,
+		} {
+			body := `{"id":1}`
+			if contentType == MediaTypeXML {
+				body = `<order><id>1</id></order>`
+			}
+			resp := postBody(t, ts.URL+"/orders/Lenient", contentType, body, nil)
+			require.Equal(t, status, resp.StatusCode, contentType)
+		}
+
+		// Map, slice and string inputs are held to the same media types
+		for path, body := range map[string]string{
+			"/orders/Labels": `{"env":"prod"}`,
+			"/orders/Batch":  `[{"id":1,"items":[]}]`,
+			"/orders/Rename": `"order"`,
+		} {
+			resp := postBody(t, ts.URL+path, "text/plain", body, nil)
+			require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode, path)
+			resp = postBody(t, ts.URL+path, "", body, nil)
+			require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode, path)
+			resp = postBody(t, ts.URL+path, MediaTypeJSON, body, nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode, path)
+			require.JSONEq(t, body, readAll(t, resp), path)
+		}
+
+		// Inputs with files only accept multipart/form-data
+		resp := postBody(t, ts.URL+"/files/Avatar", MediaTypeJSON, `{"user_id":1}`, nil)
+		require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		require.NoError(t, w.WriteField("user_id", "1"))
+		part, err := w.CreateFormFile("avatar", "a.png")
+		require.NoError(t, err)
+		part.Write(pngHeader)
+		require.NoError(t, w.Close())
+		resp = postBody(t, ts.URL+"/files/Avatar", w.FormDataContentType(), body.String(), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// Requests without a body need no content type
+		resp = postBody(t, ts.URL+"/orders/Lenient", "", "", nil)
+		require.NotEqual(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	})
+
+	t.Run("Strict JSON", func(t *testing.T) {
+		ts := newHardenedServer(t, map[string]interface{}{})
+		for body, message := range map[string]string{
+			`{"id":1,"extra":true}`:                      `unknown field \"extra\"`,
+			`{"id":1,"id":2}`:                            `duplicate key \"id\" in $`,
+			`{"name":"a","Name":"b"}`:                    `duplicate key \"Name\" in $`,
+			`{"id":1,"ID":2}`:                            `duplicate key \"ID\" in $`,
+			`{"id":1,"items":[{"text":"a","text":"b"}]}`: `duplicate key \"text\" in $.items[0]`,
+		} {
+			resp := postBody(t, ts.URL+"/orders/Strict", MediaTypeJSON, body, nil)
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+			require.Contains(t, readAll(t, resp), message, body)
+
+			resp = postBody(t, ts.URL+"/orders/Lenient", MediaTypeJSON, body, nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode, "only StrictJSON methods reject %s", body)
+		}
+
+		resp := postBody(t, ts.URL+"/orders/Strict", MediaTypeJSON, `{"id":1} {"id":2}`, nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Contains(t, readAll(t, resp), "unexpected data after the value")
+
+		resp = postBody(t, ts.URL+"/orders/Strict", MediaTypeJSON, `{"id":1,"items":[{"text":"a"},{"text":"a"}]}`, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, `{"id":1,"items":[{"text":"a"},{"text":"a"}]}`, readAll(t, resp))
+	})
+
+	t.Run("Deeply Nested JSON", func(t *testing.T) {
+		// Each level keeps only its own key, so the check allocates in
+		// proportion to the body rather than to its depth times its size
+		const depth = 3000
+		key := strings.Repeat("k", 100)
+		nested := strings.Repeat(`{"`+key+`":`, depth) + `{"a":1,"A":2}` + strings.Repeat("}", depth)
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		err := checkDuplicateKeys(json.NewDecoder(strings.NewReader(nested)), nil)
+		runtime.ReadMemStats(&after)
+		require.ErrorContains(t, err, `duplicate key "A" in $.`+key+".")
+		require.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(64<<20))
+
+		tooDeep := strings.Repeat(`[`, maxJSONDepth+2) + strings.Repeat(`]`, maxJSONDepth+2)
+		require.ErrorContains(t, checkDuplicateKeys(json.NewDecoder(strings.NewReader(tooDeep)), nil), "exceeded max depth")
+
+		ts := newHardenedServer(t, map[string]interface{}{})
+		resp := postBody(t, ts.URL+"/orders/Strict", MediaTypeJSON, nested, nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Contains(t, readAll(t, resp), `duplicate key \"A\"`)
+		require.Equal(t, "$.items[0].", jsonPath([]jsonPathStep{{key: "items", index: -1}, {index: 0}, {key: "", index: -1}}))
+	})
+
+	t.Run("Gin Mode", func(t *testing.T) {
+		defer gin.SetMode(gin.DebugMode)
+		newHardenedServer(t, map[string]interface{}{"gin_mode": "release"})
+		require.Equal(t, gin.ReleaseMode, gin.Mode())
+		newHardenedServer(t, map[string]interface{}{})
+		require.Equal(t, gin.DebugMode, gin.Mode(), "debug remains the default")
+
+		for key, value := range map[string]string{"gin_mode": "production", "security_frame_options": "ALLOW-FROM x"} {
+			c, err := config.New(config.WithDefault(map[string]interface{}{"otel_enabled": false, key: value}))
+			require.NoError(t, err)
+			_, err = NewServer(c)
+			require.ErrorContains(t, err, key)
+		}
+	})
+}
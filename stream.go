@@ -0,0 +1,270 @@
+package httpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Media types of streamed responses
+const (
+	MediaTypeEventStream = "text/event-stream"
+	MediaTypeNDJSON      = "application/x-ndjson"
+)
+
+// Names of the events closing a Server-Sent Events stream
+const (
+	streamEndEvent   = "end"   // The stream completed; clients do not reconnect
+	streamErrorEvent = "error" // The stream failed; its data is an ErrorResponse
+)
+
+// defaultHeartbeat is the interval of keep-alive messages on idle streams
+const defaultHeartbeat = 15 * time.Second
+
+// Event is an item of a streamed response with Server-Sent Events metadata.
+// Streams of other types send each item as the data of an unnamed event.
+type Event struct {
+	ID    string        // Sent as the event id, which reconnecting clients echo in Last-Event-ID
+	Name  string        // Event type
+	Data  interface{}   // Encoded as JSON
+	Retry time.Duration // Reconnection delay requested from the client, if positive
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	eventType   = reflect.TypeOf(Event{})
+)
+
+// streamKind tells how a method output yields the items of a streamed response
+type streamKind int
+
+const (
+	streamNone streamKind = iota
+	streamChan            // <-chan T or chan T
+	streamSeq             // iter.Seq[T]
+	streamSeq2            // iter.Seq2[T, error]; a non-nil error ends the stream
+)
+
+// streamOf returns how t streams and the type of its items
+func streamOf(t reflect.Type) (streamKind, reflect.Type) {
+	if t == nil {
+		return streamNone, nil
+	}
+	switch t.Kind() {
+	case reflect.Chan:
+		if t.ChanDir()&reflect.RecvDir != 0 {
+			return streamChan, t.Elem()
+		}
+	case reflect.Func:
+		if t.NumIn() != 1 || t.NumOut() != 0 {
+			break
+		}
+		yield := t.In(0)
+		if yield.Kind() != reflect.Func || yield.NumOut() != 1 || yield.Out(0).Kind() != reflect.Bool {
+			break
+		}
+		switch {
+		case yield.NumIn() == 1:
+			return streamSeq, yield.In(0)
+		case yield.NumIn() == 2 && yield.In(1) == errorType:
+			return streamSeq2, yield.In(0)
+		}
+	}
+	return streamNone, nil
+}
+
+// negotiateStream picks the format of a streamed response from an Accept
+// header, defaulting to Server-Sent Events
+func negotiateStream(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return MediaTypeEventStream, true
+	}
+	for _, r := range parseAccept(accept) {
+		switch r.mediaType {
+		case MediaTypeEventStream, MediaTypeNDJSON:
+			return r.mediaType, true
+		case "*/*", "text/*":
+			return MediaTypeEventStream, true
+		case "application/*":
+			return MediaTypeNDJSON, true
+		}
+	}
+	return "", false
+}
+
+// streamItem is an item, or the error ending a stream
+type streamItem struct {
+	value interface{}
+	err   error
+}
+
+// streamItems pumps the items of a method output into a channel until the
+// output is exhausted or done is closed
+func streamItems(output reflect.Value, kind streamKind, done <-chan struct{}) <-chan streamItem {
+	items := make(chan streamItem)
+	send := func(item streamItem) bool {
+		select {
+		case items <- item:
+			return true
+		case <-done:
+			return false
+		}
+	}
+	go func() {
+		defer close(items)
+		defer func() {
+			if r := recover(); r != nil {
+				send(streamItem{err: fmt.Errorf("stream panicked: %v", r)})
+			}
+		}()
+		if output.IsNil() {
+			return
+		}
+		if kind == streamChan {
+			cases := []reflect.SelectCase{
+				{Dir: reflect.SelectRecv, Chan: output},
+				{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(done)},
+			}
+			for {
+				chosen, value, ok := reflect.Select(cases)
+				if chosen == 1 || !ok || !send(streamItem{value: value.Interface()}) {
+					return
+				}
+			}
+		}
+		yield := reflect.MakeFunc(output.Type().In(0), func(args []reflect.Value) []reflect.Value {
+			item := streamItem{value: args[0].Interface()}
+			if len(args) == 2 && !args[1].IsNil() {
+				item.err = args[1].Interface().(error)
+			}
+			return []reflect.Value{reflect.ValueOf(send(item) && item.err == nil)}
+		})
+		output.Call([]reflect.Value{yield})
+	}()
+	return items
+}
+
+// writeStream sends the items of a method output as they are produced,
+// flushing each one. It stops when the client disconnects, which cancels
+// the request context passed to the method, and sends heartbeats while idle.
+func (s *Server) writeStream(c *gin.Context, output reflect.Value, kind streamKind, mediaType string) {
+	ctx := c.Request.Context()
+	done := make(chan struct{})
+	defer close(done)
+	items := streamItems(output, kind, done)
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", mediaType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no") // Disable proxy buffering
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+
+	var heartbeat <-chan time.Time
+	if s.heartbeat > 0 {
+		ticker := time.NewTicker(s.heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+	sse := mediaType == MediaTypeEventStream
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			logger.InfoContext(ctx, "Stream client disconnected")
+			return
+		case <-heartbeat:
+			if sse {
+				_, err = io.WriteString(c.Writer, ": heartbeat\n\n")
+			} else {
+				_, err = io.WriteString(c.Writer, "\n")
+			}
+		case item, ok := <-items:
+			switch {
+			case !ok:
+				if sse {
+					writeSSE(c.Writer, Event{Name: streamEndEvent})
+					c.Writer.Flush()
+				}
+				return
+			case item.err != nil:
+				logger.ErrorContext(ctx, "Stream failed", logger.ErrField(item.err))
+				if sse {
+					writeSSE(c.Writer, Event{Name: streamErrorEvent, Data: ErrorResponse{Error: item.err.Error()}})
+				} else {
+					writeNDJSON(c.Writer, ErrorResponse{Error: item.err.Error()})
+				}
+				c.Writer.Flush()
+				return
+			case sse:
+				err = writeSSE(c.Writer, streamEvent(item.value))
+			default:
+				err = writeNDJSON(c.Writer, streamEvent(item.value).Data)
+			}
+		}
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to write stream", logger.ErrField(err))
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// streamEvent wraps a stream item in an Event, unless it is one
+func streamEvent(value interface{}) Event {
+	switch v := value.(type) {
+	case Event:
+		return v
+	case *Event:
+		if v != nil {
+			return *v
+		}
+	}
+	return Event{Data: value}
+}
+
+// writeSSE writes ev in the Server-Sent Events format
+func writeSSE(w io.Writer, ev Event) error {
+	var b strings.Builder
+	if ev.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", sseField(ev.ID))
+	}
+	if ev.Name != "" {
+		fmt.Fprintf(&b, "event: %s\n", sseField(ev.Name))
+	}
+	if ev.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", ev.Retry.Milliseconds())
+	}
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	fmt.Fprintf(&b, "data: %s\n\n", data)
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// sseField strips line breaks, which would end a field early
+func sseField(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
+
+// writeNDJSON writes v as a line of newline-delimited JSON
+func writeNDJSON(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,290 @@
+package httpc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/stretchr/testify/require"
+)
+
+// tickQuery selects the ticks of a stream
+type tickQuery struct {
+	Count       int    `query:"count"`
+	LastEventID string `header:"Last-Event-ID"`
+}
+
+// tick is an item of a test stream
+type tick struct {
+	N int `json:"n"`
+}
+
+// streamService streams ticks in each of the supported ways
+type streamService struct {
+	stopped chan struct{} // Closed when Idle sees its request cancelled
+}
+
+func (s *streamService) Ticks(ctx context.Context, q tickQuery) (<-chan tick, error) {
+	ticks := make(chan tick)
+	go func() {
+		defer close(ticks)
+		for n := 1; n <= q.Count; n++ {
+			select {
+			case ticks <- tick{N: n}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ticks, nil
+}
+
+func (s *streamService) Feed(q tickQuery) (iter.Seq2[Event, error], error) {
+	start, _ := strconv.Atoi(q.LastEventID)
+	return func(yield func(Event, error) bool) {
+		for n := start + 1; n <= q.Count; n++ {
+			if !yield(Event{ID: strconv.Itoa(n), Name: "tick", Data: tick{N: n}}, nil) {
+				return
+			}
+		}
+	}, nil
+}
+
+func (s *streamService) Fail(q tickQuery) (iter.Seq2[tick, error], error) {
+	return func(yield func(tick, error) bool) {
+		if yield(tick{N: 1}, nil) {
+			yield(tick{}, errors.New("sensor offline"))
+		}
+	}, nil
+}
+
+func (s *streamService) Idle(ctx context.Context, q tickQuery) (<-chan tick, error) {
+	go func() {
+		<-ctx.Done()
+		close(s.stopped)
+	}()
+	return make(chan tick), nil
+}
+
+func (s *streamService) RegisterMethods() []MethodInfo {
+	methods := []MethodInfo{
+		{Name: "Ticks", OutputType: reflect.TypeOf((<-chan tick)(nil))},
+		{Name: "Feed", OutputType: reflect.TypeOf((iter.Seq2[Event, error])(nil))},
+		{Name: "Fail", OutputType: reflect.TypeOf((iter.Seq2[tick, error])(nil))},
+		{Name: "Idle", OutputType: reflect.TypeOf((<-chan tick)(nil))},
+	}
+	for i := range methods {
+		methods[i].HTTPMethod = "GET"
+		methods[i].InputType = reflect.TypeOf(tickQuery{})
+		methods[i].Func = reflect.ValueOf(s).MethodByName(methods[i].Name)
+	}
+	return methods
+}
+
+// newStreamServer serves a streamService with the given heartbeat interval
+func newStreamServer(t *testing.T, heartbeatMs int) (*httptest.Server, *streamService) {
+	c, err := config.New(config.WithDefault(map[string]interface{}{
+		"otel_enabled":        false,
+		"port":                8080,
+		"stream_heartbeat_ms": heartbeatMs,
+	}))
+	require.NoError(t, err)
+	srv, err := NewServer(c)
+	require.NoError(t, err)
+	svc := &streamService{stopped: make(chan struct{})}
+	require.NoError(t, srv.RegisterService(svc, WithPathPrefix("/stream")))
+	ts := httptest.NewServer(srv.engine)
+	t.Cleanup(ts.Close)
+	return ts, svc
+}
+
+// newStreamClient creates a client retrying streams quickly
+func newStreamClient(t *testing.T) *HTTPClient {
+	c, err := config.New(config.WithDefault(map[string]interface{}{
+		"otel_enabled":                false,
+		"http_client_timeout_ms":      1000,
+		"http_client_max_retries":     2,
+		"http_client_backoff_base_ms": 50,
+		"http_client_disable_backoff": true,
+	}))
+	require.NoError(t, err)
+	client, err := NewHTTPClient(c)
+	require.NoError(t, err)
+	return client
+}
+
+func TestStreaming(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	t.Run("Server-Sent Events", func(t *testing.T) {
+		ts, _ := newStreamServer(t, 0)
+		resp, err := http.Get(ts.URL + "/stream/Ticks?count=2")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, MediaTypeEventStream, resp.Header.Get("Content-Type"))
+		require.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+		body := readAll(t, resp)
+		require.Equal(t, "data: {\"n\":1}\n\ndata: {\"n\":2}\n\nevent: end\ndata: null\n\n", body)
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		ts, _ := newStreamServer(t, 0)
+		req, err := http.NewRequest("GET", ts.URL+"/stream/Ticks?count=2", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", MediaTypeNDJSON)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, MediaTypeNDJSON, resp.Header.Get("Content-Type"))
+		require.Equal(t, "{\"n\":1}\n{\"n\":2}\n", readAll(t, resp))
+
+		req.Header.Set("Accept", MediaTypeJSON)
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNotAcceptable, resp.StatusCode)
+	})
+
+	t.Run("Stream Error", func(t *testing.T) {
+		ts, _ := newStreamServer(t, 0)
+		resp, err := http.Get(ts.URL + "/stream/Fail")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, "data: {\"n\":1}\n\nevent: error\ndata: {\"error\":\"sensor offline\"}\n\n", readAll(t, resp))
+
+		var ticks []int
+		for v, err := range Events[tick](mustStream(t, newStreamClient(t), ts.URL+"/stream/Fail")) {
+			if err != nil {
+				require.ErrorContains(t, err, "sensor offline")
+				break
+			}
+			ticks = append(ticks, v.N)
+		}
+		require.Equal(t, []int{1}, ticks)
+	})
+
+	t.Run("Heartbeat And Disconnect", func(t *testing.T) {
+		ts, svc := newStreamServer(t, 10)
+		resp, err := http.Get(ts.URL + "/stream/Idle")
+		require.NoError(t, err)
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, ": heartbeat\n", line)
+
+		resp.Body.Close()
+		select {
+		case <-svc.stopped:
+		case <-time.After(2 * time.Second):
+			t.Fatal("the method context was not cancelled when the client disconnected")
+		}
+	})
+
+	t.Run("Client Events", func(t *testing.T) {
+		ts, _ := newStreamServer(t, 0)
+		client := newStreamClient(t)
+
+		stream := mustStream(t, client, ts.URL+"/stream/Feed?count=3")
+		var ids []string
+		for stream.Next() {
+			require.Equal(t, "tick", stream.Event().Name)
+			ids = append(ids, stream.Event().ID)
+		}
+		require.NoError(t, stream.Err())
+		require.Equal(t, []string{"1", "2", "3"}, ids)
+		require.Equal(t, "3", stream.LastEventID())
+
+		var ticks []int
+		stream, err := client.Stream(context.Background(), "GET", ts.URL+"/stream/Ticks?count=3", nil, WithAccept(MediaTypeNDJSON))
+		require.NoError(t, err)
+		for v, err := range Events[tick](stream) {
+			require.NoError(t, err)
+			ticks = append(ticks, v.N)
+		}
+		require.Equal(t, []int{1, 2, 3}, ticks)
+	})
+
+	t.Run("Client Reconnects With Last-Event-ID", func(t *testing.T) {
+		var lastIDs []string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lastIDs = append(lastIDs, r.Header.Get("Last-Event-ID"))
+			w.Header().Set("Content-Type", MediaTypeEventStream)
+			start, _ := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+			// Each connection sends one event, then is cut
+			fmt.Fprintf(w, "retry: 5\nid: %d\ndata: {\"n\":%d}\n\n", start+1, start+1)
+			if start+1 == 3 {
+				fmt.Fprint(w, "event: end\ndata: null\n\n")
+			}
+		}))
+		defer ts.Close()
+
+		var ticks []int
+		for v, err := range Events[tick](mustStream(t, newStreamClient(t), ts.URL)) {
+			require.NoError(t, err)
+			ticks = append(ticks, v.N)
+		}
+		require.Equal(t, []int{1, 2, 3}, ticks)
+		require.Equal(t, []string{"", "1", "2"}, lastIDs)
+	})
+
+	t.Run("Client Gives Up", func(t *testing.T) {
+		connections := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			connections++
+			if connections > 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Header().Set("Content-Type", MediaTypeEventStream)
+			fmt.Fprint(w, "data: {\"n\":1}\n\n")
+		}))
+		defer ts.Close()
+
+		stream := mustStream(t, newStreamClient(t), ts.URL)
+		require.True(t, stream.Next())
+		require.False(t, stream.Next())
+		require.ErrorContains(t, stream.Err(), "event stream lost after 2 reconnections")
+	})
+
+	t.Run("Documented Stream", func(t *testing.T) {
+		ts, _ := newStreamServer(t, 0)
+		doc, _ := fetchDocument(t, ts)
+		paths := doc["paths"].(map[string]interface{})
+		responses := paths["/stream/Ticks"].(map[string]interface{})["get"].(map[string]interface{})["responses"].(map[string]interface{})
+		content := responses["200"].(map[string]interface{})["content"].(map[string]interface{})
+		require.Contains(t, content, MediaTypeEventStream)
+		require.Contains(t, content, MediaTypeNDJSON)
+		schema := content[MediaTypeNDJSON].(map[string]interface{})["schema"].(map[string]interface{})
+		require.Equal(t, "#/components/schemas/tick", schema["$ref"])
+	})
+}
+
+// mustStream opens an event stream of a GET request
+func mustStream(t *testing.T, client *HTTPClient, url string) *EventStream {
+	stream, err := client.Stream(context.Background(), "GET", url, nil)
+	require.NoError(t, err)
+	return stream
+}
+
+// readAll reads a response body as a string
+func readAll(t *testing.T, resp *http.Response) string {
+	var b strings.Builder
+	_, err := bufio.NewReader(resp.Body).WriteTo(&b)
+	require.NoError(t, err)
+	return b.String()
+}
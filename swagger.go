@@ -1,127 +1,176 @@
 package httpc
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 )
 
-// generateSchema generates a Swagger schema for a given type
-func generateSchema(t reflect.Type) map[string]interface{} {
-	schema := map[string]interface{}{
-		"type":       "object",
-		"properties": map[string]interface{}{},
-	}
-
-	if t.Kind() == reflect.Ptr {
+// queryParameters documents the query parameters bound for a GET method:
+// the QueryParam for string inputs, or one parameter per bound struct field
+func queryParameters(method MethodInfo, rules *validationRules) []map[string]interface{} {
+	t := method.InputType
+	for t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
-
 	if t.Kind() != reflect.Struct {
-		return map[string]interface{}{
-			"type": t.Kind().String(),
+		return []map[string]interface{}{
+			{
+				"name":     method.queryParam(),
+				"in":       "query",
+				"required": false,
+				"schema":   primitiveSchema(t),
+			},
 		}
 	}
+	params := []map[string]interface{}{}
+	appendQueryParameters(&params, t, "", rules)
+	return params
+}
 
-	properties := schema["properties"].(map[string]interface{})
-	var required []string
-
+func appendQueryParameters(params *[]map[string]interface{}, t reflect.Type, prefix string, rules *validationRules) {
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
-		jsonTag := field.Tag.Get("json")
-		if jsonTag == "" || jsonTag == "-" {
+		if !field.IsExported() {
+			continue
+		}
+		key, skip := fieldKey(field, queryTags)
+		if skip || boundElsewhere(field, queryTags) {
 			continue
 		}
 
-		jsonName := strings.Split(jsonTag, ",")[0]
-		validateTag := field.Tag.Get("validate")
-		fieldSchema := map[string]interface{}{}
+		ft := field.Type
+		if field.Anonymous && ft.Kind() == reflect.Struct && !isScalarType(ft) {
+			appendQueryParameters(params, ft, prefix, rules)
+			continue
+		}
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && !isScalarType(ft) {
+			appendQueryParameters(params, ft, prefix+key+".", rules)
+			continue
+		}
 
-		switch field.Type.Kind() {
-		case reflect.String:
-			fieldSchema["type"] = "string"
-			if strings.Contains(validateTag, "min=") {
-				for _, part := range strings.Split(validateTag, ",") {
-					if strings.HasPrefix(part, "min=") {
-						if min, err := parseInt(strings.TrimPrefix(part, "min=")); err == nil {
-							fieldSchema["minLength"] = float64(min)
-						}
-					}
-				}
-			}
-			if strings.Contains(validateTag, "max=") {
-				for _, part := range strings.Split(validateTag, ",") {
-					if strings.HasPrefix(part, "max=") {
-						if max, err := parseInt(strings.TrimPrefix(part, "max=")); err == nil {
-							fieldSchema["maxLength"] = float64(max)
-						}
-					}
-				}
-			}
-			if strings.Contains(validateTag, "email") {
-				fieldSchema["format"] = "email"
-			}
-		case reflect.Int, reflect.Int32, reflect.Int64:
-			fieldSchema["type"] = "integer"
-			if strings.Contains(validateTag, "gte=") {
-				for _, part := range strings.Split(validateTag, ",") {
-					if strings.HasPrefix(part, "gte=") {
-						if min, err := parseInt(strings.TrimPrefix(part, "gte=")); err == nil {
-							fieldSchema["minimum"] = float64(min)
-						}
-					}
-				}
-			}
-			if strings.Contains(validateTag, "lte=") {
-				for _, part := range strings.Split(validateTag, ",") {
-					if strings.HasPrefix(part, "lte=") {
-						if max, err := parseInt(strings.TrimPrefix(part, "lte=")); err == nil {
-							fieldSchema["maximum"] = float64(max)
-						}
-					}
-				}
-			}
-		case reflect.Float32, reflect.Float64:
-			fieldSchema["type"] = "number"
-			if strings.Contains(validateTag, "gte=") {
-				for _, part := range strings.Split(validateTag, ",") {
-					if strings.HasPrefix(part, "gte=") {
-						if min, err := parseFloat(strings.TrimPrefix(part, "gte=")); err == nil {
-							fieldSchema["minimum"] = min
-						}
-					}
-				}
-			}
-		case reflect.Struct:
-			fieldSchema = generateSchema(field.Type)
+		validateTag := rules.expand(field.Tag.Get("validate"))
+		required := field.Type.Kind() != reflect.Ptr && hasValidateRule(validateTag, "required")
+		schema := primitiveSchema(ft)
+		applyValidateRules(schema, ft, validateTag, rules)
+		param := map[string]interface{}{
+			"name":     prefix + key,
+			"in":       "query",
+			"required": required,
+			"schema":   schema,
+		}
+		annotateParameter(param, field)
+		if ft.Kind() == reflect.Slice {
+			param["style"] = "form"
+			param["explode"] = true
 		}
+		*params = append(*params, param)
+	}
+}
 
-		if strings.Contains(validateTag, "required") {
-			required = append(required, jsonName)
+// sourceParameters documents the path, header and cookie fields of a struct
+// input, plus its `query` fields when the query string is not bound as a whole
+func sourceParameters(method MethodInfo, rules *validationRules) []map[string]interface{} {
+	var params []map[string]interface{}
+	for _, f := range sourceFields(method.InputType) {
+		if f.source == sourceBody || (f.source == sourceQuery && method.HTTPMethod == "GET") {
+			continue
+		}
+		validateTag := rules.expand(f.field.Tag.Get("validate"))
+		required := f.source == sourcePath ||
+			(f.field.Type.Kind() != reflect.Ptr && hasValidateRule(validateTag, "required"))
+		schema := primitiveSchema(f.field.Type)
+		applyValidateRules(schema, f.field.Type, validateTag, rules)
+		param := map[string]interface{}{
+			"name":     f.key,
+			"in":       f.source,
+			"required": required,
+			"schema":   schema,
 		}
+		annotateParameter(param, f.field)
+		params = append(params, param)
+	}
+	return params
+}
 
-		properties[jsonName] = fieldSchema
+// primitiveSchema returns the schema for a query-bindable type
+func primitiveSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == durationType:
+		return map[string]interface{}{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": float64(0)}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": primitiveSchema(t.Elem())}
 	}
+	return map[string]interface{}{"type": "string"}
+}
 
-	if len(required) > 0 {
-		schema["required"] = required
+// hasValidateRule reports whether a validate tag contains rule, with or without a parameter
+func hasValidateRule(validateTag, rule string) bool {
+	for _, part := range strings.Split(validateTag, ",") {
+		if part == rule || strings.HasPrefix(part, rule+"=") {
+			return true
+		}
 	}
+	return false
+}
 
-	return schema
+// setExample adds example to every media type of content, unless it is nil
+func setExample(content map[string]interface{}, example interface{}) {
+	if example == nil {
+		return
+	}
+	for _, mediaType := range content {
+		mediaType.(map[string]interface{})["example"] = example
+	}
+}
+
+// mediaTypeContent lists schema under every registered media type able to encode t
+func mediaTypeContent(t reflect.Type, schema map[string]interface{}) map[string]interface{} {
+	content := map[string]interface{}{}
+	for _, c := range codecsFor(t) {
+		content[c.MediaType()] = map[string]interface{}{
+			"schema": schema,
+		}
+	}
+	return content
 }
 
-// parseInt is a helper function to parse string to int
-func parseInt(s string) (int, error) {
-	var result int
-	_, err := fmt.Sscanf(s, "%d", &result)
-	return result, err
+// binarySchema describes binary content, such as a file
+func binarySchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string", "format": "binary"}
 }
 
-// parseFloat is a helper function to parse string to float64
-func parseFloat(s string) (float64, error) {
-	var result float64
-	_, err := fmt.Sscanf(s, "%f", &result)
-	return result, err
+// streamContent lists the schema of the items of a streamed response under
+// each stream format. The data of Event items is not described.
+func streamContent(schemas *schemaRegistry, item reflect.Type) map[string]interface{} {
+	schema := map[string]interface{}{}
+	if item != eventType && item != reflect.PointerTo(eventType) {
+		schema = schemas.generateSchema(item)
+	}
+	return map[string]interface{}{
+		MediaTypeEventStream: map[string]interface{}{"schema": schema},
+		MediaTypeNDJSON:      map[string]interface{}{"schema": schema},
+	}
 }
 
 // updateSwaggerDoc updates the Swagger documentation for the given service
@@ -141,11 +190,22 @@ func updateSwaggerDoc(s *Server, service interface{}, prefix string) error {
 			"paths": map[string]interface{}{},
 		}
 	}
+	if s.schemas == nil {
+		s.schemas = newSchemaRegistry(s.rules)
+	}
+	components, ok := s.swagger["components"].(map[string]interface{})
+	if !ok {
+		components = map[string]interface{}{}
+		s.swagger["components"] = components
+	}
+	components["schemas"] = s.schemas.schemas
+	errorSchema := s.schemas.generateSchema(reflect.TypeOf(ErrorResponse{}))
 
 	info, err := getServiceInfo(service)
 	if err != nil {
 		return err
 	}
+	defaultTag := serviceTag(service, prefix)
 
 	paths := s.swagger["paths"].(map[string]interface{})
 	for _, method := range info {
@@ -154,86 +214,104 @@ func updateSwaggerDoc(s *Server, service interface{}, prefix string) error {
 			continue
 		}
 
-		path := prefix + "/" + method.Name
-		if !strings.HasPrefix(path, "/") {
-			path = "/" + path
-		}
+		path := openAPIPath(routePath(prefix, method))
 
 		pathItem := map[string]interface{}{}
 		if existing, ok := paths[path]; ok {
 			pathItem = existing.(map[string]interface{})
 		}
 
+		if method.Kind == MethodWebSocket {
+			operation := map[string]interface{}{
+				"operationId": uniqueOperationID(paths, path, method, defaultTag),
+				"summary":     method.summary(),
+				"responses": map[string]interface{}{
+					"101": map[string]interface{}{"description": "Switching to a WebSocket exchanging JSON messages"},
+				},
+				// OpenAPI cannot describe WebSocket messages, so an extension lists their schemas
+				"x-websocket": map[string]interface{}{
+					"clientMessage": s.schemas.generateSchema(method.InputType),
+					"serverMessage": s.schemas.generateSchema(method.OutputType),
+				},
+			}
+			describeOperation(operation, method, defaultTag)
+			pathItem["get"] = operation
+			paths[path] = pathItem
+			continue
+		}
+
+		var okContent map[string]interface{}
+		if kind, item := streamOf(method.OutputType); kind != streamNone {
+			okContent = streamContent(s.schemas, item)
+		} else if isDownload(method.OutputType) {
+			okContent = map[string]interface{}{
+				MediaTypeOctetStream: map[string]interface{}{"schema": binarySchema()},
+			}
+		} else {
+			okContent = mediaTypeContent(method.OutputType, s.schemas.generateSchema(method.OutputType))
+		}
+		setExample(okContent, method.ResponseExample)
 		operation := map[string]interface{}{
-			"operationId": method.Name,
+			"operationId": uniqueOperationID(paths, path, method, defaultTag),
+			"summary":     method.summary(),
 			"responses": map[string]interface{}{
 				"200": map[string]interface{}{
 					"description": "Successful response",
-					"content": map[string]interface{}{
-						"application/json": map[string]interface{}{
-							"schema": map[string]interface{}{
-								"type": method.OutputType.Kind().String(),
-							},
-						},
-					},
+					"content":     okContent,
 				},
 				"400": map[string]interface{}{
 					"description": "Bad request",
 					"content": map[string]interface{}{
-						"application/json": map[string]interface{}{
-							"schema": map[string]interface{}{
-								"type": "object",
-								"properties": map[string]interface{}{
-									"error": map[string]interface{}{
-										"type": "string",
-									},
-								},
-							},
-						},
+						MediaTypeJSON: map[string]interface{}{"schema": errorSchema},
 					},
 				},
 				"500": map[string]interface{}{
 					"description": "Internal server error",
 					"content": map[string]interface{}{
-						"application/json": map[string]interface{}{
-							"schema": map[string]interface{}{
-								"type": "object",
-								"properties": map[string]interface{}{
-									"error": map[string]interface{}{
-										"type": "string",
-									},
-								},
-							},
-						},
+						MediaTypeJSON: map[string]interface{}{"schema": errorSchema},
 					},
 				},
 			},
-			"summary": method.Name,
+		}
+		describeOperation(operation, method, defaultTag)
+		if isDownload(method.OutputType) {
+			operation["responses"].(map[string]interface{})["206"] = map[string]interface{}{
+				"description": "Partial content of a Range request",
+				"content":     okContent,
+			}
+		} else if kind, _ := streamOf(method.OutputType); method.Cache != nil && kind == streamNone && method.HTTPMethod == "GET" {
+			operation["responses"].(map[string]interface{})["304"] = map[string]interface{}{
+				"description": "Not modified since the representation identified by If-None-Match",
+			}
 		}
 
+		params := sourceParameters(method, s.rules)
 		if method.HTTPMethod == "GET" {
-			operation["parameters"] = []map[string]interface{}{
-				{
-					"name":     "name",
-					"in":       "query",
-					"required": false,
-					"schema": map[string]interface{}{
-						"type": "string",
-					},
-				},
-			}
+			params = append(queryParameters(method, s.rules), params...)
 		} else {
 			// POST, PUT, DELETE, PATCH, OPTIONS, HEAD
-			schema := generateSchema(method.InputType)
-			operation["requestBody"] = map[string]interface{}{
-				"content": map[string]interface{}{
-					"application/json": map[string]interface{}{
-						"schema": schema,
-					},
-				},
-				"required": true,
+			bodyType := method.InputType
+			if body, ok := bodyField(bodyType); ok {
+				bodyType = body.field.Type
+			}
+			if hasBodyFields(bodyType) || len(params) == 0 {
+				content := mediaTypeContent(bodyType, s.schemas.generateSchema(bodyType))
+				if len(fileFields(bodyType)) > 0 {
+					// Files are only uploaded as multipart/form-data
+					content = map[string]interface{}{
+						MediaTypeMultipart: map[string]interface{}{"schema": s.schemas.generateSchema(bodyType)},
+					}
+				}
+				setExample(content, method.RequestExample)
+				operation["requestBody"] = map[string]interface{}{
+					"content":  content,
+					"required": true,
+				}
 			}
 		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
 
 		pathItem[strings.ToLower(method.HTTPMethod)] = operation
 		paths[path] = pathItem
@@ -241,3 +319,183 @@ func updateSwaggerDoc(s *Server, service interface{}, prefix string) error {
 
 	return nil
 }
+
+// describeOperation adds the description, tags and deprecation of method to operation
+func describeOperation(operation map[string]interface{}, method MethodInfo, defaultTag string) {
+	setNonEmpty(operation, "description", method.Description)
+	if tags := method.tags(defaultTag); len(tags) > 0 {
+		operation["tags"] = tags
+	}
+	if method.Deprecated {
+		operation["deprecated"] = true
+	}
+}
+
+// uniqueOperationID returns the operationId of a method. A default operationId
+// already used by another operation of the document is qualified with the
+// service tag; explicit ones are kept so validation reports the clash.
+func uniqueOperationID(paths map[string]interface{}, path string, method MethodInfo, tag string) string {
+	id := method.operationID()
+	if method.OperationID != "" {
+		return id
+	}
+	taken := map[string]bool{}
+	for p, item := range paths {
+		for m, operation := range item.(map[string]interface{}) {
+			if p == path && m == strings.ToLower(method.HTTPMethod) {
+				continue
+			}
+			if operation, ok := operation.(map[string]interface{}); ok {
+				if opID, ok := operation["operationId"].(string); ok {
+					taken[opID] = true
+				}
+			}
+		}
+	}
+	if !taken[id] {
+		return id
+	}
+	id = operationIDPrefix.ReplaceAllString(tag, "_") + "_" + id
+	candidate := id
+	for n := 2; taken[candidate]; n++ {
+		candidate = fmt.Sprintf("%s_%d", id, n)
+	}
+	return candidate
+}
+
+// operationIDPrefix matches the characters of a tag not kept in operationIds
+var operationIDPrefix = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// ServerOption configures a Server created by NewServer
+type ServerOption func(*Server)
+
+// APIInfo describes the API in the info object of the OpenAPI document
+type APIInfo struct {
+	Title          string
+	Version        string
+	Description    string
+	TermsOfService string
+	Contact        *APIContact
+	License        *APILicense
+}
+
+// APIContact is the contact information of the API
+type APIContact struct {
+	Name  string
+	URL   string
+	Email string
+}
+
+// APILicense is the license of the API
+type APILicense struct {
+	Name string
+	URL  string
+}
+
+// APIServer is a base URL the API is served from
+type APIServer struct {
+	URL         string
+	Description string
+}
+
+// WithAPIInfo sets the info object of the OpenAPI document. Empty title and
+// version keep the defaults.
+func WithAPIInfo(info APIInfo) ServerOption {
+	return func(s *Server) {
+		doc := s.swagger["info"].(map[string]interface{})
+		setNonEmpty(doc, "title", info.Title)
+		setNonEmpty(doc, "version", info.Version)
+		setNonEmpty(doc, "description", info.Description)
+		setNonEmpty(doc, "termsOfService", info.TermsOfService)
+		if info.Contact != nil {
+			contact := map[string]interface{}{}
+			setNonEmpty(contact, "name", info.Contact.Name)
+			setNonEmpty(contact, "url", info.Contact.URL)
+			setNonEmpty(contact, "email", info.Contact.Email)
+			doc["contact"] = contact
+		}
+		if info.License != nil {
+			license := map[string]interface{}{"name": info.License.Name}
+			setNonEmpty(license, "url", info.License.URL)
+			doc["license"] = license
+		}
+	}
+}
+
+// WithAPIServers lists the base URLs of the API in the OpenAPI document
+func WithAPIServers(servers ...APIServer) ServerOption {
+	return func(s *Server) {
+		list := make([]interface{}, 0, len(servers))
+		for _, server := range servers {
+			entry := map[string]interface{}{"url": server.URL}
+			setNonEmpty(entry, "description", server.Description)
+			list = append(list, entry)
+		}
+		s.swagger["servers"] = list
+	}
+}
+
+func setNonEmpty(m map[string]interface{}, key, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
+
+// serviceTag returns the default tag of a service's operations: its path
+// prefix, or its type name when registered at the root
+func serviceTag(service interface{}, prefix string) string {
+	if tag := strings.Trim(prefix, "/"); tag != "" {
+		return tag
+	}
+	t := reflect.TypeOf(service)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// annotateSchema adds the `description` and `example` tags of a field to its
+// schema. A $ref is wrapped in allOf, as siblings of $ref are ignored.
+func annotateSchema(schema map[string]interface{}, field reflect.StructField) map[string]interface{} {
+	description, hasDescription := field.Tag.Lookup("description")
+	example, hasExample := field.Tag.Lookup("example")
+	if !hasDescription && !hasExample {
+		return schema
+	}
+	if _, ok := schema["$ref"]; ok {
+		schema = map[string]interface{}{"allOf": []interface{}{schema}}
+	}
+	if hasDescription {
+		schema["description"] = description
+	}
+	if hasExample {
+		schema["example"] = exampleValue(field.Type, example)
+	}
+	return schema
+}
+
+// annotateParameter adds the `description` and `example` tags of a field to its parameter
+func annotateParameter(param map[string]interface{}, field reflect.StructField) {
+	if description, ok := field.Tag.Lookup("description"); ok {
+		param["description"] = description
+	}
+	if example, ok := field.Tag.Lookup("example"); ok {
+		param["example"] = exampleValue(field.Type, example)
+	}
+}
+
+// exampleValue converts an `example` tag to the JSON type of t. Strings are
+// kept as written; other types are parsed as JSON, falling back to the raw text.
+func exampleValue(t reflect.Type, example string) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.String || t == timeType {
+		return example
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(example), &value); err == nil {
+		return value
+	}
+	return example
+}
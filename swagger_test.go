@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
+	config "github.com/T-Prohmpossadhorn/go-core-config"
 	logger "github.com/T-Prohmpossadhorn/go-core-logger"
 	"github.com/stretchr/testify/require"
 )
@@ -83,8 +85,7 @@ func TestSwagger(t *testing.T) {
 		require.True(t, ok)
 		jsonContent, ok := content["application/json"].(map[string]interface{})
 		require.True(t, ok)
-		schema, ok := jsonContent["schema"].(map[string]interface{})
-		require.True(t, ok)
+		schema := resolveRef(t, doc, jsonContent["schema"])
 		properties, ok := schema["properties"].(map[string]interface{})
 		require.True(t, ok)
 		require.Contains(t, properties, "name")
@@ -103,4 +104,135 @@ func TestSwagger(t *testing.T) {
 		require.NoError(t, err)
 		require.Contains(t, string(body), "Swagger UI")
 	})
+
+	t.Run("Query Parameters", func(t *testing.T) {
+		svc := &SearchService{}
+		ts := setupServer(t, serverCfg, svc, "/v1")
+		defer ts.Close()
+
+		resp, err := http.Get(ts.URL + "/api/docs/swagger.json")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var doc map[string]interface{}
+		err = json.NewDecoder(resp.Body).Decode(&doc)
+		require.NoError(t, err)
+		paths := doc["paths"].(map[string]interface{})
+
+		search := paths["/v1/Search"].(map[string]interface{})["get"].(map[string]interface{})
+		params := map[string]map[string]interface{}{}
+		for _, p := range search["parameters"].([]interface{}) {
+			param := p.(map[string]interface{})
+			require.Equal(t, "query", param["in"])
+			params[param["name"].(string)] = param
+		}
+		require.Len(t, params, 6)
+		require.Equal(t, true, params["q"]["required"])
+		require.Equal(t, "string", params["q"]["schema"].(map[string]interface{})["type"])
+		require.Equal(t, "array", params["tag"]["schema"].(map[string]interface{})["type"])
+		require.Equal(t, true, params["tag"]["explode"])
+		require.Equal(t, false, params["limit"]["required"])
+		require.Equal(t, "integer", params["limit"]["schema"].(map[string]interface{})["type"])
+		require.Equal(t, "date-time", params["since"]["schema"].(map[string]interface{})["format"])
+		require.Contains(t, params, "page.number")
+		require.Contains(t, params, "page.size")
+
+		lookup := paths["/v1/Lookup"].(map[string]interface{})["get"].(map[string]interface{})
+		lookupParams := lookup["parameters"].([]interface{})
+		require.Len(t, lookupParams, 1)
+		require.Equal(t, "id", lookupParams[0].(map[string]interface{})["name"])
+	})
+	t.Run("Component Schemas", func(t *testing.T) {
+		svc := &CustomPathService{}
+		ts := setupServer(t, serverCfg, svc, "/v1")
+		defer ts.Close()
+
+		resp, err := http.Get(ts.URL + "/api/docs/swagger.json")
+		require.NoError(t, err)
+		var doc map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&doc))
+		components := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+		require.Contains(t, components, "CustomInput")
+		require.Contains(t, components, "CustomOutput")
+		require.Contains(t, components, "ErrorResponse")
+		require.Contains(t, components, "FieldError")
+
+		post := doc["paths"].(map[string]interface{})["/v1/Process"].(map[string]interface{})["post"].(map[string]interface{})
+		responses := post["responses"].(map[string]interface{})
+		okSchema := responses["200"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"]
+		require.Equal(t, map[string]interface{}{"$ref": "#/components/schemas/CustomOutput"}, okSchema)
+		require.Contains(t, resolveRef(t, doc, okSchema)["properties"], "result")
+
+		for _, status := range []string{"400", "500"} {
+			errSchema := responses[status].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"]
+			require.Equal(t, map[string]interface{}{"$ref": "#/components/schemas/ErrorResponse"}, errSchema)
+		}
+		fields := resolveRef(t, doc, components["ErrorResponse"])["properties"].(map[string]interface{})["fields"].(map[string]interface{})
+		require.Equal(t, map[string]interface{}{"$ref": "#/components/schemas/FieldError"}, fields["items"])
+	})
+	t.Run("Metadata", func(t *testing.T) {
+		cfgMap, err := toConfigMap(serverCfg)
+		require.NoError(t, err)
+		c, err := config.New(config.WithDefault(cfgMap))
+		require.NoError(t, err)
+		srv, err := NewServer(c,
+			WithAPIInfo(APIInfo{
+				Title:       "Catalog API",
+				Version:     "2.1.0",
+				Description: "Books and authors",
+				Contact:     &APIContact{Name: "Catalog Team", Email: "catalog@example.com"},
+				License:     &APILicense{Name: "MIT", URL: "https://opensource.org/licenses/MIT"},
+			}),
+			WithAPIServers(APIServer{URL: "https://api.example.com", Description: "Production"}),
+		)
+		require.NoError(t, err)
+		require.NoError(t, srv.RegisterService(&CatalogService{}, WithPathPrefix("/catalog")))
+		ts := httptest.NewServer(srv.engine)
+		defer ts.Close()
+
+		resp, err := http.Get(ts.URL + "/api/docs/swagger.json")
+		require.NoError(t, err)
+		var doc map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&doc))
+
+		require.Equal(t, map[string]interface{}{
+			"title":       "Catalog API",
+			"version":     "2.1.0",
+			"description": "Books and authors",
+			"contact":     map[string]interface{}{"name": "Catalog Team", "email": "catalog@example.com"},
+			"license":     map[string]interface{}{"name": "MIT", "url": "https://opensource.org/licenses/MIT"},
+		}, doc["info"])
+		require.Equal(t, []interface{}{map[string]interface{}{"url": "https://api.example.com", "description": "Production"}}, doc["servers"])
+
+		paths := doc["paths"].(map[string]interface{})
+		get := paths["/catalog/GetBook"].(map[string]interface{})["get"].(map[string]interface{})
+		require.Equal(t, "Look up a book", get["summary"])
+		require.Equal(t, "Returns the book with the given ISBN.", get["description"])
+		require.Equal(t, []interface{}{"books"}, get["tags"])
+		require.Equal(t, "getBook", get["operationId"])
+		require.NotContains(t, get, "deprecated")
+		param := get["parameters"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, "ISBN to look up", param["description"])
+		require.Equal(t, "9780134190440", param["example"])
+		okJSON := get["responses"].(map[string]interface{})["200"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})
+		require.Equal(t, "The Go Programming Language", okJSON["example"].(map[string]interface{})["title"])
+
+		post := paths["/catalog/AddBook"].(map[string]interface{})["post"].(map[string]interface{})
+		require.Equal(t, "AddBook", post["summary"])
+		require.Equal(t, "AddBook", post["operationId"])
+		require.Equal(t, []interface{}{"catalog"}, post["tags"], "tags default to the service prefix")
+		require.Equal(t, true, post["deprecated"])
+		reqJSON := post["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})
+		require.Equal(t, "9780134190440", reqJSON["example"].(map[string]interface{})["isbn"])
+
+		book := resolveRef(t, doc, reqJSON["schema"])["properties"].(map[string]interface{})
+		require.Equal(t, "ISBN-13 of the book", book["isbn"].(map[string]interface{})["description"])
+		require.Equal(t, "9780134190440", book["isbn"].(map[string]interface{})["example"])
+		require.Equal(t, float64(380), book["pages"].(map[string]interface{})["example"])
+		require.Equal(t, map[string]interface{}{
+			"allOf":       []interface{}{map[string]interface{}{"$ref": "#/components/schemas/BookAuthor"}},
+			"nullable":    true,
+			"description": "Primary author",
+		}, book["author"])
+	})
 }
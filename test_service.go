@@ -1,8 +1,12 @@
 package httpc
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"strings"
+	"time"
 )
 
 // User for testing
@@ -181,3 +185,242 @@ func (s CustomPathService) RegisterMethods() []MethodInfo {
 		},
 	}
 }
+
+// PageQuery for testing
+type PageQuery struct {
+	Number int `query:"number"`
+	Size   int `query:"size"`
+}
+
+// SearchQuery for testing
+type SearchQuery struct {
+	Term  string    `query:"q" validate:"required"`
+	Tags  []string  `query:"tag"`
+	Limit *int      `query:"limit" validate:"omitempty,gte=1,lte=100"`
+	Since time.Time `query:"since" time_format:"2006-01-02"`
+	Page  PageQuery `query:"page"`
+}
+
+// SearchResult for testing
+type SearchResult struct {
+	Summary string `json:"summary"`
+}
+
+// SearchService for testing
+type SearchService struct{}
+
+func (s SearchService) Search(q SearchQuery) (SearchResult, error) {
+	limit := "none"
+	if q.Limit != nil {
+		limit = fmt.Sprint(*q.Limit)
+	}
+	return SearchResult{Summary: fmt.Sprintf("q=%s tags=%s limit=%s since=%s page=%d/%d",
+		q.Term, strings.Join(q.Tags, "|"), limit, q.Since.Format("2006-01-02"), q.Page.Number, q.Page.Size)}, nil
+}
+
+func (s SearchService) Lookup(id string) (SearchResult, error) {
+	return SearchResult{Summary: "id=" + id}, nil
+}
+
+func (s SearchService) RegisterMethods() []MethodInfo {
+	return []MethodInfo{
+		{
+			Name:       "Search",
+			HTTPMethod: "GET",
+			InputType:  reflect.TypeOf(SearchQuery{}),
+			OutputType: reflect.TypeOf(SearchResult{}),
+			Func:       reflect.ValueOf(s).MethodByName("Search"),
+		},
+		{
+			Name:       "Lookup",
+			HTTPMethod: "GET",
+			InputType:  reflect.TypeOf(""),
+			OutputType: reflect.TypeOf(SearchResult{}),
+			Func:       reflect.ValueOf(s).MethodByName("Lookup"),
+			QueryParam: "id",
+		},
+	}
+}
+
+// UserPatch for testing
+type UserPatch struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"omitempty,email"`
+}
+
+// UpdateUserInput for testing
+type UpdateUserInput struct {
+	ID      int       `path:"id" validate:"gte=1"`
+	Tenant  string    `header:"X-Tenant" validate:"required"`
+	Session string    `cookie:"session"`
+	DryRun  bool      `query:"dry_run"`
+	Payload UserPatch `body:""`
+}
+
+// DeleteUserInput for testing
+type DeleteUserInput struct {
+	ID     int    `path:"id" validate:"gte=1"`
+	Tenant string `header:"X-Tenant"`
+}
+
+// UserService for testing
+type UserService struct{}
+
+func (s UserService) Update(in UpdateUserInput) (string, error) {
+	return fmt.Sprintf("updated %d for %s (session=%s dry_run=%t): %s", in.ID, in.Tenant, in.Session, in.DryRun, in.Payload.Name), nil
+}
+
+func (s UserService) Delete(in DeleteUserInput) (string, error) {
+	return fmt.Sprintf("deleted %d for %s", in.ID, in.Tenant), nil
+}
+
+func (s UserService) RegisterMethods() []MethodInfo {
+	return []MethodInfo{
+		{
+			Name:       "Update",
+			HTTPMethod: "PUT",
+			InputType:  reflect.TypeOf(UpdateUserInput{}),
+			OutputType: reflect.TypeOf(""),
+			Func:       reflect.ValueOf(s).MethodByName("Update"),
+		},
+		{
+			Name:       "Delete",
+			HTTPMethod: "DELETE",
+			InputType:  reflect.TypeOf(DeleteUserInput{}),
+			OutputType: reflect.TypeOf(""),
+			Func:       reflect.ValueOf(s).MethodByName("Delete"),
+			Path:       "users/:id",
+		},
+	}
+}
+
+// OrderItem for testing
+type OrderItem struct {
+	SKU      string `json:"sku" validate:"required"`
+	Quantity int    `json:"quantity" validate:"gte=1,lte=10"`
+}
+
+// OrderInput for testing
+type OrderInput struct {
+	Customer UserPatch   `json:"customer"`
+	Items    []OrderItem `json:"items" validate:"required,min=1,dive"`
+}
+
+// OrderService for testing
+type OrderService struct{}
+
+func (s OrderService) Place(in OrderInput) (string, error) {
+	return fmt.Sprintf("placed %d items for %s", len(in.Items), in.Customer.Name), nil
+}
+
+func (s OrderService) RegisterMethods() []MethodInfo {
+	return []MethodInfo{
+		{
+			Name:       "Place",
+			HTTPMethod: "POST",
+			InputType:  reflect.TypeOf(OrderInput{}),
+			OutputType: reflect.TypeOf(""),
+			Func:       reflect.ValueOf(s).MethodByName("Place"),
+		},
+	}
+}
+
+// BookAuthor for testing
+type BookAuthor struct {
+	Name string `json:"name" example:"Alan Donovan"`
+}
+
+// Book for testing
+type Book struct {
+	ISBN   string      `json:"isbn" validate:"required" description:"ISBN-13 of the book" example:"9780134190440"`
+	Title  string      `json:"title" validate:"required" description:"Title of the book"`
+	Pages  int         `json:"pages" validate:"gte=1" example:"380"`
+	Author *BookAuthor `json:"author" description:"Primary author"`
+}
+
+// BookQuery for testing
+type BookQuery struct {
+	ISBN string `query:"isbn" validate:"required" description:"ISBN to look up" example:"9780134190440"`
+}
+
+// CatalogService for testing
+type CatalogService struct{}
+
+func (s CatalogService) GetBook(q BookQuery) (Book, error) {
+	return Book{ISBN: q.ISBN, Title: "The Go Programming Language", Pages: 380}, nil
+}
+
+func (s CatalogService) AddBook(b Book) (Book, error) {
+	return b, nil
+}
+
+func (s CatalogService) RegisterMethods() []MethodInfo {
+	example := Book{ISBN: "9780134190440", Title: "The Go Programming Language", Pages: 380}
+	return []MethodInfo{
+		{
+			Name:            "GetBook",
+			HTTPMethod:      "GET",
+			InputType:       reflect.TypeOf(BookQuery{}),
+			OutputType:      reflect.TypeOf(Book{}),
+			Func:            reflect.ValueOf(s).MethodByName("GetBook"),
+			Summary:         "Look up a book",
+			Description:     "Returns the book with the given ISBN.",
+			Tags:            []string{"books"},
+			OperationID:     "getBook",
+			ResponseExample: example,
+		},
+		{
+			Name:           "AddBook",
+			HTTPMethod:     "POST",
+			InputType:      reflect.TypeOf(Book{}),
+			OutputType:     reflect.TypeOf(Book{}),
+			Func:           reflect.ValueOf(s).MethodByName("AddBook"),
+			Deprecated:     true,
+			RequestExample: example,
+		},
+	}
+}
+
+// ChatMessage for testing
+type ChatMessage struct {
+	Text string `json:"text" validate:"required"`
+}
+
+// ChatReply for testing
+type ChatReply struct {
+	Text  string `json:"text"`
+	Error string `json:"error,omitempty"`
+}
+
+// ChatService for testing
+type ChatService struct{}
+
+func (s ChatService) Echo(ws *WebSocket[ChatMessage, ChatReply]) error {
+	for {
+		msg, err := ws.Receive()
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil
+		case errors.Is(err, ErrInvalidMessage):
+			err = ws.Send(ChatReply{Error: err.Error()})
+		case err == nil:
+			err = ws.Send(ChatReply{Text: strings.ToUpper(msg.Text)})
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s ChatService) RegisterMethods() []MethodInfo {
+	return []MethodInfo{
+		{
+			Name:       "Echo",
+			Kind:       MethodWebSocket,
+			InputType:  reflect.TypeOf(ChatMessage{}),
+			OutputType: reflect.TypeOf(ChatReply{}),
+			Func:       reflect.ValueOf(s).MethodByName("Echo"),
+			Summary:    "Echo chat messages in upper case",
+		},
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	config "github.com/T-Prohmpossadhorn/go-core-config"
@@ -82,3 +83,21 @@ func toConfigMap(cfg ServerConfig) (map[string]interface{}, error) {
 		"port":         cfg.Port,
 	}, nil
 }
+
+// resolveRef follows a $ref in a decoded OpenAPI document to the named schema
+func resolveRef(t *testing.T, doc map[string]interface{}, schema interface{}) map[string]interface{} {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		t.Fatalf("Schema is not an object: %v", schema)
+	}
+	ref, ok := s["$ref"].(string)
+	if !ok {
+		return s
+	}
+	components := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	target, ok := components[strings.TrimPrefix(ref, componentsPrefix)].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Unresolved schema reference %s", ref)
+	}
+	return target
+}
@@ -1,8 +1,10 @@
 package httpc
 
 import (
+	"net/http"
 	"reflect"
 	"strings"
+	"time"
 )
 
 // MethodInfo represents a service method's metadata
@@ -12,6 +14,87 @@ type MethodInfo struct {
 	InputType  reflect.Type
 	OutputType reflect.Type
 	Func       reflect.Value // Stores method function
+	QueryParam string        // Query parameter bound to string inputs on GET, defaults to "name"
+	Path       string        // Route relative to the service prefix, e.g. "users/:id"; defaults to Name
+	Kind       MethodKind    // MethodHTTP by default
+	Cache      *CachePolicy  // Makes GET responses cacheable with ETags, none when nil
+	StrictJSON bool          // Reject JSON bodies with unknown fields or duplicate keys
+
+	// OpenAPI documentation of the operation
+	Summary         string      // Defaults to Name
+	Description     string      // Longer explanation, may use CommonMark
+	Tags            []string    // Defaults to the service path prefix
+	OperationID     string      // Defaults to Name
+	Deprecated      bool        // Marks the operation as deprecated
+	RequestExample  interface{} // Example request body
+	ResponseExample interface{} // Example successful response
+}
+
+// MethodKind tells how a method is served
+type MethodKind int
+
+const (
+	// MethodHTTP serves a request and its response on HTTPMethod
+	MethodHTTP MethodKind = iota
+	// MethodWebSocket serves a WebSocket on GET, exchanging InputType and
+	// OutputType JSON messages. The method takes an optional context.Context and
+	// a *WebSocket[InputType, OutputType] and returns an error.
+	MethodWebSocket
+)
+
+// defaultQueryParam is the query parameter bound to string GET inputs when QueryParam is empty
+const defaultQueryParam = "name"
+
+// queryParam returns the query parameter name used for string GET inputs
+func (m MethodInfo) queryParam() string {
+	if m.QueryParam == "" {
+		return defaultQueryParam
+	}
+	return m.QueryParam
+}
+
+// summary returns the OpenAPI summary of the method
+func (m MethodInfo) summary() string {
+	if m.Summary == "" {
+		return m.Name
+	}
+	return m.Summary
+}
+
+// operationID returns the OpenAPI operationId of the method
+func (m MethodInfo) operationID() string {
+	if m.OperationID == "" {
+		return m.Name
+	}
+	return m.OperationID
+}
+
+// tags returns the OpenAPI tags of the method, defaulting to defaultTag
+func (m MethodInfo) tags(defaultTag string) []string {
+	if len(m.Tags) > 0 {
+		return m.Tags
+	}
+	if defaultTag == "" {
+		return nil
+	}
+	return []string{defaultTag}
+}
+
+// queryTags are the struct tags consulted, in order, when binding query parameters
+var queryTags = []string{"query", "form", "json"}
+
+// formTags are the struct tags consulted, in order, when binding form and
+// multipart bodies
+var formTags = []string{"form", "json"}
+
+// Response carries the metadata of the final response to an HTTPClient call
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte        // Raw response body
+	Attempts   int           // Number of attempts made, including the successful one
+	Duration   time.Duration // Total time spent, including retries and backoff
+	Cached     bool          // Answered by the client cache, after revalidation if Attempts is not 0
 }
 
 // ServiceOption configures service registration
@@ -19,6 +102,7 @@ type ServiceOption func(*serviceConfig)
 
 type serviceConfig struct {
 	prefix string
+	cors   *CORSPolicy // Overrides the server's policy when set
 }
 
 // WithPathPrefix sets a custom path prefix for endpoints
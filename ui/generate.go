@@ -0,0 +1,76 @@
+//go:build ignore
+
+// generate downloads the documentation UI assets listed in vendor/VERSIONS,
+// so they are embedded in the binary and served without network access.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// assetURLs maps a package of vendor/VERSIONS to the URL of one of its files
+var assetURLs = map[string]string{
+	"swagger-ui-dist": "https://unpkg.com/swagger-ui-dist@%s/%s",
+	"redoc":           "https://cdn.redoc.ly/redoc/v%s/bundles/%s",
+}
+
+func main() {
+	dir := filepath.Join("ui", "vendor")
+	versions, err := os.Open(filepath.Join(dir, "VERSIONS"))
+	if err != nil {
+		fail(err)
+	}
+	defer versions.Close()
+
+	scanner := bufio.NewScanner(versions)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		pattern, ok := assetURLs[fields[0]]
+		if !ok {
+			fail(fmt.Errorf("unknown package %s", fields[0]))
+		}
+		for _, file := range fields[2:] {
+			if err := download(fmt.Sprintf(pattern, fields[1], file), filepath.Join(dir, file)); err != nil {
+				fail(err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fail(err)
+	}
+}
+
+func download(url, path string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch %s: status %d", url, resp.StatusCode)
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Println("fetched", url)
+	return nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
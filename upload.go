@@ -0,0 +1,440 @@
+package httpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+// MediaTypeMultipart is the media type of requests uploading files
+const MediaTypeMultipart = "multipart/form-data"
+
+const (
+	defaultUploadMaxFileBytes    = 10 << 20
+	defaultUploadMaxRequestBytes = 32 << 20
+	maxFormValueBytes            = 1 << 20 // Largest text part of a multipart body
+	sniffLen                     = 512     // Bytes read by http.DetectContentType
+	uploadsKey                   = "httpc.uploads"
+)
+
+var fileType = reflect.TypeOf(File{})
+
+// File is a file of a multipart/form-data request. Input fields of type
+// *File or []*File are bound from the file parts named by their form or json
+// tag. On the server each file is streamed to a temporary file, removed once
+// the method returns; on the client NewFile wraps the content to upload.
+type File struct {
+	Filename     string               // Base name of the file given by the client
+	ContentType  string               // Content type declared by the client
+	DetectedType string               // Content type sniffed from the content, see http.DetectContentType
+	Size         int64                // Size in bytes, 0 if unknown
+	Header       textproto.MIMEHeader // Headers of the part
+
+	content io.Reader
+}
+
+// NewFile returns a file uploading the content of r as filename. Its content
+// type is guessed from the extension of filename; set ContentType to
+// override it. If r can seek, a failed upload is retried from its current
+// offset.
+func NewFile(filename string, r io.Reader) *File {
+	return &File{
+		Filename:    filename,
+		ContentType: mime.TypeByExtension(filepath.Ext(filename)),
+		content:     r,
+	}
+}
+
+// Read reads the content of the file
+func (f *File) Read(p []byte) (int, error) {
+	if f.content == nil {
+		return 0, io.EOF
+	}
+	return f.content.Read(p)
+}
+
+// Seek sets the offset of the next Read. Uploaded files on the server can
+// always seek.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := f.content.(io.Seeker)
+	if !ok {
+		return 0, fmt.Errorf("file %q cannot seek", f.Filename)
+	}
+	return seeker.Seek(offset, whence)
+}
+
+// uploadConfig limits the files accepted in multipart requests
+type uploadConfig struct {
+	maxFile    int64 // Largest file part
+	maxRequest int64 // Largest multipart body
+}
+
+// newUploadConfig reads the upload_* settings of c
+func newUploadConfig(c *config.Config) uploadConfig {
+	return uploadConfig{
+		maxFile:    int64(getIntConfig(c, "upload_max_file_bytes", defaultUploadMaxFileBytes)),
+		maxRequest: int64(getIntConfig(c, "upload_max_request_bytes", defaultUploadMaxRequestBytes)),
+	}
+}
+
+// fileFields lists the fields of t holding *File or []*File, including those
+// promoted from embedded structs, keyed by their form or json tag
+func fileFields(t reflect.Type) []sourceField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var fields []sourceField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if isFileType(field.Type) {
+			if key, skip := fieldKey(field, formTags); !skip {
+				fields = append(fields, sourceField{field: field, index: field.Index, key: key})
+			}
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for _, nested := range fileFields(field.Type) {
+				nested.index = append([]int{i}, nested.index...)
+				fields = append(fields, nested)
+			}
+		}
+	}
+	return fields
+}
+
+// isFileType reports whether t is *File or []*File
+func isFileType(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t == reflect.PointerTo(fileType)
+}
+
+// decodeMultipart streams a multipart/form-data body into the struct pointed
+// to by ptr. Text parts are decoded like a form, and file parts are spooled
+// to temporary files bound to the File fields. The returned status is 413
+// when a limit of uploads is exceeded.
+func decodeMultipart(c *gin.Context, ptr interface{}, limits uploadConfig) (int, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limits.maxRequest)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("invalid multipart body: %w", err)
+	}
+
+	values := url.Values{}
+	files := map[string][]*File{}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return bodyStatus(err), fmt.Errorf("invalid multipart body: %w", err)
+		}
+		name := part.FormName()
+		switch {
+		case name == "":
+		case part.FileName() == "":
+			value, err := io.ReadAll(io.LimitReader(part, maxFormValueBytes+1))
+			if err != nil {
+				return bodyStatus(err), fmt.Errorf("failed to read form value %q: %w", name, err)
+			}
+			if len(value) > maxFormValueBytes {
+				return http.StatusRequestEntityTooLarge, fmt.Errorf("form value %q exceeds %d bytes", name, maxFormValueBytes)
+			}
+			values.Add(name, string(value))
+		default:
+			file, status, err := spoolFile(c, part, limits.maxFile)
+			if err != nil {
+				return status, err
+			}
+			files[name] = append(files[name], file)
+		}
+		part.Close()
+	}
+
+	if err := decodeValues(values, ptr, formTags...); err != nil {
+		return http.StatusBadRequest, err
+	}
+	v := reflect.ValueOf(ptr).Elem()
+	for _, f := range fileFields(v.Type()) {
+		parts := files[f.key]
+		if len(parts) == 0 {
+			continue
+		}
+		fv := v.FieldByIndex(f.index)
+		if fv.Kind() == reflect.Slice {
+			fv.Set(reflect.ValueOf(parts))
+		} else {
+			fv.Set(reflect.ValueOf(parts[len(parts)-1]))
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// spoolFile copies a file part to a temporary file, removed by removeUploads
+func spoolFile(c *gin.Context, part *multipart.Part, maxSize int64) (*File, int, error) {
+	tmp, err := os.CreateTemp("", "httpc-upload-*")
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to store upload: %w", err)
+	}
+	uploads, _ := c.Get(uploadsKey)
+	tmps, _ := uploads.([]*os.File)
+	c.Set(uploadsKey, append(tmps, tmp))
+
+	size, err := io.Copy(tmp, io.LimitReader(part, maxSize+1))
+	if err != nil {
+		return nil, bodyStatus(err), fmt.Errorf("failed to read file %q: %w", part.FileName(), err)
+	}
+	if size > maxSize {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("file %q exceeds the upload limit of %d bytes", part.FileName(), maxSize)
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to read upload: %w", err)
+	}
+	return &File{
+		Filename:     part.FileName(),
+		ContentType:  part.Header.Get("Content-Type"),
+		DetectedType: http.DetectContentType(head[:n]),
+		Size:         size,
+		Header:       part.Header,
+		content:      tmp,
+	}, http.StatusOK, nil
+}
+
+// bodyStatus is 413 when err comes from a request size limit, including the
+// limits of the zstd decoder, else 400
+func bodyStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || errors.Is(err, zstd.ErrWindowSizeExceeded) || errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// removeUploads deletes the temporary files of a request's uploads
+func removeUploads(c *gin.Context) {
+	uploads, ok := c.Get(uploadsKey)
+	if !ok {
+		return
+	}
+	for _, tmp := range uploads.([]*os.File) {
+		tmp.Close()
+		if err := os.Remove(tmp.Name()); err != nil {
+			logger.WarnContext(c.Request.Context(), "Failed to remove upload", logger.ErrField(err))
+		}
+	}
+}
+
+// multipartBody encodes input as a multipart/form-data body: each form or
+// json-tagged field as a text part and each File as a file part. The body is
+// written through a pipe as it is sent, so files are never held in memory.
+type multipartBody struct {
+	boundary string
+	values   url.Values
+	files    []namedFile
+	offsets  []int64 // Initial offsets of the files, restored on each send
+}
+
+type namedFile struct {
+	name string
+	file *File
+}
+
+// newMultipartBody prepares the multipart encoding of the struct input
+func newMultipartBody(input interface{}) (*multipartBody, error) {
+	values, err := encodeValues(input, formTags...)
+	if err != nil {
+		return nil, err
+	}
+	body := &multipartBody{
+		boundary: multipart.NewWriter(io.Discard).Boundary(),
+		values:   values,
+	}
+	v := reflect.ValueOf(input)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return body, nil
+		}
+		v = v.Elem()
+	}
+	for _, f := range fileFields(v.Type()) {
+		fv := v.FieldByIndex(f.index)
+		if fv.Kind() == reflect.Ptr {
+			fv = reflect.Append(reflect.MakeSlice(reflect.SliceOf(fv.Type()), 0, 1), fv)
+		}
+		for i := 0; i < fv.Len(); i++ {
+			if file := fv.Index(i).Interface().(*File); file != nil {
+				body.files = append(body.files, namedFile{name: f.key, file: file})
+			}
+		}
+	}
+	return body, nil
+}
+
+// contentType returns the Content-Type header of the body
+func (b *multipartBody) contentType() string {
+	return mime.FormatMediaType(MediaTypeMultipart, map[string]string{"boundary": b.boundary})
+}
+
+// replayable records the offsets of the files, reporting whether every file
+// can be sent again
+func (b *multipartBody) replayable() bool {
+	b.offsets = make([]int64, len(b.files))
+	for i, f := range b.files {
+		seeker, ok := f.file.content.(io.Seeker)
+		if !ok {
+			b.offsets = nil
+			return false
+		}
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			b.offsets = nil
+			return false
+		}
+		b.offsets[i] = offset
+	}
+	return true
+}
+
+// open starts writing the body, rewinding the files if they were sent before
+func (b *multipartBody) open() io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(b.write(pw))
+	}()
+	return pr
+}
+
+func (b *multipartBody) write(w io.Writer) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(b.boundary); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(b.values))
+	for key := range b.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range b.values[key] {
+			if err := mw.WriteField(key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, f := range b.files {
+		if i < len(b.offsets) {
+			if _, err := f.file.content.(io.Seeker).Seek(b.offsets[i], io.SeekStart); err != nil {
+				return fmt.Errorf("failed to rewind file %q: %w", f.file.Filename, err)
+			}
+		}
+		header := textproto.MIMEHeader{}
+		for key, vals := range f.file.Header {
+			header[key] = vals
+		}
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+			"name":     f.name,
+			"filename": f.file.Filename,
+		}))
+		contentType := f.file.ContentType
+		if contentType == "" {
+			contentType = MediaTypeOctetStream
+		}
+		header.Set("Content-Type", contentType)
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, f.file); err != nil {
+			return fmt.Errorf("failed to upload file %q: %w", f.file.Filename, err)
+		}
+	}
+	return mw.Close()
+}
+
+// lazyBody opens its content on the first Read, so a request body that is
+// replaced by GetBody before being sent never starts writing
+type lazyBody struct {
+	open func() io.ReadCloser
+	rc   io.ReadCloser
+}
+
+func (l *lazyBody) Read(p []byte) (int, error) {
+	if l.rc == nil {
+		l.rc = l.open()
+	}
+	return l.rc.Read(p)
+}
+
+func (l *lazyBody) Close() error {
+	if l.rc == nil {
+		return nil
+	}
+	return l.rc.Close()
+}
+
+// newUploadRequest builds the request of a call encoding input as a
+// multipart/form-data body. Calls uploading a file that cannot seek are sent
+// once, as the file cannot be read again for a retry.
+func (h *HTTPClient) newUploadRequest(ctx context.Context, method, url string, input interface{}, co *callOptions) (*http.Request, error) {
+	body, err := newMultipartBody(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal input: %w", err)
+	}
+	url, err = h.resolveURL(url)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, &lazyBody{open: body.open})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body.replayable() {
+		req.GetBody = func() (io.ReadCloser, error) {
+			return &lazyBody{open: body.open}, nil
+		}
+	} else {
+		co.maxRetries = 0
+	}
+	req.Header.Set("Content-Type", body.contentType())
+	req.Header.Set("Accept", MediaTypeJSON)
+	co.apply(req)
+	return req, nil
+}
+
+// Upload sends input as a multipart/form-data request, streaming its File
+// fields, and decodes a successful response into output like Call.
+func (h *HTTPClient) Upload(ctx context.Context, method, url string, input, output interface{}, opts ...CallOption) (*Response, error) {
+	co := h.newCallOptions(append(opts, WithContentType(MediaTypeMultipart)))
+	co.keepBody = true
+	return h.call(ctx, method, url, input, output, co)
+}
@@ -0,0 +1,270 @@
+package httpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/stretchr/testify/require"
+)
+
+// pngHeader starts the content of a PNG image
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// avatarUpload is a multipart input with text and file fields
+type avatarUpload struct {
+	UserID      int     `json:"user_id" validate:"required"`
+	Avatar      *File   `json:"avatar" validate:"required"`
+	Attachments []*File `json:"attachments"`
+}
+
+// uploadSummary describes the files received by fileService
+type uploadSummary struct {
+	UserID       int      `json:"user_id"`
+	Filename     string   `json:"filename"`
+	ContentType  string   `json:"content_type"`
+	DetectedType string   `json:"detected_type"`
+	Size         int64    `json:"size"`
+	Attachments  []string `json:"attachments"`
+}
+
+// fileQuery selects a file to download
+type fileQuery struct {
+	Name string `query:"name"`
+}
+
+// fileService receives uploads and serves downloads
+type fileService struct {
+	spooled []string // Temporary files of the last upload
+}
+
+func (s *fileService) Avatar(in avatarUpload) (uploadSummary, error) {
+	s.spooled = []string{in.Avatar.content.(*os.File).Name()}
+	content, err := io.ReadAll(in.Avatar)
+	if err != nil {
+		return uploadSummary{}, err
+	}
+	summary := uploadSummary{
+		UserID:       in.UserID,
+		Filename:     in.Avatar.Filename,
+		ContentType:  in.Avatar.ContentType,
+		DetectedType: in.Avatar.DetectedType,
+		Size:         int64(len(content)),
+	}
+	for _, f := range in.Attachments {
+		s.spooled = append(s.spooled, f.content.(*os.File).Name())
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return uploadSummary{}, err
+		}
+		summary.Attachments = append(summary.Attachments, f.Filename+":"+string(data))
+	}
+	return summary, nil
+}
+
+func (s *fileService) Report(q fileQuery) (Download, error) {
+	return Download{
+		Content:  strings.NewReader("0123456789"),
+		Filename: q.Name + ".txt",
+		ModTime:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}, nil
+}
+
+func (s *fileService) Export(q fileQuery) (io.Reader, error) {
+	return io.MultiReader(strings.NewReader("id,name\n"), strings.NewReader("1,"+q.Name+"\n")), nil
+}
+
+func (s *fileService) RegisterMethods() []MethodInfo {
+	return []MethodInfo{
+		{
+			Name:       "Avatar",
+			HTTPMethod: "POST",
+			InputType:  reflect.TypeOf(avatarUpload{}),
+			OutputType: reflect.TypeOf(uploadSummary{}),
+			Func:       reflect.ValueOf(s).MethodByName("Avatar"),
+		},
+		{
+			Name:       "Report",
+			HTTPMethod: "GET",
+			InputType:  reflect.TypeOf(fileQuery{}),
+			OutputType: reflect.TypeOf(Download{}),
+			Func:       reflect.ValueOf(s).MethodByName("Report"),
+		},
+		{
+			Name:       "Export",
+			HTTPMethod: "GET",
+			InputType:  reflect.TypeOf(fileQuery{}),
+			OutputType: readerType,
+			Func:       reflect.ValueOf(s).MethodByName("Export"),
+		},
+	}
+}
+
+// newFileServer serves a fileService at /files with the given settings
+func newFileServer(t *testing.T, settings map[string]interface{}) (*httptest.Server, *fileService) {
+	settings["otel_enabled"] = false
+	settings["port"] = 8080
+	c, err := config.New(config.WithDefault(settings))
+	require.NoError(t, err)
+	srv, err := NewServer(c)
+	require.NoError(t, err)
+	svc := &fileService{}
+	require.NoError(t, srv.RegisterService(svc, WithPathPrefix("/files")))
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts, svc
+}
+
+// postMultipart posts the parts written by build to url
+func postMultipart(t *testing.T, url string, build func(w *multipart.Writer)) *http.Response {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	build(w)
+	require.NoError(t, w.Close())
+	resp, err := http.Post(url, w.FormDataContentType(), &body)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestUploads(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	t.Run("Bind Multipart Input", func(t *testing.T) {
+		ts, svc := newFileServer(t, map[string]interface{}{})
+		resp := postMultipart(t, ts.URL+"/files/Avatar", func(w *multipart.Writer) {
+			require.NoError(t, w.WriteField("user_id", "7"))
+			part, err := w.CreateFormFile("avatar", "me.bin")
+			require.NoError(t, err)
+			part.Write(pngHeader)
+			for _, name := range []string{"a.txt", "b.txt"} {
+				part, err := w.CreateFormFile("attachments", name)
+				require.NoError(t, err)
+				part.Write([]byte("content of " + name))
+			}
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, `{"user_id":7,"filename":"me.bin","content_type":"application/octet-stream","detected_type":"image/png","size":16,"attachments":["a.txt:content of a.txt","b.txt:content of b.txt"]}`, readAll(t, resp))
+
+		// The temporary files are removed once the method returns
+		require.Len(t, svc.spooled, 3)
+		for _, name := range svc.spooled {
+			_, err := os.Stat(name)
+			require.True(t, os.IsNotExist(err), name)
+		}
+	})
+
+	t.Run("Validate Missing File", func(t *testing.T) {
+		ts, _ := newFileServer(t, map[string]interface{}{})
+		resp := postMultipart(t, ts.URL+"/files/Avatar", func(w *multipart.Writer) {
+			require.NoError(t, w.WriteField("user_id", "7"))
+		})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Contains(t, readAll(t, resp), "avatar")
+	})
+
+	t.Run("Size Limits", func(t *testing.T) {
+		ts, svc := newFileServer(t, map[string]interface{}{
+			"upload_max_file_bytes":    8,
+			"upload_max_request_bytes": 1024,
+		})
+		resp := postMultipart(t, ts.URL+"/files/Avatar", func(w *multipart.Writer) {
+			part, err := w.CreateFormFile("avatar", "big.bin")
+			require.NoError(t, err)
+			part.Write(pngHeader)
+		})
+		require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+		require.Contains(t, readAll(t, resp), `file \"big.bin\" exceeds the upload limit of 8 bytes`)
+		require.Nil(t, svc.spooled, "the method is not called")
+
+		resp = postMultipart(t, ts.URL+"/files/Avatar", func(w *multipart.Writer) {
+			require.NoError(t, w.WriteField("user_id", strings.Repeat("7", 2048)))
+		})
+		require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+
+	t.Run("Client Upload", func(t *testing.T) {
+		ts, _ := newFileServer(t, map[string]interface{}{})
+		client := newStreamClient(t)
+
+		avatar := NewFile("me.png", io.MultiReader(bytes.NewReader(pngHeader)))
+		var summary uploadSummary
+		resp, err := client.Upload(context.Background(), "POST", ts.URL+"/files/Avatar", avatarUpload{
+			UserID:      3,
+			Avatar:      avatar,
+			Attachments: []*File{NewFile("notes.txt", strings.NewReader("hi"))},
+		}, &summary)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, uploadSummary{
+			UserID:       3,
+			Filename:     "me.png",
+			ContentType:  "image/png",
+			DetectedType: "image/png",
+			Size:         16,
+			Attachments:  []string{"notes.txt:hi"},
+		}, summary)
+	})
+
+	t.Run("Client Retries Seekable Files", func(t *testing.T) {
+		var bodies []string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			file, _, err := r.FormFile("avatar")
+			require.NoError(t, err)
+			data, _ := io.ReadAll(file)
+			bodies = append(bodies, r.FormValue("user_id")+":"+string(data))
+			if len(bodies) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("{}"))
+		}))
+		defer ts.Close()
+
+		client := newStreamClient(t)
+		_, err := client.Upload(context.Background(), "POST", ts.URL, avatarUpload{
+			UserID: 1,
+			Avatar: NewFile("a.txt", strings.NewReader("abc")),
+		}, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"1:abc", "1:abc"}, bodies)
+
+		// A file that cannot seek is sent once
+		bodies = nil
+		_, err = client.Upload(context.Background(), "POST", ts.URL, avatarUpload{
+			UserID: 1,
+			Avatar: NewFile("a.txt", io.MultiReader(strings.NewReader("abc"))),
+		}, nil)
+		var respErr *ResponseError
+		require.ErrorAs(t, err, &respErr)
+		require.Equal(t, http.StatusServiceUnavailable, respErr.StatusCode)
+		require.Len(t, bodies, 1)
+	})
+
+	t.Run("Documented Upload", func(t *testing.T) {
+		ts, _ := newFileServer(t, map[string]interface{}{})
+		doc, _ := fetchDocument(t, ts)
+		operation := doc["paths"].(map[string]interface{})["/files/Avatar"].(map[string]interface{})["post"].(map[string]interface{})
+		content := operation["requestBody"].(map[string]interface{})["content"].(map[string]interface{})
+		require.Len(t, content, 1)
+		schema := resolveRef(t, doc, content[MediaTypeMultipart].(map[string]interface{})["schema"])
+		properties := schema["properties"].(map[string]interface{})
+		binary := map[string]interface{}{"type": "string", "format": "binary"}
+		require.Equal(t, binary, properties["avatar"])
+		require.Equal(t, map[string]interface{}{"type": "array", "items": binary}, properties["attachments"])
+		require.Equal(t, []interface{}{"user_id", "avatar"}, schema["required"])
+	})
+}
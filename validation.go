@@ -0,0 +1,407 @@
+package httpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+)
+
+// FieldError describes one failed validation rule
+type FieldError struct {
+	Field   string `json:"field" validate:"required"`                                             // JSON path of the field, e.g. "address.city" or "items[0].name"
+	In      string `json:"in,omitempty" validate:"omitempty,oneof=path query header cookie body"` // Request part the field is bound from
+	Rule    string `json:"rule" validate:"required"`                                              // Validation tag that failed, e.g. "required"
+	Param   string `json:"param,omitempty"`                                                       // Rule parameter, e.g. "50" for max=50
+	Message string `json:"message" validate:"required"`                                           // Human-readable message in the negotiated locale
+}
+
+// ErrorResponse is the JSON body of error responses. Fields is set for validation failures.
+type ErrorResponse struct {
+	Error  string       `json:"error" validate:"required"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// ResponseError is returned by HTTPClient when the server responds with an
+// unexpected status. Fields holds the per-field details of validation failures.
+type ResponseError struct {
+	StatusCode int
+	Message    string
+	Fields     []FieldError
+	Body       []byte
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// newResponseError builds a ResponseError from an error response body,
+// falling back to "unknown error" when it is not an ErrorResponse
+func newResponseError(status int, body []byte) *ResponseError {
+	respErr := &ResponseError{StatusCode: status, Message: "unknown error", Body: body}
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != "" {
+		respErr.Message = errResp.Error
+		respErr.Fields = errResp.Fields
+	}
+	return respErr
+}
+
+// defaultLocale is the locale used when Accept-Language matches no registered translator
+const defaultLocale = "en"
+
+// newValidator returns a validator reporting fields by their request names
+// and an English translator for its messages
+func newValidator() (*validator.Validate, ut.Translator, error) {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(validationFieldName)
+	if err := validate.RegisterValidation("regexp", validateRegexp); err != nil {
+		return nil, nil, fmt.Errorf("failed to register regexp validation: %w", err)
+	}
+
+	trans, err := newTranslator(validate)
+	if err != nil {
+		return nil, nil, err
+	}
+	return validate, trans, nil
+}
+
+// newTranslator returns an English translator with the default messages of
+// the built-in rules registered on validate
+func newTranslator(validate *validator.Validate) (ut.Translator, error) {
+	english := en.New()
+	trans, _ := ut.New(english, english).GetTranslator(defaultLocale)
+	if err := en_translations.RegisterDefaultTranslations(validate, trans); err != nil {
+		return nil, fmt.Errorf("failed to register validation messages: %w", err)
+	}
+	return trans, nil
+}
+
+// validationFieldName names a field by its source tag key, then its json tag
+func validationFieldName(field reflect.StructField) string {
+	if _, key, ok := fieldSource(field); ok {
+		return key
+	}
+	if key, skip := fieldKey(field, []string{"json", "form"}); !skip {
+		return key
+	}
+	return field.Name
+}
+
+// RegisterTranslator adds validation messages for the locale of trans. register
+// installs the messages, e.g. fr_translations.RegisterDefaultTranslations. The
+// locale is chosen per request from Accept-Language, defaulting to English.
+// Call it before the server starts handling requests.
+func (s *Server) RegisterTranslator(trans ut.Translator, register func(*validator.Validate, ut.Translator) error) error {
+	if err := register(s.validate, trans); err != nil {
+		return fmt.Errorf("failed to register %s validation messages: %w", trans.Locale(), err)
+	}
+	s.translators[normalizeLocale(trans.Locale())] = trans
+	return nil
+}
+
+// translator returns the translator for the first supported language in an
+// Accept-Language header, trying the base language of regional tags
+func (s *Server) translator(acceptLanguage string) ut.Translator {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := normalizeLocale(strings.TrimSpace(strings.Split(part, ";")[0]))
+		if trans, ok := s.translators[tag]; ok {
+			return trans
+		}
+		if base, _, found := strings.Cut(tag, "-"); found {
+			if trans, ok := s.translators[base]; ok {
+				return trans
+			}
+		}
+	}
+	return s.translators[defaultLocale]
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+}
+
+// validationResponse converts a validation error on an input of type t into
+// an ErrorResponse with one FieldError per failed rule
+func validationResponse(err error, m MethodInfo, t reflect.Type, trans ut.Translator) ErrorResponse {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return ErrorResponse{Error: "validation failed: " + err.Error()}
+	}
+	defaultSource := sourceBody
+	if m.HTTPMethod == http.MethodGet {
+		defaultSource = sourceQuery
+	}
+	fields := fieldErrors(errs, t, defaultSource, trans)
+	return ErrorResponse{Error: "validation failed: " + joinMessages(fields), Fields: fields}
+}
+
+// fieldErrors converts validation errors on a value of type t into
+// FieldErrors. Fields without a source tag are reported in defaultSource.
+func fieldErrors(errs validator.ValidationErrors, t reflect.Type, defaultSource string, trans ut.Translator) []FieldError {
+	sources := map[string]string{}
+	for _, f := range sourceFields(t) {
+		sources[f.field.Name] = f.source
+	}
+
+	fields := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		// Namespaces start with the input type name, which is not part of the request
+		path := fe.Namespace()
+		if _, rest, found := strings.Cut(path, "."); found {
+			path = rest
+		}
+		in := defaultSource
+		top := strings.SplitN(fe.StructNamespace(), ".", 3)
+		if len(top) > 1 {
+			name, _, _ := strings.Cut(top[1], "[")
+			if source, ok := sources[name]; ok {
+				in = source
+				// Fields of a `body` payload are reported relative to the body
+				if source == sourceBody {
+					if _, rest, found := strings.Cut(path, "."); found {
+						path = rest
+					}
+				}
+			}
+		}
+
+		message := fe.Error()
+		if trans != nil {
+			message = fe.Translate(trans)
+		}
+		if message == fe.Error() {
+			message = fmt.Sprintf("%s failed on the '%s' rule", fe.Field(), fe.Tag())
+		}
+		fields = append(fields, FieldError{
+			Field:   path,
+			In:      in,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: message,
+		})
+	}
+	return fields
+}
+
+// joinMessages lists the messages of fields, separated by semicolons
+func joinMessages(fields []FieldError) string {
+	messages := make([]string, len(fields))
+	for i, f := range fields {
+		messages[i] = f.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// validationRules records the aliases and custom rules registered on a
+// Server so the OpenAPI document can describe them
+type validationRules struct {
+	aliases map[string]string
+	hints   map[string]map[string]interface{}
+}
+
+func newValidationRules() *validationRules {
+	return &validationRules{
+		aliases: map[string]string{},
+		hints:   map[string]map[string]interface{}{},
+	}
+}
+
+// expand replaces registered aliases in a validate tag with the rules they stand for
+func (r *validationRules) expand(validateTag string) string {
+	if r == nil || len(r.aliases) == 0 || validateTag == "" {
+		return validateTag
+	}
+	parts := strings.Split(validateTag, ",")
+	for i, part := range parts {
+		if tags, ok := r.aliases[part]; ok {
+			parts[i] = r.expand(tags)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// applyHints merges the schema hints of the custom rules in validateTag into schema
+func (r *validationRules) applyHints(schema map[string]interface{}, validateTag string) {
+	if r == nil || len(r.hints) == 0 {
+		return
+	}
+	for _, part := range strings.Split(validateTag, ",") {
+		rule, _, _ := strings.Cut(part, "=")
+		for key, value := range r.hints[rule] {
+			schema[key] = value
+		}
+	}
+}
+
+// ValidationOption configures a rule registered with Server.RegisterValidation
+type ValidationOption func(*validationRule)
+
+type validationRule struct {
+	schema         map[string]interface{}
+	message        string
+	callEvenIfNull bool
+}
+
+// WithSchemaHint merges schema, e.g. {"format": "iso-4217"} or {"pattern": "^[A-Z]{3}$"},
+// into the OpenAPI schema of every field using the rule
+func WithSchemaHint(schema map[string]interface{}) ValidationOption {
+	return func(r *validationRule) {
+		r.schema = schema
+	}
+}
+
+// WithValidationMessage sets the English message for the rule. {0} is
+// replaced by the field name and {1} by the rule parameter.
+func WithValidationMessage(message string) ValidationOption {
+	return func(r *validationRule) {
+		r.message = message
+	}
+}
+
+// WithCallEvenIfNull runs the rule on nil pointers and zero values too
+func WithCallEvenIfNull() ValidationOption {
+	return func(r *validationRule) {
+		r.callEvenIfNull = true
+	}
+}
+
+// RegisterValidation adds a custom validation rule usable in `validate` tags
+// of service inputs. Register rules before the services that use them so the
+// OpenAPI document reflects their schema hints.
+func (s *Server) RegisterValidation(tag string, fn validator.Func, opts ...ValidationOption) error {
+	rule := &validationRule{}
+	for _, opt := range opts {
+		opt(rule)
+	}
+	if err := s.validate.RegisterValidation(tag, fn, rule.callEvenIfNull); err != nil {
+		return fmt.Errorf("failed to register validation %s: %w", tag, err)
+	}
+	if rule.schema != nil {
+		s.rules.hints[tag] = rule.schema
+	}
+	if rule.message != "" {
+		return s.RegisterValidationMessage(tag, rule.message)
+	}
+	return nil
+}
+
+// RegisterValidationMessage sets the English message reported for tag, such
+// as a tag raised by a struct-level validator. {0} is replaced by the field
+// name and {1} by the rule parameter.
+func (s *Server) RegisterValidationMessage(tag, message string) error {
+	trans := s.translators[defaultLocale]
+	err := s.validate.RegisterTranslation(tag, trans,
+		func(ut ut.Translator) error {
+			return ut.Add(tag, message, true)
+		},
+		func(ut ut.Translator, fe validator.FieldError) string {
+			msg, err := ut.T(fe.Tag(), fe.Field(), fe.Param())
+			if err != nil {
+				return fe.Error()
+			}
+			return msg
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register message for %s: %w", tag, err)
+	}
+	return nil
+}
+
+// RegisterStructValidation adds a struct-level validator for the given types,
+// for rules spanning several fields. fn reports failures with
+// StructLevel.ReportError, naming fields by their JSON names.
+func (s *Server) RegisterStructValidation(fn validator.StructLevelFunc, types ...interface{}) {
+	s.validate.RegisterStructValidation(fn, types...)
+}
+
+// Validator returns the validator of the server, with its custom rules,
+// aliases and struct validators and naming fields by their request names.
+// Pass it to WithContractValidation so clients check calls against the same
+// rules. Messages set with RegisterValidationMessage and RegisterTranslator
+// stay with the server.
+func (s *Server) Validator() *validator.Validate {
+	return s.validate
+}
+
+// RegisterAlias makes alias stand for a comma-separated list of rules, e.g.
+// RegisterAlias("username", "min=3,max=20,alphanum")
+func (s *Server) RegisterAlias(alias, tags string) {
+	s.validate.RegisterAlias(alias, tags)
+	s.rules.aliases[alias] = tags
+}
+
+// Sides of a call checked by client contract validation
+const (
+	ContractRequest  = "request"
+	ContractResponse = "response"
+)
+
+// ContractError is returned by HTTPClient when contract validation is enabled
+// and an input or decoded output violates the validate tags of its struct.
+// Request violations are reported before anything is sent.
+type ContractError struct {
+	Side   string // ContractRequest or ContractResponse
+	Type   reflect.Type
+	Fields []FieldError
+}
+
+func (e *ContractError) Error() string {
+	return fmt.Sprintf("%s violates contract of %s: %s", e.Side, e.Type, joinMessages(e.Fields))
+}
+
+// WithContractValidation validates struct inputs before they are sent and
+// decoded struct outputs after a successful response. v carries custom rules,
+// such as those of Server.Validator; nil uses a validator naming fields by
+// JSON tags. ContractError messages are the English messages of the built-in
+// rules, which NewHTTPClient registers on v, so create the client before v is
+// used concurrently. Other rules are reported as "<field> failed on the
+// '<rule>' rule".
+func WithContractValidation(v *validator.Validate) ClientOption {
+	return func(h *HTTPClient) {
+		h.validateContracts = true
+		h.validate = v
+	}
+}
+
+// checkContract validates value, a struct or pointer to one, when contract
+// validation is enabled. method places request violations in the query of
+// GET calls and in the body otherwise, as the server reports them.
+func (h *HTTPClient) checkContract(side, method string, value interface{}) error {
+	if !h.validateContracts || value == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	t := rv.Type()
+
+	err := h.validate.Struct(rv.Interface())
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	defaultSource := ""
+	if side == ContractRequest {
+		defaultSource = sourceBody
+		if method == http.MethodGet {
+			defaultSource = sourceQuery
+		}
+	}
+	return &ContractError{Side: side, Type: t, Fields: fieldErrors(errs, t, defaultSource, h.trans)}
+}
@@ -0,0 +1,278 @@
+package httpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/go-playground/locales/fr"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	"github.com/stretchr/testify/require"
+)
+
+type transferInput struct {
+	From     string `json:"from" validate:"required,account"`
+	To       string `json:"to" validate:"required,account"`
+	Currency string `json:"currency" validate:"currency_code"`
+	Amount   int    `json:"amount" validate:"gte=1"`
+}
+
+type transferService struct{}
+
+func (s transferService) Transfer(in transferInput) (string, error) {
+	return fmt.Sprintf("moved %d %s from %s to %s", in.Amount, in.Currency, in.From, in.To), nil
+}
+
+func (s transferService) RegisterMethods() []MethodInfo {
+	return []MethodInfo{
+		{
+			Name:       "Transfer",
+			HTTPMethod: "POST",
+			InputType:  reflect.TypeOf(transferInput{}),
+			OutputType: reflect.TypeOf(""),
+			Func:       reflect.ValueOf(s).MethodByName("Transfer"),
+		},
+	}
+}
+
+var accountPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{4}$`)
+
+func TestValidationErrors(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	serverCfg := ServerConfig{
+		OtelEnabled: false,
+		Port:        8080,
+	}
+	clientCfg, err := config.New(config.WithDefault(map[string]interface{}{
+		"otel_enabled":            false,
+		"http_client_timeout_ms":  1000,
+		"http_client_max_retries": 0,
+	}))
+	require.NoError(t, err)
+	client, err := NewHTTPClient(clientCfg)
+	require.NoError(t, err)
+
+	t.Run("Nested JSON Paths", func(t *testing.T) {
+		ts := setupServer(t, serverCfg, &OrderService{}, "/v1")
+		defer ts.Close()
+
+		input := OrderInput{
+			Customer: UserPatch{Name: "Ann", Email: "not-an-email"},
+			Items:    []OrderItem{{SKU: "a", Quantity: 1}, {Quantity: 11}},
+		}
+		var result string
+		resp, err := client.CallWithResponse("POST", ts.URL+"/v1/Place", input, &result)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		var respErr *ResponseError
+		require.True(t, errors.As(err, &respErr))
+		require.Equal(t, http.StatusBadRequest, respErr.StatusCode)
+		require.Equal(t, []FieldError{
+			{Field: "customer.email", In: "body", Rule: "email", Message: "email must be a valid email address"},
+			{Field: "items[1].sku", In: "body", Rule: "required", Message: "sku is a required field"},
+			{Field: "items[1].quantity", In: "body", Rule: "lte", Param: "10", Message: "quantity must be 10 or less"},
+		}, respErr.Fields)
+		require.Equal(t, "validation failed: email must be a valid email address; sku is a required field; quantity must be 10 or less", respErr.Message)
+
+		var body ErrorResponse
+		require.NoError(t, json.Unmarshal(resp.Body, &body))
+		require.Len(t, body.Fields, 3)
+	})
+
+	t.Run("Query Fields", func(t *testing.T) {
+		ts := setupServer(t, serverCfg, &SearchService{}, "/v1")
+		defer ts.Close()
+
+		var output SearchResult
+		err := client.Call("GET", ts.URL+"/v1/Search", nil, &output, WithQuery(url.Values{"q": {"go"}, "limit": {"500"}}))
+		var respErr *ResponseError
+		require.True(t, errors.As(err, &respErr))
+		require.Equal(t, []FieldError{{Field: "limit", In: "query", Rule: "lte", Param: "100", Message: "limit must be 100 or less"}}, respErr.Fields)
+	})
+
+	t.Run("Translated Messages", func(t *testing.T) {
+		cfgMap, err := toConfigMap(serverCfg)
+		require.NoError(t, err)
+		c, err := config.New(config.WithDefault(cfgMap))
+		require.NoError(t, err)
+		srv, err := NewServer(c)
+		require.NoError(t, err)
+
+		french := fr.New()
+		trans, _ := ut.New(french, french).GetTranslator("fr")
+		require.NoError(t, srv.RegisterTranslator(trans, fr_translations.RegisterDefaultTranslations))
+		require.NoError(t, srv.RegisterService(&OrderService{}, WithPathPrefix("/v1")))
+		ts := httptest.NewServer(srv.engine)
+		defer ts.Close()
+
+		input := OrderInput{Customer: UserPatch{Name: "Ann"}}
+		var result string
+		err = client.Call("POST", ts.URL+"/v1/Place", input, &result, WithHeader("Accept-Language", "fr-CA, en;q=0.5"))
+		var respErr *ResponseError
+		require.True(t, errors.As(err, &respErr))
+		require.Equal(t, "items est un champ obligatoire", respErr.Fields[0].Message)
+
+		err = client.Call("POST", ts.URL+"/v1/Place", input, &result, WithHeader("Accept-Language", "de"))
+		require.True(t, errors.As(err, &respErr))
+		require.Equal(t, "items is a required field", respErr.Fields[0].Message)
+	})
+
+	t.Run("Custom Rules", func(t *testing.T) {
+		cfgMap, err := toConfigMap(serverCfg)
+		require.NoError(t, err)
+		c, err := config.New(config.WithDefault(cfgMap))
+		require.NoError(t, err)
+		srv, err := NewServer(c)
+		require.NoError(t, err)
+
+		require.NoError(t, srv.RegisterValidation("account", func(fl validator.FieldLevel) bool {
+			return accountPattern.MatchString(fl.Field().String())
+		}, WithSchemaHint(map[string]interface{}{"pattern": accountPattern.String()}), WithValidationMessage("{0} must be an account number like AB1234")))
+		srv.RegisterAlias("currency_code", "min=3,max=3,uppercase")
+		srv.RegisterStructValidation(func(sl validator.StructLevel) {
+			in := sl.Current().Interface().(transferInput)
+			if in.From != "" && in.From == in.To {
+				sl.ReportError(in.To, "to", "To", "nefield", "from")
+			}
+		}, transferInput{})
+		require.NoError(t, srv.RegisterService(&transferService{}, WithPathPrefix("/v1")))
+		ts := httptest.NewServer(srv.engine)
+		defer ts.Close()
+
+		var result string
+		err = client.Call("POST", ts.URL+"/v1/Transfer", transferInput{From: "AB1234", To: "CD5678", Currency: "EUR", Amount: 5}, &result)
+		require.NoError(t, err)
+		require.Equal(t, "moved 5 EUR from AB1234 to CD5678", result)
+
+		err = client.Call("POST", ts.URL+"/v1/Transfer", transferInput{From: "AB1234", To: "nope", Currency: "eur", Amount: 5}, &result)
+		var respErr *ResponseError
+		require.True(t, errors.As(err, &respErr))
+		require.Equal(t, []FieldError{
+			{Field: "to", In: "body", Rule: "account", Message: "to must be an account number like AB1234"},
+			{Field: "currency", In: "body", Rule: "currency_code", Message: "currency failed on the 'currency_code' rule"},
+		}, respErr.Fields)
+
+		err = client.Call("POST", ts.URL+"/v1/Transfer", transferInput{From: "AB1234", To: "AB1234", Currency: "EUR", Amount: 5}, &result)
+		require.True(t, errors.As(err, &respErr))
+		require.Equal(t, []FieldError{{Field: "to", In: "body", Rule: "nefield", Param: "from", Message: "to cannot be equal to from"}}, respErr.Fields)
+
+		// A client sharing the server's validator reports the same violations before sending
+		shared, err := NewHTTPClient(clientCfg, WithContractValidation(srv.Validator()))
+		require.NoError(t, err)
+		err = shared.Call("POST", ts.URL+"/v1/Transfer", transferInput{From: "AB1234", To: "AB1234", Currency: "EUR", Amount: 5}, &result)
+		var contractErr *ContractError
+		require.True(t, errors.As(err, &contractErr))
+		require.Equal(t, respErr.Fields, contractErr.Fields)
+		err = shared.Call("POST", ts.URL+"/v1/Transfer", transferInput{From: "AB1234", To: "nope", Currency: "EUR", Amount: 5}, &result)
+		require.True(t, errors.As(err, &contractErr))
+		require.Equal(t, []FieldError{{Field: "to", In: "body", Rule: "account", Message: "to failed on the 'account' rule"}}, contractErr.Fields,
+			"messages registered on the server stay with it")
+		require.NoError(t, shared.Call("POST", ts.URL+"/v1/Transfer", transferInput{From: "AB1234", To: "CD5678", Currency: "EUR", Amount: 5}, &result))
+
+		resp, err := http.Get(ts.URL + "/api/docs/swagger.json")
+		require.NoError(t, err)
+		var doc map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&doc))
+		post := doc["paths"].(map[string]interface{})["/v1/Transfer"].(map[string]interface{})["post"].(map[string]interface{})
+		schema := resolveRef(t, doc, post["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"])
+		props := schema["properties"].(map[string]interface{})
+		require.Equal(t, accountPattern.String(), props["to"].(map[string]interface{})["pattern"])
+		require.Equal(t, float64(3), props["currency"].(map[string]interface{})["minLength"])
+		require.Equal(t, float64(3), props["currency"].(map[string]interface{})["maxLength"])
+	})
+
+	t.Run("Client Contract Validation", func(t *testing.T) {
+		hits := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits++
+			w.Header().Set("Content-Type", MediaTypeJSON)
+			w.Write([]byte(`{"name":"Ann","email":"not-an-email"}`))
+		}))
+		defer ts.Close()
+
+		cfg, err := config.New(config.WithDefault(map[string]interface{}{
+			"otel_enabled":                   false,
+			"http_client_timeout_ms":         1000,
+			"http_client_max_retries":        0,
+			"http_client_validate_contracts": true,
+		}))
+		require.NoError(t, err)
+		strict, err := NewHTTPClient(cfg)
+		require.NoError(t, err)
+
+		var output User
+		err = strict.Call("POST", ts.URL, &User{Email: "ann@example.com"}, &output)
+		var contractErr *ContractError
+		require.True(t, errors.As(err, &contractErr))
+		require.Equal(t, ContractRequest, contractErr.Side)
+		require.Equal(t, reflect.TypeOf(User{}), contractErr.Type)
+		require.Equal(t, []FieldError{{Field: "name", In: "body", Rule: "required", Message: "name is a required field"}}, contractErr.Fields)
+		require.Equal(t, 0, hits, "invalid input must not be sent")
+
+		resp, err := strict.CallWithResponse("POST", ts.URL, User{Name: "Ann", Email: "ann@example.com"}, &output)
+		require.True(t, errors.As(err, &contractErr))
+		require.Equal(t, ContractResponse, contractErr.Side)
+		require.Equal(t, []FieldError{{Field: "email", Rule: "email", Message: "email must be a valid email address"}}, contractErr.Fields)
+		require.Equal(t, "response violates contract of httpc.User: email must be a valid email address", err.Error())
+		require.NotNil(t, resp)
+		require.Equal(t, 1, hits)
+
+		// Every pointer level is followed, and GET inputs are sent as the query
+		input := &User{Email: "ann@example.com"}
+		err = strict.Call("POST", ts.URL, &input, &output)
+		require.True(t, errors.As(err, &contractErr))
+		require.Equal(t, reflect.TypeOf(User{}), contractErr.Type)
+		err = strict.Call("GET", ts.URL, input, &output)
+		require.True(t, errors.As(err, &contractErr))
+		require.Equal(t, []FieldError{{Field: "name", In: "query", Rule: "required", Message: "name is a required field"}}, contractErr.Fields)
+		var nilInput *User
+		require.NoError(t, strict.Call("POST", ts.URL, &nilInput, nil), "nil inputs are not validated")
+		require.Equal(t, 2, hits)
+
+		custom := validator.New()
+		custom.RegisterValidation("account", func(fl validator.FieldLevel) bool { return true })
+		custom.RegisterAlias("currency_code", "len=3")
+		optIn, err := NewHTTPClient(clientCfg, WithContractValidation(custom))
+		require.NoError(t, err)
+		err = optIn.Call("POST", ts.URL, transferInput{From: "x", To: "y", Currency: "EUR"}, nil)
+		require.True(t, errors.As(err, &contractErr))
+		require.Equal(t, "Amount", contractErr.Fields[0].Field)
+		require.Equal(t, "Amount must be 1 or greater", contractErr.Fields[0].Message, "caller-supplied validators get translated messages")
+
+		err = client.Call("POST", ts.URL, &User{}, &output)
+		require.NoError(t, err, "validation is opt-in")
+	})
+
+	t.Run("Non JSON Error Body", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("upstream down"))
+		}))
+		defer ts.Close()
+
+		var result string
+		err := client.Call("POST", ts.URL, User{Name: "Ann", Email: "ann@example.com"}, &result, WithExpectedStatus(http.StatusOK))
+		var respErr *ResponseError
+		require.True(t, errors.As(err, &respErr))
+		require.Equal(t, http.StatusBadGateway, respErr.StatusCode)
+		require.Equal(t, "unknown error", respErr.Message)
+		require.Empty(t, respErr.Fields)
+		require.True(t, bytes.Equal([]byte("upstream down"), respErr.Body))
+	})
+}
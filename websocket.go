@@ -0,0 +1,551 @@
+package httpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/gin-gonic/gin"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/websocket"
+)
+
+// Defaults of the websocket_* config keys
+const (
+	defaultWebSocketReadLimit = 1 << 20
+	defaultWebSocketPing      = 30 * time.Second
+)
+
+// webSocketWriteWait bounds each write, and the wait for the peer to answer a close
+const webSocketWriteWait = 10 * time.Second
+
+// ErrInvalidMessage reports a received WebSocket message that is not valid
+// JSON of the declared type or fails its validate tags. The connection stays
+// open, so a handler may reply and keep receiving.
+var ErrInvalidMessage = errors.New("invalid websocket message")
+
+// WebSocket is a connection exchanging JSON messages, receiving In messages
+// and sending Out messages. MethodWebSocket methods take a
+// *WebSocket[InputType, OutputType]; DialWebSocket returns the client side.
+type WebSocket[In, Out any] struct {
+	conn *wsConn
+}
+
+// Receive waits for the next message. It returns io.EOF once the connection
+// is closed normally, by either side or by Server.Shutdown.
+func (w *WebSocket[In, Out]) Receive() (In, error) {
+	var msg In
+	err := w.conn.receive(&msg)
+	return msg, err
+}
+
+// Send writes msg as a JSON text message. It is safe to call concurrently.
+func (w *WebSocket[In, Out]) Send(msg Out) error {
+	return w.conn.send(msg)
+}
+
+// Context returns a context cancelled when the connection closes
+func (w *WebSocket[In, Out]) Context() context.Context {
+	return w.conn.ctx
+}
+
+// Close closes the connection normally
+func (w *WebSocket[In, Out]) Close() error {
+	w.conn.close(websocket.CloseNormalClosure, "")
+	<-w.conn.done
+	return nil
+}
+
+func (w *WebSocket[In, Out]) bind(conn *wsConn) {
+	w.conn = conn
+}
+
+func (w *WebSocket[In, Out]) messageTypes() (reflect.Type, reflect.Type) {
+	return reflect.TypeFor[In](), reflect.TypeFor[Out]()
+}
+
+// webSocketHandle is implemented by every *WebSocket[In, Out], which lets
+// handlers be called through reflection with their typed connection
+type webSocketHandle interface {
+	bind(conn *wsConn)
+	messageTypes() (in, out reflect.Type)
+}
+
+var webSocketHandleType = reflect.TypeOf((*webSocketHandle)(nil)).Elem()
+
+// checkWebSocketSignature checks that a MethodWebSocket method takes an
+// optional context.Context and a *WebSocket of its InputType and OutputType,
+// and returns only an error
+func checkWebSocketSignature(method MethodInfo, fn reflect.Type) error {
+	numIn := fn.NumIn()
+	validIn := numIn == 2 || numIn == 3 && fn.In(1) == contextType
+	if !validIn || fn.NumOut() != 1 || fn.Out(0) != errorType || !fn.In(numIn-1).Implements(webSocketHandleType) {
+		return fmt.Errorf("invalid signature for WebSocket method %s: want func([context.Context,] *httpc.WebSocket[In, Out]) error", method.Name)
+	}
+	in, out := reflect.Zero(fn.In(numIn - 1)).Interface().(webSocketHandle).messageTypes()
+	if in != method.InputType || out != method.OutputType {
+		return fmt.Errorf("WebSocket method %s exchanges %v and %v messages, but declares InputType %v and OutputType %v",
+			method.Name, in, out, method.InputType, method.OutputType)
+	}
+	if method.HTTPMethod != http.MethodGet {
+		return fmt.Errorf("WebSocket method %s must use GET, not %s", method.Name, method.HTTPMethod)
+	}
+	return nil
+}
+
+// wsConn is the untyped side of a WebSocket. A read loop owns the reads, so
+// control frames are answered and a closed peer is noticed even while the
+// handler only sends.
+type wsConn struct {
+	ws       *websocket.Conn
+	ctx      context.Context
+	cancel   context.CancelFunc
+	messages chan []byte
+	readErr  error         // Why the read loop ended, set before messages is closed
+	done     chan struct{} // Closed once the read loop ended and the connection is closed
+
+	checkIn  func(msg interface{}) error // Validates received messages, if set
+	checkOut func(msg interface{}) error // Validates sent messages, if set
+	release  func()                      // Called once the connection is closed
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+	closing   atomic.Bool
+}
+
+// newWSConn starts reading ws. Without a message or pong for pongWait, if
+// positive, the connection is considered lost. Cancelling parent closes it,
+// and release is called once it is closed.
+func newWSConn(parent context.Context, ws *websocket.Conn, pongWait time.Duration, release func()) *wsConn {
+	ctx, cancel := context.WithCancel(parent)
+	c := &wsConn{
+		ws:       ws,
+		ctx:      ctx,
+		cancel:   cancel,
+		messages: make(chan []byte),
+		done:     make(chan struct{}),
+		release:  release,
+	}
+	if pongWait > 0 {
+		ws.SetPongHandler(func(string) error {
+			return ws.SetReadDeadline(time.Now().Add(pongWait))
+		})
+	}
+	stop := context.AfterFunc(parent, func() {
+		c.close(websocket.CloseNormalClosure, "")
+	})
+	go func() {
+		defer stop()
+		c.readLoop(pongWait)
+	}()
+	return c
+}
+
+func (c *wsConn) readLoop(pongWait time.Duration) {
+	defer func() {
+		c.cancel()
+		c.ws.Close()
+		c.release()
+		close(c.messages)
+		close(c.done)
+	}()
+	for {
+		if pongWait > 0 {
+			c.ws.SetReadDeadline(time.Now().Add(pongWait))
+		}
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			c.readErr = err
+			return
+		}
+		select {
+		case c.messages <- data:
+		case <-c.ctx.Done():
+			c.readErr = c.ctx.Err()
+			return
+		}
+	}
+}
+
+// receive decodes the next message into v
+func (c *wsConn) receive(v interface{}) error {
+	data, ok := <-c.messages
+	if !ok {
+		var closeErr *websocket.CloseError
+		if c.closing.Load() || errors.As(c.readErr, &closeErr) &&
+			(closeErr.Code == websocket.CloseNormalClosure || closeErr.Code == websocket.CloseGoingAway) {
+			return io.EOF
+		}
+		return c.readErr
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+	}
+	if c.checkIn != nil {
+		if err := c.checkIn(v); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+		}
+	}
+	return nil
+}
+
+// send writes v as a JSON text message
+func (c *wsConn) send(v interface{}) error {
+	if c.checkOut != nil {
+		if err := c.checkOut(v); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.ws.SetWriteDeadline(time.Now().Add(webSocketWriteWait))
+	return c.ws.WriteMessage(websocket.TextMessage, data)
+}
+
+// keepAlive pings the peer every interval until the connection closes
+func (c *wsConn) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(webSocketWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// close starts the closing handshake. The connection is closed when the peer
+// answers, or after webSocketWriteWait.
+func (c *wsConn) close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closing.Store(true)
+		if len(reason) > 123 {
+			reason = reason[:123] // Close frames carry at most 125 bytes, including the code
+		}
+		c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(webSocketWriteWait))
+		c.cancel()
+		time.AfterFunc(webSocketWriteWait, func() {
+			c.ws.Close()
+		})
+	})
+}
+
+// webSocketConfig holds the websocket_* config keys
+type webSocketConfig struct {
+	readLimit int64
+	ping      time.Duration
+	origins   []string
+}
+
+func newWebSocketConfig(c *config.Config) webSocketConfig {
+	cfg := webSocketConfig{
+		readLimit: int64(getIntConfig(c, "websocket_read_limit", defaultWebSocketReadLimit)),
+		ping:      time.Duration(getIntConfig(c, "websocket_ping_interval_ms", int(defaultWebSocketPing.Milliseconds()))) * time.Millisecond,
+	}
+	for _, origin := range strings.Split(getStringConfig(c, "websocket_allowed_origins", ""), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			cfg.origins = append(cfg.origins, strings.TrimSuffix(origin, "/"))
+		}
+	}
+	return cfg
+}
+
+// checkOrigin accepts requests without an Origin, as sent by non-browser
+// clients, from the server's own origin, or from an allowed origin
+func (cfg webSocketConfig) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range cfg.origins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	logger.WarnContext(r.Context(), "Rejected WebSocket origin", logger.String("origin", origin))
+	return false
+}
+
+// webSocketSet tracks the open WebSocket connections of a server. Hijacked
+// connections are not seen by http.Server.Shutdown, so Server.Shutdown closes
+// them through this set.
+type webSocketSet struct {
+	mu       sync.Mutex
+	conns    map[*wsConn]struct{}
+	shutdown bool
+	handlers sync.WaitGroup
+}
+
+// add tracks c, unless the server is shutting down
+func (set *webSocketSet) add(c *wsConn) bool {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	if set.shutdown {
+		return false
+	}
+	if set.conns == nil {
+		set.conns = map[*wsConn]struct{}{}
+	}
+	set.conns[c] = struct{}{}
+	set.handlers.Add(1)
+	return true
+}
+
+func (set *webSocketSet) remove(c *wsConn) {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	delete(set.conns, c)
+	set.handlers.Done()
+}
+
+func (set *webSocketSet) accepting() bool {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	return !set.shutdown
+}
+
+// closeAll refuses new connections, sends a going away close to the open
+// ones and waits for their handlers to return
+func (set *webSocketSet) closeAll(ctx context.Context) error {
+	set.mu.Lock()
+	set.shutdown = true
+	conns := make([]*wsConn, 0, len(set.conns))
+	for c := range set.conns {
+		conns = append(conns, c)
+	}
+	set.mu.Unlock()
+
+	if len(conns) > 0 {
+		logger.Info("Closing WebSocket connections", logger.Int("count", len(conns)))
+	}
+	for _, c := range conns {
+		c.close(websocket.CloseGoingAway, "server shutting down")
+	}
+	done := make(chan struct{})
+	go func() {
+		set.handlers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("WebSocket handlers did not return: %w", ctx.Err())
+	}
+}
+
+// handleWebSocket upgrades requests to a WebSocket and runs the method with
+// the typed connection until it returns. An error closes the connection with
+// status 1011 and the error as reason.
+func (s *Server) handleWebSocket(m MethodInfo) gin.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		HandshakeTimeout: webSocketWriteWait,
+		CheckOrigin:      s.webSocket.checkOrigin,
+	}
+	fnType := m.Func.Type()
+	handleType := fnType.In(fnType.NumIn() - 1).Elem()
+	validate := hasStruct(m.InputType)
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if !s.webSockets.accepting() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "server is shutting down"})
+			return
+		}
+		ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// The upgrader has already answered the request
+			logger.WarnContext(ctx, "WebSocket upgrade failed", logger.ErrField(err))
+			return
+		}
+		if s.webSocket.readLimit > 0 {
+			ws.SetReadLimit(s.webSocket.readLimit)
+		}
+		conn := newWSConn(ctx, ws, 2*s.webSocket.ping, func() {})
+		if validate {
+			trans := s.translator(c.GetHeader("Accept-Language"))
+			conn.checkIn = func(msg interface{}) error {
+				return s.validateMessage(msg, m.InputType, trans)
+			}
+		}
+		if !s.webSockets.add(conn) {
+			conn.close(websocket.CloseGoingAway, "server shutting down")
+			<-conn.done
+			return
+		}
+		defer s.webSockets.remove(conn)
+		if s.webSocket.ping > 0 {
+			go conn.keepAlive(s.webSocket.ping)
+		}
+		logger.InfoContext(ctx, "WebSocket connected", logger.String("path", c.FullPath()))
+
+		code, reason := websocket.CloseInternalServerErr, "handler panicked"
+		defer func() {
+			conn.close(code, reason)
+			<-conn.done
+			logger.InfoContext(ctx, "WebSocket closed", logger.String("path", c.FullPath()))
+		}()
+		handle := reflect.New(handleType)
+		handle.Interface().(webSocketHandle).bind(conn)
+		args := []reflect.Value{handle}
+		if fnType.NumIn() == 2 {
+			args = append([]reflect.Value{reflect.ValueOf(conn.ctx)}, args...)
+		}
+		results := m.Func.Call(args)
+		code, reason = websocket.CloseNormalClosure, ""
+		if err, _ := results[0].Interface().(error); err != nil {
+			logger.ErrorContext(ctx, "WebSocket handler failed", logger.ErrField(err))
+			code, reason = websocket.CloseInternalServerErr, err.Error()
+		}
+	}
+}
+
+// hasStruct reports whether t is a struct or a pointer to one
+func hasStruct(t reflect.Type) bool {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t != nil && t.Kind() == reflect.Struct
+}
+
+// validateMessage checks a received message against its validate tags
+func (s *Server) validateMessage(msg interface{}, t reflect.Type, trans ut.Translator) error {
+	err := s.validate.Struct(msg)
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	return errors.New("validation failed: " + joinMessages(fieldErrors(errs, t, sourceBody, trans)))
+}
+
+// DialWebSocket opens a WebSocket to the MethodWebSocket endpoint at url,
+// which receives In and sends Out messages; the returned connection sends In
+// and receives Out. url is resolved like Call URLs, and http(s) URLs are
+// dialled as ws(s). Headers and query parameters of opts are sent with the
+// handshake, which the call timeout bounds and which is not retried. The
+// handshake passes through the client's interceptors as a single attempt and
+// uses the dial and TLS settings of its transport. Cancelling ctx closes the
+// connection.
+func DialWebSocket[In, Out any](ctx context.Context, h *HTTPClient, url string, opts ...CallOption) (*WebSocket[Out, In], error) {
+	conn, err := h.dialWebSocket(ctx, url, opts)
+	if err != nil {
+		return nil, err
+	}
+	if h.validateContracts {
+		conn.checkIn = func(msg interface{}) error {
+			return h.checkContract(ContractResponse, "", msg)
+		}
+		conn.checkOut = func(msg interface{}) error {
+			return h.checkContract(ContractRequest, "", msg)
+		}
+	}
+	ws := &WebSocket[Out, In]{}
+	ws.bind(conn)
+	return ws, nil
+}
+
+func (h *HTTPClient) dialWebSocket(ctx context.Context, rawURL string, opts []CallOption) (*wsConn, error) {
+	co := h.newCallOptions(opts)
+	resolved, err := h.resolveURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(withCallInfo(ctx, CallInfo{Scope: ScopeCall, MaxAttempts: 1}), http.MethodGet, resolved, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	co.apply(req)
+
+	// The handshake is a single attempt through both interceptor chains, so
+	// they can authenticate and trace it like any call
+	var ws *websocket.Conn
+	var dialed string
+	dial := func(req *http.Request) (*http.Response, error) {
+		target := *req.URL
+		switch target.Scheme {
+		case "http":
+			target.Scheme = "ws"
+		case "https":
+			target.Scheme = "wss"
+		}
+		dialed = target.Redacted()
+		conn, resp, err := h.webSocketDialer(co.timeout).DialContext(req.Context(), target.String(), req.Header)
+		ws = conn
+		if errors.Is(err, websocket.ErrBadHandshake) && resp != nil {
+			// A refused upgrade is a response, as with http.Client.Do
+			return resp, nil
+		}
+		return resp, err
+	}
+	release := func() {}
+	attempt := func(req *http.Request) (*http.Response, error) {
+		attemptReq := req.Clone(withCallInfo(req.Context(), CallInfo{Scope: ScopeAttempt, Attempt: 1, MaxAttempts: 1}))
+		var err error
+		if release, err = h.pickEndpoint(attemptReq, req.URL.Host, map[string]bool{}); err != nil {
+			release = func() {}
+			return nil, err
+		}
+		return chainInterceptors(dial, h.attemptInterceptors)(attemptReq)
+	}
+	resp, err := chainInterceptors(attempt, h.callInterceptors)(req)
+	if err != nil || ws == nil {
+		if ws != nil {
+			ws.Close()
+		}
+		release()
+		if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, co.errorBodyLimit()))
+			return nil, newResponseError(resp.StatusCode, body)
+		}
+		if err == nil {
+			err = errors.New("no connection was established")
+		}
+		return nil, fmt.Errorf("websocket dial failed: %w", err)
+	}
+	logger.InfoContext(ctx, "WebSocket connected", logger.String("url", dialed))
+	return newWSConn(ctx, ws, 0, release), nil
+}
+
+// webSocketDialer returns a dialer with the proxy, dial and TLS settings of
+// the client's transport, or of http.DefaultTransport when the client uses
+// another kind of RoundTripper
+func (h *HTTPClient) webSocketDialer(timeout time.Duration) *websocket.Dialer {
+	transport, ok := h.client.Transport.(*http.Transport)
+	if !ok {
+		transport = http.DefaultTransport.(*http.Transport)
+	}
+	dialer := &websocket.Dialer{
+		Proxy:             transport.Proxy,
+		NetDialContext:    transport.DialContext,
+		NetDialTLSContext: transport.DialTLSContext,
+		HandshakeTimeout:  timeout,
+	}
+	if transport.TLSClientConfig != nil {
+		dialer.TLSClientConfig = transport.TLSClientConfig.Clone()
+	}
+	return dialer
+}
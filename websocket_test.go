@@ -0,0 +1,271 @@
+package httpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+	logger "github.com/T-Prohmpossadhorn/go-core-logger"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// chatService adds WebSockets that fail and wait for shutdown to ChatService
+type chatService struct {
+	ChatService
+	watching chan struct{} // Closed once Watch waits for shutdown
+}
+
+func (s *chatService) Fail(ctx context.Context, ws *WebSocket[ChatMessage, ChatReply]) error {
+	if _, err := ws.Receive(); err != nil {
+		return err
+	}
+	return errors.New("room closed")
+}
+
+func (s *chatService) Watch(ctx context.Context, ws *WebSocket[ChatMessage, ChatReply]) error {
+	close(s.watching)
+	<-ctx.Done()
+	return nil
+}
+
+func (s *chatService) RegisterMethods() []MethodInfo {
+	methods := []MethodInfo{{Name: "Echo"}, {Name: "Fail"}, {Name: "Watch"}}
+	for i := range methods {
+		methods[i].Kind = MethodWebSocket
+		methods[i].InputType = reflect.TypeOf(ChatMessage{})
+		methods[i].OutputType = reflect.TypeOf(ChatReply{})
+		methods[i].Func = reflect.ValueOf(s).MethodByName(methods[i].Name)
+	}
+	return methods
+}
+
+// badWebSocketService declares a WebSocket method given by its fields
+type badWebSocketService struct {
+	method MethodInfo
+}
+
+func (s badWebSocketService) Reply(in ChatMessage) (ChatReply, error) {
+	return ChatReply{Text: in.Text}, nil
+}
+
+func (s badWebSocketService) Chat(ws *WebSocket[ChatMessage, ChatReply]) error {
+	return nil
+}
+
+func (s badWebSocketService) RegisterMethods() []MethodInfo {
+	m := s.method
+	m.Kind = MethodWebSocket
+	m.Func = reflect.ValueOf(s).MethodByName(m.Name)
+	return []MethodInfo{m}
+}
+
+// newWebSocketServer serves a chatService at /chat with the given settings
+func newWebSocketServer(t *testing.T, settings map[string]interface{}) (*Server, *httptest.Server, *chatService) {
+	settings["otel_enabled"] = false
+	settings["port"] = 8080
+	c, err := config.New(config.WithDefault(settings))
+	require.NoError(t, err)
+	srv, err := NewServer(c)
+	require.NoError(t, err)
+	svc := &chatService{watching: make(chan struct{})}
+	require.NoError(t, srv.RegisterService(svc, WithPathPrefix("/chat")))
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return srv, ts, svc
+}
+
+// dialChat opens a chat WebSocket at path of ts
+func dialChat(t *testing.T, ts *httptest.Server, path string, opts ...CallOption) (*WebSocket[ChatReply, ChatMessage], error) {
+	c, err := config.New(config.WithDefault(map[string]interface{}{"otel_enabled": false}))
+	require.NoError(t, err)
+	client, err := NewHTTPClient(c)
+	require.NoError(t, err)
+	return DialWebSocket[ChatMessage, ChatReply](context.Background(), client, ts.URL+path, opts...)
+}
+
+func TestWebSocket(t *testing.T) {
+	os.Setenv("CONFIG_LOGGER_LEVEL", "info")
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	t.Run("Exchange Messages", func(t *testing.T) {
+		_, ts, _ := newWebSocketServer(t, map[string]interface{}{})
+		ws, err := dialChat(t, ts, "/chat/Echo")
+		require.NoError(t, err)
+		defer ws.Close()
+
+		require.NoError(t, ws.Send(ChatMessage{Text: "hello"}))
+		reply, err := ws.Receive()
+		require.NoError(t, err)
+		require.Equal(t, ChatReply{Text: "HELLO"}, reply)
+
+		// Invalid messages are reported to the handler, which keeps the connection
+		require.NoError(t, ws.Send(ChatMessage{}))
+		reply, err = ws.Receive()
+		require.NoError(t, err)
+		require.Equal(t, "invalid websocket message: validation failed: text is a required field", reply.Error)
+
+		require.NoError(t, ws.Send(ChatMessage{Text: "again"}))
+		reply, err = ws.Receive()
+		require.NoError(t, err)
+		require.Equal(t, "AGAIN", reply.Text)
+
+		require.NoError(t, ws.Close())
+		_, err = ws.Receive()
+		require.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("Handler Error", func(t *testing.T) {
+		_, ts, _ := newWebSocketServer(t, map[string]interface{}{})
+		ws, err := dialChat(t, ts, "/chat/Fail")
+		require.NoError(t, err)
+		defer ws.Close()
+
+		require.NoError(t, ws.Send(ChatMessage{Text: "hi"}))
+		_, err = ws.Receive()
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		require.Equal(t, websocket.CloseInternalServerErr, closeErr.Code)
+		require.Equal(t, "room closed", closeErr.Text)
+	})
+
+	t.Run("Origin Check", func(t *testing.T) {
+		_, ts, _ := newWebSocketServer(t, map[string]interface{}{
+			"websocket_allowed_origins": "https://app.example.com, https://admin.example.com",
+		})
+		_, err := dialChat(t, ts, "/chat/Echo", WithHeader("Origin", "https://evil.example.com"))
+		var respErr *ResponseError
+		require.ErrorAs(t, err, &respErr)
+		require.Equal(t, http.StatusForbidden, respErr.StatusCode)
+
+		for _, origin := range []string{"https://admin.example.com", ts.URL} {
+			ws, err := dialChat(t, ts, "/chat/Echo", WithHeader("Origin", origin))
+			require.NoError(t, err, origin)
+			ws.Close()
+		}
+	})
+
+	t.Run("Interceptors And Transport", func(t *testing.T) {
+		srv, _, _ := newWebSocketServer(t, map[string]interface{}{})
+		var auth, requestID string
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth, requestID = r.Header.Get("Authorization"), r.Header.Get("X-Request-ID")
+			srv.Handler().ServeHTTP(w, r)
+		}))
+		defer ts.Close()
+
+		var scopes []InterceptorScope
+		recordScope := func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				info, _ := CallInfoFromContext(req.Context())
+				scopes = append(scopes, info.Scope)
+				return next(req)
+			}
+		}
+		c, err := config.New(config.WithDefault(map[string]interface{}{"otel_enabled": false}))
+		require.NoError(t, err)
+		client, err := NewHTTPClient(c, WithCallInterceptor(recordScope), WithInterceptor(recordScope, BearerAuthInterceptor("secret")))
+		require.NoError(t, err)
+
+		// The handshake fails without the transport trusting the test certificate
+		_, err = DialWebSocket[ChatMessage, ChatReply](context.Background(), client, ts.URL+"/chat/Echo")
+		require.ErrorContains(t, err, "certificate")
+
+		client.client.Transport = ts.Client().Transport
+		scopes = nil
+		ws, err := DialWebSocket[ChatMessage, ChatReply](context.Background(), client, ts.URL+"/chat/Echo")
+		require.NoError(t, err)
+		defer ws.Close()
+		require.Equal(t, "Bearer secret", auth)
+		require.NotEmpty(t, requestID)
+		require.Equal(t, []InterceptorScope{ScopeCall, ScopeAttempt}, scopes)
+
+		require.NoError(t, ws.Send(ChatMessage{Text: "tls"}))
+		reply, err := ws.Receive()
+		require.NoError(t, err)
+		require.Equal(t, "TLS", reply.Text)
+	})
+
+	t.Run("Read Limit", func(t *testing.T) {
+		_, ts, _ := newWebSocketServer(t, map[string]interface{}{"websocket_read_limit": 64})
+		ws, err := dialChat(t, ts, "/chat/Echo")
+		require.NoError(t, err)
+		defer ws.Close()
+
+		require.NoError(t, ws.Send(ChatMessage{Text: strings.Repeat("a", 100)}))
+		_, err = ws.Receive()
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		require.Equal(t, websocket.CloseMessageTooBig, closeErr.Code)
+	})
+
+	t.Run("Ping Keeps Idle Connections", func(t *testing.T) {
+		_, ts, _ := newWebSocketServer(t, map[string]interface{}{"websocket_ping_interval_ms": 20})
+		ws, err := dialChat(t, ts, "/chat/Echo")
+		require.NoError(t, err)
+		defer ws.Close()
+
+		// Idle for several times the pong wait, kept open by the client's pongs
+		time.Sleep(200 * time.Millisecond)
+		require.NoError(t, ws.Send(ChatMessage{Text: "still here"}))
+		reply, err := ws.Receive()
+		require.NoError(t, err)
+		require.Equal(t, "STILL HERE", reply.Text)
+	})
+
+	t.Run("Graceful Shutdown", func(t *testing.T) {
+		srv, ts, svc := newWebSocketServer(t, map[string]interface{}{})
+		ws, err := dialChat(t, ts, "/chat/Watch")
+		require.NoError(t, err)
+		<-svc.watching
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		require.NoError(t, srv.Shutdown(ctx))
+		_, err = ws.Receive()
+		require.ErrorIs(t, err, io.EOF, "the client sees a going away close")
+
+		_, err = dialChat(t, ts, "/chat/Echo")
+		var respErr *ResponseError
+		require.ErrorAs(t, err, &respErr)
+		require.Equal(t, http.StatusServiceUnavailable, respErr.StatusCode)
+	})
+
+	t.Run("Invalid Methods", func(t *testing.T) {
+		for _, m := range []MethodInfo{
+			{Name: "Reply", InputType: reflect.TypeOf(ChatMessage{}), OutputType: reflect.TypeOf(ChatReply{})},
+			{Name: "Chat", InputType: reflect.TypeOf(ChatReply{}), OutputType: reflect.TypeOf(ChatReply{})},
+			{Name: "Chat", HTTPMethod: "POST", InputType: reflect.TypeOf(ChatMessage{}), OutputType: reflect.TypeOf(ChatReply{})},
+		} {
+			_, err := getServiceInfo(badWebSocketService{method: m})
+			require.Error(t, err, m.Name)
+		}
+		methods, err := getServiceInfo(badWebSocketService{method: MethodInfo{
+			Name: "Chat", InputType: reflect.TypeOf(ChatMessage{}), OutputType: reflect.TypeOf(ChatReply{}),
+		}})
+		require.NoError(t, err)
+		require.Equal(t, http.MethodGet, methods[0].HTTPMethod)
+	})
+
+	t.Run("Documented Endpoint", func(t *testing.T) {
+		_, ts, _ := newWebSocketServer(t, map[string]interface{}{})
+		doc, _ := fetchDocument(t, ts)
+		operation := doc["paths"].(map[string]interface{})["/chat/Echo"].(map[string]interface{})["get"].(map[string]interface{})
+		require.Contains(t, operation["responses"], "101")
+		require.NotContains(t, operation, "parameters")
+		require.Equal(t, map[string]interface{}{
+			"clientMessage": map[string]interface{}{"$ref": "#/components/schemas/ChatMessage"},
+			"serverMessage": map[string]interface{}{"$ref": "#/components/schemas/ChatReply"},
+		}, operation["x-websocket"])
+	})
+}